@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/raymondwongso/carddeck/config"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/spf13/cobra"
+)
+
+// apiKeyCommand returns apikey command managing API keys directly in the database
+func apiKeyCommand() *cobra.Command {
+	apiKeyCmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Create and revoke API keys authenticating clients of the server",
+	}
+
+	apiKeyCmd.AddCommand(func() *cobra.Command {
+		var name, tenantID string
+
+		createCmd := &cobra.Command{
+			Use:   "create",
+			Short: "Create new API key of the tenant, the key is only shown once",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cmd.SilenceUsage = true
+				return createAPIKey(cmd.Context(), cmd.OutOrStdout(), name, tenantID)
+			},
+		}
+		createCmd.Flags().StringVar(&name, "name", "", "name describing owner of the key, e.g. studio name")
+		createCmd.Flags().StringVar(&tenantID, "tenant", "", "ID of tenant of the key, new tenant named after the key is created when empty")
+
+		return createCmd
+	}())
+
+	apiKeyCmd.AddCommand(&cobra.Command{
+		Use:   "revoke [api key id]",
+		Short: "Revoke API key, other API keys of its tenant keep working",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return revokeAPIKey(cmd.Context(), args[0])
+		},
+	})
+
+	return apiKeyCmd
+}
+
+func createAPIKey(ctx context.Context, w io.Writer, name, tenantID string) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	apiKey, err := carddeck.BuildService(config, db).CreateAPIKey(ctx, name, tenantID)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTENANT\tNAME\tKEY")
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", apiKey.ID, apiKey.TenantID, apiKey.Name, apiKey.Key)
+	return tw.Flush()
+}
+
+func revokeAPIKey(ctx context.Context, id string) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return carddeck.BuildService(config, db).RevokeAPIKey(ctx, id)
+}
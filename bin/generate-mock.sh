@@ -2,7 +2,7 @@
 
 set -euo pipefail
 
-for file in `find . -name '*.go' | grep -v /vendor/`; do
+for file in `find . -name '*.go' | grep -v /vendor/ | grep -v '.pb.go$'`; do
     if `grep -q 'interface {' ${file}`; then
         dest=${file//internal\//}
         mockgen -source=${file} -destination=test/mock/${dest}
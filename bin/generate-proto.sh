@@ -0,0 +1,11 @@
+#!/usr/bin/env bash
+
+set -euo pipefail
+
+# requires protoc, protoc-gen-go and protoc-gen-go-grpc
+for file in `find . -name '*.proto' | grep -v /vendor/`; do
+    protoc --proto_path=. \
+        --go_out=. --go_opt=paths=source_relative \
+        --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+        ${file}
+done
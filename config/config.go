@@ -2,22 +2,43 @@
 package config
 
 import (
+	"errors"
+	"slices"
+
 	"github.com/joeshaw/envdecode"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 )
 
 type Config struct {
-	Server   server
-	Postgres postgres
+	Server    server
+	Postgres  postgres
+	Webhook   webhook
+	Auth      auth
+	Tenant    tenant
+	RateLimit rateLimit
+	CORS      cors
+	Security  security
 }
 
 type server struct {
 	Port              string `env:"SERVER_PORT,default=8080"`
+	GRPCPort          string `env:"SERVER_GRPC_PORT,default=9090"`
 	ShutdownTimeout   int    `env:"SERVER_SHUTDOWN_TIMEOUT,default=15"`
 	ReadTimeout       int    `env:"SERVER_READ_TIMEOUT,default=5"`
 	ReadHeaderTimeout int    `env:"SERVER_READ_HEADER_TIMEOUT,default=5"`
 	WriteTimeout      int    `env:"SERVER_WRITE_TIMEOUT,default=5"`
+	// IdempotencyTTL is how long (in seconds) responses of requests with Idempotency-Key header are kept
+	IdempotencyTTL int `env:"SERVER_IDEMPOTENCY_TTL,default=86400"`
+	// MaxBodySize is maximum size in bytes of request bodies, e.g. of deck import and batch operations
+	MaxBodySize int64 `env:"SERVER_MAX_BODY_SIZE,default=1048576"`
+	// PurgeInterval is how often (in seconds) rows no longer needed, such as expired idempotency keys, are deleted
+	PurgeInterval int `env:"SERVER_PURGE_INTERVAL,default=3600"`
+	// DeckEventRetention is how long (in seconds) deck events are kept for replay, 0 keeps them until the deck is deleted
+	DeckEventRetention int `env:"SERVER_DECK_EVENT_RETENTION,default=604800"`
+	// APIKeyRequired rejects requests without X-API-Key header,
+	// otherwise they are served but only see decks created without API key
+	APIKeyRequired bool `env:"SERVER_API_KEY_REQUIRED,default=false"`
 }
 
 type postgres struct {
@@ -26,14 +47,112 @@ type postgres struct {
 	User         string `env:"POSTGRES_USER,default=defaultuser"`
 	Pass         string `env:"POSTGRESS_PASS,default=defaultpass"`
 	DatabaseName string `env:"POSTGRESS_DBNAME,default=carddeck_dev"`
+	// CompactCards stores deck cards using compact encoding instead of array of card objects
+	CompactCards bool `env:"POSTGRES_COMPACT_CARDS,default=false"`
+	// ListenNotify broadcasts deck events to every server instance using LISTEN/NOTIFY,
+	// enable it when running more than one instance
+	ListenNotify bool `env:"POSTGRES_LISTEN_NOTIFY,default=false"`
 	// in production, typically we will have more configuration parameters
 	// such as max_open_conn, max_conn_idle, etc.
 }
 
+type webhook struct {
+	// PollInterval is how often (in seconds) due webhook deliveries are dispatched
+	PollInterval int `env:"WEBHOOK_POLL_INTERVAL,default=5"`
+	// MaxAttempts is number of attempts before a delivery is moved to dead letters
+	MaxAttempts int `env:"WEBHOOK_MAX_ATTEMPTS,default=8"`
+	// Timeout is how long (in seconds) to wait for the webhook URL to respond
+	Timeout int `env:"WEBHOOK_TIMEOUT,default=10"`
+	// AllowPrivateNetworks allows webhook URLs resolving to loopback, private and link-local addresses,
+	// only meant for development since anyone creating a webhook could then reach services of the server network
+	AllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS,default=false"`
+}
+
+type auth struct {
+	// JWKSURL enables bearer tokens signed by keys of JSON Web Key Set fetched from the URL,
+	// typically jwks_uri of the identity provider
+	JWKSURL string `env:"AUTH_JWKS_URL"`
+	// JWKSFile enables bearer tokens signed by keys of JSON Web Key Set stored in the file, used when JWKSURL is empty
+	JWKSFile string `env:"AUTH_JWKS_FILE"`
+	// JWKSRefreshInterval is how often (in seconds) the key set is reloaded to pick up rotated keys
+	JWKSRefreshInterval int `env:"AUTH_JWKS_REFRESH_INTERVAL,default=300"`
+	// Issuer and Audience are expected iss and aud claims of bearer tokens, not verified when empty
+	Issuer   string `env:"AUTH_ISSUER"`
+	Audience string `env:"AUTH_AUDIENCE"`
+	// TenantClaim is name of the bearer token claim holding tenant of the token
+	TenantClaim string `env:"AUTH_TENANT_CLAIM,default=sub"`
+}
+
+// TokensEnabled reports whether a key set is configured, requests then need a bearer token or API key
+func (a auth) TokensEnabled() bool {
+	return a.JWKSURL != "" || a.JWKSFile != ""
+}
+
+type tenant struct {
+	// MaxActiveDecks is default maximum number of decks a tenant has not deleted, 0 means unlimited
+	MaxActiveDecks int `env:"TENANT_MAX_ACTIVE_DECKS,default=0"`
+	// MaxRequestsPerMinute is default maximum number of REST requests of a tenant per minute on each instance, 0 means unlimited
+	MaxRequestsPerMinute int `env:"TENANT_MAX_REQUESTS_PER_MINUTE,default=0"`
+}
+
+// rateLimit configures token buckets of each client per group of routes, refilled by Rate requests per second up to Burst requests.
+// 0 rate disables limiting of the group.
+type rateLimit struct {
+	// Create limits creating and deleting decks and webhooks, including import and batch operations
+	CreateRate  float64 `env:"RATE_LIMIT_CREATE_RATE,default=2"`
+	CreateBurst int     `env:"RATE_LIMIT_CREATE_BURST,default=10"`
+	// Draw limits drawing, shuffling and returning cards, and GraphQL
+	DrawRate  float64 `env:"RATE_LIMIT_DRAW_RATE,default=10"`
+	DrawBurst int     `env:"RATE_LIMIT_DRAW_BURST,default=20"`
+	// Read limits getting, watching and exporting decks, and listing webhook dead letters
+	ReadRate  float64 `env:"RATE_LIMIT_READ_RATE,default=20"`
+	ReadBurst int     `env:"RATE_LIMIT_READ_BURST,default=40"`
+	// IP limits every authenticated route by client IP before authentication, including requests with invalid credentials
+	IPRate  float64 `env:"RATE_LIMIT_IP_RATE,default=50"`
+	IPBurst int     `env:"RATE_LIMIT_IP_BURST,default=100"`
+	// ClientIPHeader is header holding IP of clients set by the reverse proxy, e.g. X-Forwarded-For.
+	// Clients not authenticated are limited by remote address of the connection when empty.
+	ClientIPHeader string `env:"RATE_LIMIT_CLIENT_IP_HEADER"`
+	// TrustedProxies is number of reverse proxies appending to ClientIPHeader,
+	// the address appended by the outermost one is the client IP
+	TrustedProxies int `env:"RATE_LIMIT_TRUSTED_PROXIES,default=1"`
+}
+
+// cors configures cross-origin requests of browsers, lists are separated by ";"
+type cors struct {
+	// AllowedOrigins are origins allowed to call the REST API, e.g. https://game.example.com, "*" allows every origin.
+	// Empty disables CORS.
+	AllowedOrigins   []string `env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `env:"CORS_ALLOWED_METHODS,default=GET;POST;DELETE"`
+	AllowedHeaders   []string `env:"CORS_ALLOWED_HEADERS,default=Accept;Accept-Language;Authorization;Content-Type;Idempotency-Key;If-Match;If-Modified-Since;If-None-Match;Last-Event-ID;X-API-Key"`
+	ExposedHeaders   []string `env:"CORS_EXPOSED_HEADERS,default=ETag;Idempotent-Replayed;RateLimit-Limit;RateLimit-Remaining;RateLimit-Reset;RateLimit-Policy;Retry-After"`
+	AllowCredentials bool     `env:"CORS_ALLOW_CREDENTIALS,default=false"`
+	// MaxAge is how long (in seconds) browsers cache preflight responses
+	MaxAge int `env:"CORS_MAX_AGE,default=600"`
+}
+
+// validate rejects "*" origin allowing credentials, which would let every site make requests on behalf of the user
+func (c cors) validate() error {
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		return errors.New(`CORS_ALLOW_CREDENTIALS can not be used with "*" in CORS_ALLOWED_ORIGINS, list the allowed origins instead`)
+	}
+	return nil
+}
+
+// security configures security headers of every REST response
+type security struct {
+	ContentSecurityPolicy string `env:"SECURITY_CONTENT_SECURITY_POLICY,default=default-src 'none'; frame-ancestors 'none'"`
+	FrameOptions          string `env:"SECURITY_FRAME_OPTIONS,default=DENY"`
+	ReferrerPolicy        string `env:"SECURITY_REFERRER_POLICY,default=no-referrer"`
+	// HSTSMaxAge is max-age (in seconds) of Strict-Transport-Security header, 0 disables it.
+	// Only enable it when the server is reached through HTTPS.
+	HSTSMaxAge int `env:"SECURITY_HSTS_MAX_AGE,default=0"`
+}
+
 // Load returns config object that is populated from env variables and additional
 // env provided in filepath.
 // filepath is the path to additional env files
-// return error if decoding failed, in which it is advised to abort further operation
+// return error if decoding or validation failed, in which it is advised to abort further operation
 func Load(filepath ...string) (*Config, error) {
 	// load additional env from specified filepath
 	if len(filepath) > 0 {
@@ -46,5 +165,10 @@ func Load(filepath ...string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := config.CORS.validate(); err != nil {
+		log.Error().Err(err).Msg("invalid env")
+		return nil, err
+	}
+
 	return &config, nil
 }
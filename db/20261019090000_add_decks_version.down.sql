@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public.decks DROP COLUMN IF EXISTS "version";
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE public.decks ADD COLUMN IF NOT EXISTS "version" BIGINT NOT NULL DEFAULT 1;
+
+COMMIT;
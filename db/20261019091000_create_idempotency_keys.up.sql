@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.idempotency_keys (
+  "key" VARCHAR(255) PRIMARY KEY,
+  "fingerprint" VARCHAR(255) NOT NULL,
+  "status_code" INTEGER NOT NULL DEFAULT 0,
+  "header" JSONB,
+  "body" BYTEA,
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+  "expires_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.deck_events (
+  "id" BIGSERIAL PRIMARY KEY,
+  "deck_id" VARCHAR(255) NOT NULL,
+  "type" VARCHAR(64) NOT NULL,
+  "payload" JSONB NOT NULL,
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS deck_events_deck_id_id_idx ON public.deck_events ("deck_id", "id");
+
+COMMIT;
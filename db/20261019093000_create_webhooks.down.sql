@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP VIEW IF EXISTS public.webhook_dead_letters;
+DROP TABLE IF EXISTS public.webhook_deliveries;
+DROP TABLE IF EXISTS public.webhooks;
+
+COMMIT;
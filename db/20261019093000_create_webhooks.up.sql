@@ -0,0 +1,34 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS public.webhooks (
+  "id" VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid(),
+  "url" TEXT NOT NULL,
+  "events" JSONB NOT NULL,
+  "secret" TEXT NOT NULL,
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- outbox of webhook deliveries, rows are inserted in the same transaction as the deck change
+CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+  "id" BIGSERIAL PRIMARY KEY,
+  "webhook_id" VARCHAR(255) NOT NULL REFERENCES public.webhooks ("id") ON DELETE CASCADE,
+  "event_type" VARCHAR(64) NOT NULL,
+  "payload" JSONB NOT NULL,
+  "status" VARCHAR(16) NOT NULL DEFAULT 'pending',
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "next_attempt_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+  "last_status_code" INTEGER NOT NULL DEFAULT 0,
+  "last_error" TEXT NOT NULL DEFAULT '',
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+  "updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON public.webhook_deliveries ("next_attempt_at") WHERE "status" = 'pending';
+
+-- deliveries that failed every attempt
+CREATE OR REPLACE VIEW public.webhook_dead_letters AS
+  SELECT "id", "webhook_id", "event_type", "payload", "attempts", "last_status_code", "last_error", "created_at", "updated_at"
+  FROM public.webhook_deliveries
+  WHERE "status" = 'dead';
+
+COMMIT;
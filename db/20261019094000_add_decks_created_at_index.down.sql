@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS public.decks_created_at_id_idx;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- decks are listed from the newest
+CREATE INDEX IF NOT EXISTS decks_created_at_id_idx ON public.decks ("created_at" DESC, "id");
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS public.decks_owner_id_idx;
+ALTER TABLE public.decks DROP COLUMN IF EXISTS "owner_id";
+DROP TABLE IF EXISTS public.api_keys;
+
+COMMIT;
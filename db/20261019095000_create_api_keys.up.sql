@@ -0,0 +1,17 @@
+BEGIN;
+
+-- only SHA-256 hash of the key is stored, revoked keys are kept so decks stay owned by them
+CREATE TABLE IF NOT EXISTS public.api_keys (
+  "id" VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid(),
+  "name" VARCHAR(255) NOT NULL DEFAULT '',
+  "key_hash" VARCHAR(64) NOT NULL UNIQUE,
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+  "revoked_at" TIMESTAMP WITHOUT TIME ZONE
+);
+
+-- decks created without API key have no owner and stay visible to everyone
+ALTER TABLE public.decks ADD COLUMN IF NOT EXISTS "owner_id" VARCHAR(255) REFERENCES public.api_keys ("id");
+
+CREATE INDEX IF NOT EXISTS decks_owner_id_idx ON public.decks ("owner_id");
+
+COMMIT;
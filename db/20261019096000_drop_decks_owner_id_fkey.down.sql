@@ -0,0 +1,6 @@
+BEGIN;
+
+-- fails when decks are owned by subjects of bearer tokens, delete or reassign them first
+ALTER TABLE public.decks ADD CONSTRAINT decks_owner_id_fkey FOREIGN KEY ("owner_id") REFERENCES public.api_keys ("id");
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- decks are also owned by subjects of bearer tokens, which are not stored in api_keys
+ALTER TABLE public.decks DROP CONSTRAINT IF EXISTS decks_owner_id_fkey;
+
+COMMIT;
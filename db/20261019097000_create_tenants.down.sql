@@ -0,0 +1,16 @@
+BEGIN;
+
+DROP POLICY IF EXISTS decks_tenant_isolation ON public.decks;
+ALTER TABLE public.decks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE public.decks DISABLE ROW LEVEL SECURITY;
+
+ALTER INDEX IF EXISTS public.decks_tenant_id_idx RENAME TO decks_owner_id_idx;
+ALTER TABLE public.decks RENAME COLUMN "tenant_id" TO "owner_id";
+
+DROP INDEX IF EXISTS public.webhooks_tenant_id_idx;
+ALTER TABLE public.webhooks DROP COLUMN IF EXISTS "tenant_id";
+
+ALTER TABLE public.api_keys DROP COLUMN IF EXISTS "tenant_id";
+DROP TABLE IF EXISTS public.tenants;
+
+COMMIT;
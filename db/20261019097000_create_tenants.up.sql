@@ -0,0 +1,34 @@
+BEGIN;
+
+-- quota columns override defaults of the server when not NULL
+CREATE TABLE IF NOT EXISTS public.tenants (
+  "id" VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid(),
+  "name" VARCHAR(255) NOT NULL DEFAULT '',
+  "max_active_decks" INTEGER,
+  "max_requests_per_minute" INTEGER,
+  "created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- every existing API key becomes a tenant with the same ID, so its decks stay with the tenant
+INSERT INTO public.tenants ("id", "name", "created_at") SELECT "id", "name", "created_at" FROM public.api_keys ON CONFLICT DO NOTHING;
+ALTER TABLE public.api_keys ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(255) REFERENCES public.tenants ("id");
+UPDATE public.api_keys SET "tenant_id" = "id" WHERE "tenant_id" IS NULL;
+ALTER TABLE public.api_keys ALTER COLUMN "tenant_id" SET NOT NULL;
+
+-- decks are not referencing tenants, tenants of bearer tokens only exist in the identity provider
+ALTER TABLE public.decks RENAME COLUMN "owner_id" TO "tenant_id";
+ALTER INDEX IF EXISTS public.decks_owner_id_idx RENAME TO decks_tenant_id_idx;
+
+-- webhooks only receive events of decks of their tenant, webhooks created without tenant receive events of decks without tenant
+ALTER TABLE public.webhooks ADD COLUMN IF NOT EXISTS "tenant_id" VARCHAR(255);
+CREATE INDEX IF NOT EXISTS webhooks_tenant_id_idx ON public.webhooks ("tenant_id");
+
+-- row level security is defense in depth in addition to the tenant condition of every query.
+-- The server sets carddeck.tenant_id in the transaction of every query, unset or empty means no tenant.
+-- FORCE applies the policy to the owner of the table too, superusers still bypass it.
+ALTER TABLE public.decks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.decks FORCE ROW LEVEL SECURITY;
+CREATE POLICY decks_tenant_isolation ON public.decks
+  USING ("tenant_id" IS NULL OR "tenant_id" = current_setting('carddeck.tenant_id', true));
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS public.idempotency_keys_expires_at_idx;
+
+COMMIT;
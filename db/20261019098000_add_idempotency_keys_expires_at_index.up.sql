@@ -0,0 +1,6 @@
+BEGIN;
+
+-- expired keys are purged periodically
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON public.idempotency_keys ("expires_at");
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS public.deck_events_created_at_idx;
+ALTER TABLE public.deck_events DROP CONSTRAINT IF EXISTS deck_events_deck_id_fkey;
+ALTER TABLE public.decks DROP CONSTRAINT IF EXISTS decks_pkey;
+
+COMMIT;
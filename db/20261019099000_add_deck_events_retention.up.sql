@@ -0,0 +1,19 @@
+BEGIN;
+
+-- deck IDs are always generated, the key lets deck events reference their deck
+ALTER TABLE public.decks ADD CONSTRAINT decks_pkey PRIMARY KEY ("id");
+
+-- events of decks deleted before this migration are not replayed anymore.
+-- Row level security is lifted for the owner, so decks of every tenant are seen.
+ALTER TABLE public.decks NO FORCE ROW LEVEL SECURITY;
+DELETE FROM public.deck_events WHERE NOT EXISTS (SELECT 1 FROM public.decks WHERE decks.id = deck_events.deck_id);
+ALTER TABLE public.decks FORCE ROW LEVEL SECURITY;
+
+-- events are deleted together with their deck
+ALTER TABLE public.deck_events ADD CONSTRAINT deck_events_deck_id_fkey
+  FOREIGN KEY ("deck_id") REFERENCES public.decks ("id") ON DELETE CASCADE;
+
+-- events older than SERVER_DECK_EVENT_RETENTION are purged periodically
+CREATE INDEX IF NOT EXISTS deck_events_created_at_idx ON public.deck_events ("created_at");
+
+COMMIT;
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/client"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/spf13/cobra"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatGlyph = "glyph"
+
+	// urlEnv overrides default base URL of the server used by deck commands
+	urlEnv     = "CARDDECK_URL"
+	defaultURL = "http://localhost:8080"
+	// apiKeyEnv sets API key sent by deck and play commands
+	apiKeyEnv = "CARDDECK_API_KEY"
+)
+
+// deckCommand returns deck command driving decks of a running server through its REST API
+func deckCommand() *cobra.Command {
+	var (
+		baseURL string
+		apiKey  string
+		format  string
+	)
+
+	deckCmd := &cobra.Command{
+		Use:   "deck",
+		Short: "Create, get, draw and shuffle decks of a running server",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case formatTable, formatJSON, formatGlyph:
+			default:
+				return fmt.Errorf("unknown format %q, use %s, %s or %s", format, formatTable, formatJSON, formatGlyph)
+			}
+
+			// arguments are valid, errors from now on are not caused by usage
+			cmd.SilenceUsage = true
+			return nil
+		},
+	}
+
+	deckCmd.PersistentFlags().StringVar(&baseURL, "url", defaultBaseURL(), fmt.Sprintf("base URL of the server, defaults to %s env", urlEnv))
+	deckCmd.PersistentFlags().StringVar(&apiKey, "api-key", os.Getenv(apiKeyEnv), fmt.Sprintf("API key of the server, defaults to %s env", apiKeyEnv))
+	deckCmd.PersistentFlags().StringVarP(&format, "format", "f", formatTable, "output format: table, json or glyph")
+
+	deckCmd.AddCommand(func() *cobra.Command {
+		var (
+			shuffled bool
+			cards    []string
+		)
+
+		createCmd := &cobra.Command{
+			Use:   "create",
+			Short: "Create new deck, containing the full deck unless cards are specified",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				deck, err := client.New(baseURL, client.WithAPIKey(apiKey)).CreateDeck(cmd.Context(), shuffled, cards)
+				if err != nil {
+					return err
+				}
+				return printDeckSummary(cmd.OutOrStdout(), format, deck)
+			},
+		}
+		createCmd.Flags().BoolVar(&shuffled, "shuffled", false, "shuffle the deck")
+		createCmd.Flags().StringSliceVar(&cards, "cards", nil, "comma separated card codes, e.g. AS,KD,10H")
+
+		return createCmd
+	}())
+
+	deckCmd.AddCommand(&cobra.Command{
+		Use:   "get [deck id]",
+		Short: "Get deck together with its remaining cards",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			deck, err := client.New(baseURL, client.WithAPIKey(apiKey)).GetDeck(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return printDeck(cmd.OutOrStdout(), format, deck)
+		},
+	})
+
+	deckCmd.AddCommand(func() *cobra.Command {
+		var (
+			count   int64
+			version int64
+		)
+
+		drawCmd := &cobra.Command{
+			Use:   "draw [deck id]",
+			Short: "Draw cards from the top of the deck",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				drawn, err := client.New(baseURL, client.WithAPIKey(apiKey)).DrawCards(cmd.Context(), args[0], count, version)
+				if err != nil {
+					return err
+				}
+				return printDrawnCards(cmd.OutOrStdout(), format, drawn)
+			},
+		}
+		drawCmd.Flags().Int64VarP(&count, "count", "n", 1, "number of cards to draw")
+		drawCmd.Flags().Int64Var(&version, "version", 0, "only draw if the deck is still at this version")
+
+		return drawCmd
+	}())
+
+	deckCmd.AddCommand(func() *cobra.Command {
+		var version int64
+
+		shuffleCmd := &cobra.Command{
+			Use:   "shuffle [deck id]",
+			Short: "Shuffle remaining cards of the deck",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				deck, err := client.New(baseURL, client.WithAPIKey(apiKey)).ShuffleDeck(cmd.Context(), args[0], version)
+				if err != nil {
+					return err
+				}
+				return printDeckSummary(cmd.OutOrStdout(), format, deck)
+			},
+		}
+		shuffleCmd.Flags().Int64Var(&version, "version", 0, "only shuffle if the deck is still at this version")
+
+		return shuffleCmd
+	}())
+
+	return deckCmd
+}
+
+// defaultBaseURL returns base URL of the server from the env, falling back to local server
+func defaultBaseURL() string {
+	if url := os.Getenv(urlEnv); url != "" {
+		return url
+	}
+	return defaultURL
+}
+
+func printDeckSummary(w io.Writer, format string, deck *client.DeckSummary) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, struct {
+			*client.DeckSummary
+			Version int64 `json:"version"`
+		}{deck, deck.Version})
+	case formatGlyph:
+		_, err := fmt.Fprintf(w, "%s %s x%d\n", deck.ID, entity.GlyphBack, deck.Remaining)
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSHUFFLED\tREMAINING\tVERSION")
+		fmt.Fprintf(tw, "%s\t%t\t%d\t%d\n", deck.ID, deck.Shuffled, deck.Remaining, deck.Version)
+		return tw.Flush()
+	}
+}
+
+func printDeck(w io.Writer, format string, deck *entity.Deck) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, struct {
+			*entity.Deck
+			Version int64 `json:"version"`
+		}{deck, deck.Version})
+	case formatGlyph:
+		_, err := fmt.Fprintf(w, "%s\n%s\n", deck.ID, glyphs(deck.Cards))
+		return err
+	default:
+		if err := printDeckSummary(w, format, &client.DeckSummary{
+			ID:        deck.ID,
+			Shuffled:  deck.Shuffled,
+			Remaining: int64(deck.Remaining()),
+			Version:   deck.Version,
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		return printCards(w, format, deck.Cards)
+	}
+}
+
+func printDrawnCards(w io.Writer, format string, drawn *client.DrawnCards) error {
+	switch format {
+	case formatJSON:
+		return printJSON(w, struct {
+			*client.DrawnCards
+			Version int64 `json:"version"`
+		}{drawn, drawn.Version})
+	case formatGlyph:
+		return printCards(w, format, drawn.Cards)
+	default:
+		if err := printCards(w, format, drawn.Cards); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\nVERSION  %d\n", drawn.Version)
+		return err
+	}
+}
+
+func printCards(w io.Writer, format string, cards *entity.Cards) error {
+	if cards == nil {
+		cards = &entity.Cards{}
+	}
+
+	switch format {
+	case formatJSON:
+		return printJSON(w, struct {
+			Cards *entity.Cards `json:"cards"`
+		}{cards})
+	case formatGlyph:
+		_, err := fmt.Fprintln(w, glyphs(cards))
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CODE\tVALUE\tSUIT\tCARD")
+		for _, card := range *cards {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", card.Code, card.Val, card.Suit, card.Glyph())
+		}
+		return tw.Flush()
+	}
+}
+
+func glyphs(cards *entity.Cards) string {
+	if cards == nil {
+		return ""
+	}
+
+	glyphs := make([]string, len(*cards))
+	for i, card := range *cards {
+		glyphs[i] = card.Glyph()
+	}
+	return strings.Join(glyphs, " ")
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -15,10 +15,83 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/decks": {
+        "/batch": {
+            "post": {
+                "description": "All operations are executed in a single transaction, if one fails every operation is rolled back.\ndeck_id may reference the deck of a previous operation using \"$\u003cindex\u003e\", e.g. \"$0\".",
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Execute ordered deck operations (create, draw, shuffle, return) atomically",
+                "parameters": [
+                    {
+                        "description": "Ordered list of operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/cards/{file}": {
             "get": {
+                "description": "File is the card code or \"back\" followed by .svg or .png extension, e.g. QH.svg or back.png.",
                 "produces": [
-                    "application/json"
+                    "image/svg+xml",
+                    "image/png"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Get image of card face or card back",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Card code or back, with .svg or .png extension",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card set of the card, searched in every card set when omitted",
+                        "name": "set",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hex color of card back, e.g. 1f4e9c",
+                        "name": "color",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/decks": {
+            "post": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
                 ],
                 "tags": [
                     "carddeck"
@@ -36,6 +109,49 @@ const docTemplate = `{
                         "description": "Specify cards used in this newly created deck",
                         "name": "cards",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Restore deck position and shuffled flag from compact encoding returned by GET /decks/{id}?format=compact",
+                        "name": "compact",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retrying request with the same key replays the first response instead of creating another deck",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/decks/import": {
+            "post": {
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Import deck state from document produced by export, creating a new deck",
+                "parameters": [
+                    {
+                        "description": "Deck export document",
+                        "name": "document",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.DeckExport"
+                        }
                     }
                 ],
                 "responses": {}
@@ -44,7 +160,10 @@ const docTemplate = `{
         "/decks/{id}": {
             "get": {
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
                 ],
                 "tags": [
                     "carddeck"
@@ -57,15 +176,140 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "compact"
+                        ],
+                        "type": "string",
+                        "description": "Response format, use compact to get compact encoding of the deck instead of cards",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "unicode",
+                            "short",
+                            "ascii"
+                        ],
+                        "type": "string",
+                        "description": "Also render every card as Unicode playing card, short notation (e.g. Q♥) or multi-line ASCII art",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of card names and error messages: en, id, ja or es",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of cached deck, 304 is returned when the deck is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of cached deck, ignored when If-None-Match is supplied",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {}
+            },
+            "delete": {
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Delete specific deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete if deck ETag (returned by GET /decks/{id}) still matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
             }
         },
         "/decks/{id}/cards": {
             "get": {
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Draw cards from specific deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of cards to withdraw",
+                        "name": "count",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "unicode",
+                            "short",
+                            "ascii"
+                        ],
+                        "type": "string",
+                        "description": "Also render every card as Unicode playing card, short notation (e.g. Q♥) or multi-line ASCII art",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of card names and error messages: en, id, ja or es",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only draw if deck ETag (returned by GET /decks/{id}) still matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retrying request with the same key replays the first response instead of drawing again",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
                 ],
                 "tags": [
                     "carddeck"
@@ -85,10 +329,510 @@ const docTemplate = `{
                         "name": "count",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "unicode",
+                            "short",
+                            "ascii"
+                        ],
+                        "type": "string",
+                        "description": "Also render every card as Unicode playing card, short notation (e.g. Q♥) or multi-line ASCII art",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of card names and error messages: en, id, ja or es",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only draw if deck ETag (returned by GET /decks/{id}) still matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retrying request with the same key replays the first response instead of drawing again",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/decks/{id}/events": {
+            "get": {
+                "description": "Sends an event every time the deck is drawn from, shuffled or returned to.\nReconnecting clients sending Last-Event-ID header receive the events they missed first.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Stream changes of specific deck as Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, events after it are replayed",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.DeckEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/decks/{id}/export": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Export deck state into portable versioned document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/decks/{id}/return": {
+            "post": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Return cards to the bottom of specific deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated codes of cards returned to the deck",
+                        "name": "cards",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return cards if deck ETag (returned by GET /decks/{id}) still matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retrying request with the same key replays the first response instead of returning cards again",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/decks/{id}/shuffle": {
+            "post": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor",
+                    "application/protobuf"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Shuffle remaining cards of specific deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only shuffle if deck ETag (returned by GET /decks/{id}) still matches",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retrying request with the same key replays the first response instead of shuffling again",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {}
             }
+        },
+        "/decks/{id}/ws": {
+            "get": {
+                "description": "Pushes a JSON event every time the deck is drawn from, shuffled or returned to.\nThe connection is closed by the server when the client falls too far behind or the server shuts down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "carddeck"
+                ],
+                "summary": "Watch changes of specific deck over WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the deck",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/entity.DeckEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "post": {
+                "description": "Every delivery is a POST of JSON payload signed with HMAC-SHA256 of the secret, see README.",
+                "consumes": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Subscribe URL to deck lifecycle events",
+                "parameters": [
+                    {
+                        "description": "URL, subscribed events (deck.created, deck.exhausted, deck.deleted) and secret",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/rest.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Webhook"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete webhook together with its pending and dead deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/dead-letters": {
+            "get": {
+                "produces": [
+                    "application/json",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List deliveries of webhook that failed every attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/rest.WebhookDeadLettersResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "shuffled": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "description": "Version is the deck version expected by the client, equivalent to If-Match header. 0 means any version.",
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.Card": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "suit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.DeckEvent": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "description": "Cards contains the drawn or returned cards, empty for shuffle.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Card"
+                    }
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is assigned when the event is persisted, it increases with every event.",
+                    "type": "integer"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "shuffled": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.DeckExport": {
+            "type": "object",
+            "properties": {
+                "card_set": {
+                    "type": "string"
+                },
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "checksum": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/entity.DeckExportMetadata"
+                },
+                "shuffled": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.DeckExportMetadata": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.BatchOperation"
+                    }
+                }
+            }
+        },
+        "rest.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret signs every delivery, see X-Carddeck-Signature header. It is never returned.",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "rest.WebhookDeadLettersResponse": {
+            "type": "object",
+            "properties": {
+                "dead_letters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.WebhookDelivery"
+                    }
+                }
+            }
         }
     }
 }`
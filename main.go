@@ -9,9 +9,15 @@ Usage:
 
 Available Commands:
 
+	apikey      Create and revoke API keys authenticating clients of the server
 	completion  Generate the autocompletion script for the specified shell
+	deck        Create, get, draw and shuffle decks of a running server
+	export      Export deck state from database into portable document
 	help        Help about any command
+	import      Import deck state from portable document into database
+	play        Play a deck of a running server in the terminal
 	server      Spin up HTTP Server
+	tenant      Create tenants and set their quota
 
 Flags:
 
@@ -23,7 +29,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -33,12 +42,16 @@ import (
 	"github.com/raymondwongso/carddeck/config"
 	_ "github.com/raymondwongso/carddeck/docs"
 	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
 	"github.com/raymondwongso/carddeck/modules/middleware"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// swaggerCSP is Content-Security-Policy of Swagger UI
+const swaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+
 func main() {
 	root := &cobra.Command{
 		Use:   "carddeck",
@@ -57,6 +70,45 @@ func main() {
 		return serverCmd
 	}())
 
+	root.AddCommand(func() *cobra.Command {
+		var output, tenantID string
+
+		exportCmd := &cobra.Command{
+			Use:   "export [deck id]",
+			Short: "Export deck state from database into portable document",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return exportDeck(entity.WithTenant(cmd.Context(), tenantID), args[0], output)
+			},
+		}
+		exportCmd.Flags().StringVarP(&output, "output", "o", "", "write document to file instead of stdout")
+		exportCmd.Flags().StringVar(&tenantID, "tenant", "", "ID of tenant owning the deck")
+
+		return exportCmd
+	}())
+
+	root.AddCommand(func() *cobra.Command {
+		var input, tenantID string
+
+		importCmd := &cobra.Command{
+			Use:   "import",
+			Short: "Import deck state from portable document into database",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return importDeck(entity.WithTenant(cmd.Context(), tenantID), input)
+			},
+		}
+		importCmd.Flags().StringVarP(&input, "input", "i", "", "read document from file instead of stdin")
+		importCmd.Flags().StringVar(&tenantID, "tenant", "", "ID of tenant owning the imported deck")
+
+		return importCmd
+	}())
+
+	root.AddCommand(apiKeyCommand())
+	root.AddCommand(tenantCommand())
+	root.AddCommand(deckCommand())
+	root.AddCommand(playCommand())
+
 	if err := root.Execute(); err != nil {
 		log.Fatal().Err(err).Msg("error executing root command")
 	}
@@ -68,25 +120,137 @@ func server() error {
 		return err
 	}
 
-	handler, err := carddeck.BuildHandler(config)
+	db, err := carddeck.Connect(config)
 	if err != nil {
 		return err
 	}
+	defer db.Close()
+
+	hub := carddeck.BuildEventHub()
+	svc := carddeck.BuildServerService(config, db, hub)
+	corsPolicy := middleware.CORSPolicy{
+		AllowedOrigins:   config.CORS.AllowedOrigins,
+		AllowedMethods:   config.CORS.AllowedMethods,
+		AllowedHeaders:   config.CORS.AllowedHeaders,
+		ExposedHeaders:   config.CORS.ExposedHeaders,
+		AllowCredentials: config.CORS.AllowCredentials,
+		MaxAge:           config.CORS.MaxAge,
+	}
+	// WebSocket handshakes are not subject to CORS, the upgrader checks their origin against the same policy
+	handler := carddeck.BuildHandler(config, svc, corsPolicy)
+	verifier := tokenVerifier(context.Background(), config)
+	authentication := authentication(config, verifier, svc)
+	// REST and gRPC API share the counter, so calls of both count against the same quota
+	quota := middleware.NewRequestCounter(svc)
+	requestQuota := middleware.RequestQuota(quota, carddeck.WriteError)
+	idempotent := middleware.Idempotency(
+		carddeck.BuildIdempotencyStore(db),
+		time.Duration(config.Server.IdempotencyTTL)*time.Second,
+		carddeck.WriteError,
+	)
+	rateLimitPolicy := func(rate float64, burst int) middleware.RateLimitPolicy {
+		return middleware.RateLimitPolicy{
+			Rate:           rate,
+			Burst:          burst,
+			ClientIPHeader: config.RateLimit.ClientIPHeader,
+			TrustedProxies: config.RateLimit.TrustedProxies,
+		}
+	}
+	rateLimit := func(rate float64, burst int) func(http.Handler) http.Handler {
+		return middleware.RateLimit(rateLimitPolicy(rate, burst), carddeck.WriteError)
+	}
+	createLimit := rateLimit(config.RateLimit.CreateRate, config.RateLimit.CreateBurst)
+	drawLimit := rateLimit(config.RateLimit.DrawRate, config.RateLimit.DrawBurst)
+	readLimit := rateLimit(config.RateLimit.ReadRate, config.RateLimit.ReadBurst)
+	ipPolicy := rateLimitPolicy(config.RateLimit.IPRate, config.RateLimit.IPBurst)
+	ipPolicy.ByClientIP = true
+	ipLimit := middleware.RateLimit(ipPolicy, carddeck.WriteError)
+
+	// rate limit and request quota are per tenant, so requests are authenticated first.
+	// Authentication looks up API keys in the database, so it is limited by client IP in front.
+	// Scopes only limit bearer tokens, and requests without credentials are rejected when tokens are enabled,
+	// so they do not get more rights than tokens, see middleware.RequireScope
+	authenticated := func(limit func(http.Handler) http.Handler, h http.Handler) http.Handler {
+		return ipLimit(authentication(limit(requestQuota(h))))
+	}
+	route := func(limit func(http.Handler) http.Handler, scope string, h http.Handler) http.Handler {
+		return authenticated(limit, middleware.RequireScope(scope, config.Auth.TokensEnabled(), carddeck.WriteError)(h))
+	}
+	api := func(limit func(http.Handler) http.Handler, scope string, h http.HandlerFunc) http.Handler {
+		return route(limit, scope, h)
+	}
+	// idempotency keys are scoped to the tenant, so idempotent handlers are authenticated first
+	apiIdempotent := func(limit func(http.Handler) http.Handler, scope string, h http.HandlerFunc) http.Handler {
+		return route(limit, scope, idempotent(h))
+	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /swagger/*", httpSwagger.WrapHandler)
-	mux.HandleFunc("POST /decks", handler.CreateDeck)
-	mux.HandleFunc("GET /decks/{id}", handler.GetDeck)
-	mux.HandleFunc("GET /decks/{id}/cards", handler.DrawCards)
+	// Swagger UI runs inline scripts and styles, which are blocked by Content-Security-Policy of the API
+	mux.Handle("GET /swagger/*", middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{ContentSecurityPolicy: swaggerCSP})(httpSwagger.WrapHandler))
+	mux.Handle("POST /decks", apiIdempotent(createLimit, entity.ScopeDeckDraw, handler.CreateDeck))
+	mux.Handle("GET /decks/{id}", api(readLimit, entity.ScopeDeckRead, handler.GetDeck))
+	mux.Handle("DELETE /decks/{id}", api(createLimit, entity.ScopeDeckAdmin, handler.DeleteDeck))
+	mux.Handle("POST /decks/{id}/cards", apiIdempotent(drawLimit, entity.ScopeDeckDraw, handler.DrawCards))
+	// Deprecated: drawing cards mutates the deck, use POST /decks/{id}/cards instead
+	mux.Handle("GET /decks/{id}/cards", apiIdempotent(drawLimit, entity.ScopeDeckDraw, handler.DrawCards))
+	mux.Handle("POST /decks/{id}/shuffle", apiIdempotent(drawLimit, entity.ScopeDeckDraw, handler.ShuffleDeck))
+	mux.Handle("POST /decks/{id}/return", apiIdempotent(drawLimit, entity.ScopeDeckDraw, handler.ReturnCards))
+	mux.Handle("GET /decks/{id}/ws", api(readLimit, entity.ScopeDeckRead, handler.WatchDeck))
+	mux.Handle("GET /decks/{id}/events", api(readLimit, entity.ScopeDeckRead, handler.StreamDeckEvents))
+	mux.Handle("GET /decks/{id}/export", api(readLimit, entity.ScopeDeckRead, handler.ExportDeck))
+	// card images are the same for everyone and are loaded by <img> tags, which can not send API key,
+	// so they are only rate limited by client IP
+	mux.Handle("GET /cards/{file}", readLimit(http.HandlerFunc(handler.GetCardImage)))
+	mux.Handle("POST /decks/import", api(createLimit, entity.ScopeDeckAdmin, handler.ImportDeck))
+	// batch operations create decks and draw, shuffle or return cards
+	mux.Handle("POST /batch", apiIdempotent(createLimit, entity.ScopeDeckDraw, handler.Batch))
+	// a single GraphQL request may both query decks and draw cards, so the resolvers check scopes of each field
+	var graphqlHandler http.Handler = carddeck.BuildGraphQLHandler(config, svc)
+	if config.Auth.TokensEnabled() {
+		graphqlHandler = middleware.RequireAuthentication(carddeck.WriteError)(graphqlHandler)
+	}
+	mux.Handle("POST /graphql", authenticated(drawLimit, graphqlHandler))
+	mux.Handle("POST /webhooks", api(createLimit, entity.ScopeDeckAdmin, handler.CreateWebhook))
+	mux.Handle("DELETE /webhooks/{id}", api(createLimit, entity.ScopeDeckAdmin, handler.DeleteWebhook))
+	mux.Handle("GET /webhooks/{id}/dead-letters", api(readLimit, entity.ScopeDeckAdmin, handler.ListWebhookDeadLetters))
+
+	security := middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{
+		ContentSecurityPolicy: config.Security.ContentSecurityPolicy,
+		FrameOptions:          config.Security.FrameOptions,
+		ReferrerPolicy:        config.Security.ReferrerPolicy,
+		HSTSMaxAge:            config.Security.HSTSMaxAge,
+	})
 
 	server := http.Server{
 		Addr:    fmt.Sprintf(":%s", config.Server.Port),
-		Handler: middleware.HeaderMiddleware(mux),
+		Handler: middleware.Compress(security(middleware.CORS(mux, corsPolicy))),
 	}
+	// hijacked WebSocket connections are not closed by Shutdown, closing the hub ends them
+	server.RegisterOnShutdown(hub.Close)
+
+	if config.Postgres.ListenNotify {
+		listenerCtx, stopListener := context.WithCancel(context.Background())
+		server.RegisterOnShutdown(stopListener)
+		go carddeck.BuildEventListener(config, db, hub).Run(listenerCtx)
+	}
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(stopDispatcher)
+	go carddeck.BuildWebhookDispatcher(config, db).Run(dispatcherCtx)
+
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	server.RegisterOnShutdown(stopPurger)
+	go carddeck.BuildPurger(config, db).Run(purgerCtx)
 
 	intrCh := make(chan os.Signal, 1)
 	signal.Notify(intrCh, syscall.SIGINT, syscall.SIGTERM)
 
+	grpcServer := carddeck.BuildGRPCServer(config, svc, quota, verifier)
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", config.Server.GRPCPort))
+	if err != nil {
+		return err
+	}
+
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("error running server")
@@ -94,17 +258,149 @@ func server() error {
 	}()
 	log.Info().Msgf("server started at port %s", config.Server.Port)
 
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal().Err(err).Msg("error running gRPC server")
+		}
+	}()
+	log.Info().Msgf("gRPC server started at port %s", config.Server.GRPCPort)
+
 	<-intrCh
 
 	log.Info().Msg("stopping server")
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Server.ShutdownTimeout)*time.Second)
 	defer cancel()
 
+	// WatchDeck streams end once Shutdown closes the hub, so GracefulStop does not wait for them
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
 	if err := server.Shutdown(ctx); err != nil {
 		log.Error().Err(err).Msg("error stopping server")
+		grpcServer.Stop()
 		return err
 	}
+
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		log.Error().Err(ctx.Err()).Msg("error stopping gRPC server")
+		grpcServer.Stop()
+		return ctx.Err()
+	}
 	log.Info().Msg("server stopped gracefully")
 
 	return nil
 }
+
+// tokenVerifier returns verifier of bearer tokens signed by JSON Web Key Set, nil when no key set is configured
+func tokenVerifier(ctx context.Context, config *config.Config) *middleware.TokenVerifier {
+	refresh := time.Duration(config.Auth.JWKSRefreshInterval) * time.Second
+	var keys *middleware.JWKS
+	switch {
+	case config.Auth.JWKSURL != "":
+		keys = middleware.NewURLJWKS(config.Auth.JWKSURL, &http.Client{Timeout: 10 * time.Second}, refresh)
+	case config.Auth.JWKSFile != "":
+		keys = middleware.NewFileJWKS(config.Auth.JWKSFile, refresh)
+	default:
+		return nil
+	}
+
+	// server still starts while the identity provider is unavailable, the first token loads the key set again
+	if err := keys.Refresh(ctx); err != nil {
+		log.Error().Err(err).Msg("error loading JSON Web Key Set")
+	}
+
+	return middleware.NewTokenVerifier(keys, config.Auth.Issuer, config.Auth.Audience, config.Auth.TenantClaim)
+}
+
+// authentication returns middleware authenticating requests by bearer token when verifier is not nil,
+// and by API key otherwise
+func authentication(config *config.Config, verifier *middleware.TokenVerifier, auth middleware.APIKeyAuthenticator) func(http.Handler) http.Handler {
+	apiKey := middleware.APIKey(auth, config.Server.APIKeyRequired, carddeck.WriteError)
+	if verifier == nil {
+		return apiKey
+	}
+
+	bearer := middleware.Bearer(verifier, carddeck.WriteError)
+	return func(h http.Handler) http.Handler {
+		return bearer(apiKey(h))
+	}
+}
+
+func exportDeck(ctx context.Context, id, output string) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	svc := carddeck.BuildService(config, db)
+
+	doc, err := svc.ExportDeck(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func importDeck(ctx context.Context, input string) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	svc := carddeck.BuildService(config, db)
+
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var doc entity.DeckExport
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+
+	deck, err := svc.ImportDeck(ctx, &doc)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("deck imported with id %s", deck.ID)
+	fmt.Println(deck.ID)
+
+	return nil
+}
@@ -2,35 +2,146 @@
 package carddeck
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/raymondwongso/carddeck/config"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
 	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/event"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/graphql"
+	carddeckgrpc "github.com/raymondwongso/carddeck/modules/carddeck/internal/grpc"
 	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
 	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/retention"
 	"github.com/raymondwongso/carddeck/modules/carddeck/internal/service"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/webhook"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+
+	"google.golang.org/grpc"
 
 	_ "github.com/jackc/pgx/v5/stdlib" // driver for postgres
 )
 
-// BuildHandler build and returns handler
-func BuildHandler(cfg *config.Config) (*rest.Handler, error) {
-	connCfg := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+// Connect opens connection pool to the database used by carddeck module
+func Connect(cfg *config.Config) (*sqlx.DB, error) {
+	return sqlx.Connect("pgx", connString(cfg))
+}
+
+func connString(cfg *config.Config) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.Postgres.Host,
 		cfg.Postgres.Port,
 		cfg.Postgres.User,
 		cfg.Postgres.Pass,
 		cfg.Postgres.DatabaseName,
 	)
-	db, err := sqlx.Connect("pgx", connCfg)
-	if err != nil {
-		return nil, err
+}
+
+// BuildEventHub build and returns hub delivering deck events to WebSocket clients.
+// Close it on server shutdown, so open connections are closed.
+func BuildEventHub() *event.Hub {
+	return event.NewHub()
+}
+
+// BuildEventListener build and returns listener feeding the hub with events of every server instance.
+// Only used when POSTGRES_LISTEN_NOTIFY is enabled.
+func BuildEventListener(cfg *config.Config, db *sqlx.DB, hub *event.Hub) *postgres.Listener {
+	return postgres.NewListener(postgres.PgxDialer(connString(cfg)), postgres.NewDeckEvent(db), hub.Publish)
+}
+
+// BuildWebhookDispatcher build and returns dispatcher delivering webhook payloads enqueued by the service
+func BuildWebhookDispatcher(cfg *config.Config, db *sqlx.DB) *webhook.Dispatcher {
+	return webhook.NewDispatcher(
+		postgres.NewWebhook(db),
+		webhook.NewClient(time.Duration(cfg.Webhook.Timeout)*time.Second, cfg.Webhook.AllowPrivateNetworks),
+		time.Duration(cfg.Webhook.PollInterval)*time.Second,
+		cfg.Webhook.MaxAttempts,
+	)
+}
+
+// BuildPurger build and returns purger deleting expired idempotency keys,
+// and deck events older than SERVER_DECK_EVENT_RETENTION, every SERVER_PURGE_INTERVAL
+func BuildPurger(cfg *config.Config, db *sqlx.DB) *retention.Purger {
+	tasks := []retention.Task{
+		{Name: "idempotency keys", Purge: postgres.NewIdempotencyKey(db).DeleteExpired},
+	}
+	if cfg.Server.DeckEventRetention > 0 {
+		maxAge := time.Duration(cfg.Server.DeckEventRetention) * time.Second
+		events := postgres.NewDeckEvent(db)
+		tasks = append(tasks, retention.Task{Name: "deck events", Purge: func(ctx context.Context) (int64, error) {
+			return events.DeleteOlderThan(ctx, maxAge)
+		}})
 	}
 
-	deckRepository := postgres.NewDeck(db)
+	return retention.NewPurger(time.Duration(cfg.Server.PurgeInterval)*time.Second, tasks...)
+}
+
+// BuildServerService build and returns service shared by REST, GraphQL and gRPC API of the server,
+// publishing deck events to the hub.
+func BuildServerService(cfg *config.Config, db *sqlx.DB, hub *event.Hub) *service.Service {
+	var broker service.EventBroker = hub
+	if cfg.Postgres.ListenNotify {
+		// events reach the hub through BuildEventListener, including events of this instance
+		broker = event.NewSubscribeOnly(hub)
+	}
+
+	return BuildService(cfg, db, service.WithEventBroker(broker))
+}
+
+// WriteError writes err as error response of REST API, used by HTTP middleware in front of the handlers
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	rest.WriteError(w, r, err)
+}
+
+// BuildHandler build and returns handler, accepting WebSocket connections from origins allowed by corsPolicy
+func BuildHandler(cfg *config.Config, svc *service.Service, corsPolicy middleware.CORSPolicy) *rest.Handler {
+	return rest.NewHandler(svc,
+		rest.WithMaxBodySize(cfg.Server.MaxBodySize),
+		rest.WithCheckOrigin(corsPolicy.CheckOrigin),
+	)
+}
+
+// BuildGraphQLHandler build and returns handler serving GraphQL queries of POST /graphql
+func BuildGraphQLHandler(cfg *config.Config, svc *service.Service) *graphql.Handler {
+	return graphql.NewHandler(svc, graphql.WithMaxBodySize(cfg.Server.MaxBodySize))
+}
+
+// BuildGRPCServer build and returns gRPC server serving carddeckpb.CarddeckService,
+// authenticating calls by API key, or bearer token verified by verifier when it is not nil,
+// and counting them against request quota of the tenant the same as REST API
+func BuildGRPCServer(cfg *config.Config, svc *service.Service, quota carddeckgrpc.QuotaCounter, verifier *middleware.TokenVerifier) *grpc.Server {
+	var authOpts []carddeckgrpc.AuthOption
+	if verifier != nil {
+		authOpts = append(authOpts, carddeckgrpc.WithTokenVerifier(verifier))
+	}
+	auth := carddeckgrpc.NewAuth(svc, cfg.Server.APIKeyRequired, authOpts...)
+	requestQuota := carddeckgrpc.NewRequestQuota(quota)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.Unary, requestQuota.Unary),
+		grpc.ChainStreamInterceptor(auth.Stream, requestQuota.Stream),
+	)
+	carddeckpb.RegisterCarddeckServiceServer(server, carddeckgrpc.NewServer(svc))
+	return server
+}
+
+// BuildIdempotencyStore build and returns storage used by middleware.Idempotency
+func BuildIdempotencyStore(db *sqlx.DB) *postgres.IdempotencyKey {
+	return postgres.NewIdempotencyKey(db)
+}
+
+// BuildService build and returns carddeck service (usecase),
+// used by entrypoints that operate directly on the database such as CLI commands.
+func BuildService(cfg *config.Config, db *sqlx.DB, opts ...service.Option) *service.Service {
+	var deckOpts []postgres.DeckOption
+	if cfg.Postgres.CompactCards {
+		deckOpts = append(deckOpts, postgres.WithCompactEncoding())
+	}
+	deckRepository := postgres.NewDeck(db, deckOpts...)
 
 	randGenerator := func() *rand.Rand {
 		return rand.New(rand.NewSource(time.Now().Unix()))
@@ -44,6 +155,21 @@ func BuildHandler(cfg *config.Config) (*rest.Handler, error) {
 		return cards
 	}
 
-	svc := service.New(deckRepository, randGenerator, cardShuffler)
-	return rest.NewHandler(svc), nil
+	var eventOpts []postgres.DeckEventOption
+	if cfg.Postgres.ListenNotify {
+		eventOpts = append(eventOpts, postgres.WithNotify())
+	}
+	eventRepository := postgres.NewDeckEvent(db, eventOpts...)
+
+	opts = append([]service.Option{
+		service.WithEventRepository(eventRepository),
+		service.WithWebhookRepository(postgres.NewWebhook(db)),
+		service.WithAPIKeyRepository(postgres.NewAPIKey(db)),
+		service.WithTenantRepository(postgres.NewTenant(db)),
+		service.WithDefaultTenantQuota(entity.TenantQuota{
+			MaxActiveDecks:       cfg.Tenant.MaxActiveDecks,
+			MaxRequestsPerMinute: cfg.Tenant.MaxRequestsPerMinute,
+		}),
+	}, opts...)
+	return service.New(deckRepository, randGenerator, cardShuffler, opts...)
 }
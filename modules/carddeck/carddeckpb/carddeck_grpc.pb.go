@@ -0,0 +1,263 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: modules/carddeck/carddeckpb/carddeck.proto
+
+// carddeck.v1 mirrors the REST API of carddeck module, see modules/carddeck/internal/rest.
+
+package carddeckpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CarddeckService_CreateDeck_FullMethodName = "/carddeck.v1.CarddeckService/CreateDeck"
+	CarddeckService_GetDeck_FullMethodName    = "/carddeck.v1.CarddeckService/GetDeck"
+	CarddeckService_DrawCards_FullMethodName  = "/carddeck.v1.CarddeckService/DrawCards"
+	CarddeckService_WatchDeck_FullMethodName  = "/carddeck.v1.CarddeckService/WatchDeck"
+)
+
+// CarddeckServiceClient is the client API for CarddeckService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CarddeckService serves French card decks.
+//
+// Errors carry google.rpc.ErrorInfo with the carddeck error code as reason (e.g. "carddeck.deck.not_found"),
+// and google.rpc.BadRequest listing invalid fields when there are any.
+type CarddeckServiceClient interface {
+	// CreateDeck creates new deck, containing full 52 cards unless cards are supplied.
+	CreateDeck(ctx context.Context, in *CreateDeckRequest, opts ...grpc.CallOption) (*CreateDeckResponse, error)
+	// GetDeck returns deck together with its remaining cards.
+	GetDeck(ctx context.Context, in *GetDeckRequest, opts ...grpc.CallOption) (*GetDeckResponse, error)
+	// DrawCards draws cards from the top of the deck.
+	DrawCards(ctx context.Context, in *DrawCardsRequest, opts ...grpc.CallOption) (*DrawCardsResponse, error)
+	// WatchDeck streams every change of the deck until the client cancels.
+	// The stream ends with UNAVAILABLE when the server is shutting down or the client falls behind,
+	// the client should get the deck again and watch it again.
+	WatchDeck(ctx context.Context, in *WatchDeckRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeckEvent], error)
+}
+
+type carddeckServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCarddeckServiceClient(cc grpc.ClientConnInterface) CarddeckServiceClient {
+	return &carddeckServiceClient{cc}
+}
+
+func (c *carddeckServiceClient) CreateDeck(ctx context.Context, in *CreateDeckRequest, opts ...grpc.CallOption) (*CreateDeckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateDeckResponse)
+	err := c.cc.Invoke(ctx, CarddeckService_CreateDeck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *carddeckServiceClient) GetDeck(ctx context.Context, in *GetDeckRequest, opts ...grpc.CallOption) (*GetDeckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeckResponse)
+	err := c.cc.Invoke(ctx, CarddeckService_GetDeck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *carddeckServiceClient) DrawCards(ctx context.Context, in *DrawCardsRequest, opts ...grpc.CallOption) (*DrawCardsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrawCardsResponse)
+	err := c.cc.Invoke(ctx, CarddeckService_DrawCards_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *carddeckServiceClient) WatchDeck(ctx context.Context, in *WatchDeckRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeckEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CarddeckService_ServiceDesc.Streams[0], CarddeckService_WatchDeck_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDeckRequest, DeckEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CarddeckService_WatchDeckClient = grpc.ServerStreamingClient[DeckEvent]
+
+// CarddeckServiceServer is the server API for CarddeckService service.
+// All implementations must embed UnimplementedCarddeckServiceServer
+// for forward compatibility.
+//
+// CarddeckService serves French card decks.
+//
+// Errors carry google.rpc.ErrorInfo with the carddeck error code as reason (e.g. "carddeck.deck.not_found"),
+// and google.rpc.BadRequest listing invalid fields when there are any.
+type CarddeckServiceServer interface {
+	// CreateDeck creates new deck, containing full 52 cards unless cards are supplied.
+	CreateDeck(context.Context, *CreateDeckRequest) (*CreateDeckResponse, error)
+	// GetDeck returns deck together with its remaining cards.
+	GetDeck(context.Context, *GetDeckRequest) (*GetDeckResponse, error)
+	// DrawCards draws cards from the top of the deck.
+	DrawCards(context.Context, *DrawCardsRequest) (*DrawCardsResponse, error)
+	// WatchDeck streams every change of the deck until the client cancels.
+	// The stream ends with UNAVAILABLE when the server is shutting down or the client falls behind,
+	// the client should get the deck again and watch it again.
+	WatchDeck(*WatchDeckRequest, grpc.ServerStreamingServer[DeckEvent]) error
+	mustEmbedUnimplementedCarddeckServiceServer()
+}
+
+// UnimplementedCarddeckServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCarddeckServiceServer struct{}
+
+func (UnimplementedCarddeckServiceServer) CreateDeck(context.Context, *CreateDeckRequest) (*CreateDeckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDeck not implemented")
+}
+func (UnimplementedCarddeckServiceServer) GetDeck(context.Context, *GetDeckRequest) (*GetDeckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeck not implemented")
+}
+func (UnimplementedCarddeckServiceServer) DrawCards(context.Context, *DrawCardsRequest) (*DrawCardsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrawCards not implemented")
+}
+func (UnimplementedCarddeckServiceServer) WatchDeck(*WatchDeckRequest, grpc.ServerStreamingServer[DeckEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDeck not implemented")
+}
+func (UnimplementedCarddeckServiceServer) mustEmbedUnimplementedCarddeckServiceServer() {}
+func (UnimplementedCarddeckServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCarddeckServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CarddeckServiceServer will
+// result in compilation errors.
+type UnsafeCarddeckServiceServer interface {
+	mustEmbedUnimplementedCarddeckServiceServer()
+}
+
+func RegisterCarddeckServiceServer(s grpc.ServiceRegistrar, srv CarddeckServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCarddeckServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CarddeckService_ServiceDesc, srv)
+}
+
+func _CarddeckService_CreateDeck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDeckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CarddeckServiceServer).CreateDeck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CarddeckService_CreateDeck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CarddeckServiceServer).CreateDeck(ctx, req.(*CreateDeckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CarddeckService_GetDeck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CarddeckServiceServer).GetDeck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CarddeckService_GetDeck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CarddeckServiceServer).GetDeck(ctx, req.(*GetDeckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CarddeckService_DrawCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrawCardsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CarddeckServiceServer).DrawCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CarddeckService_DrawCards_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CarddeckServiceServer).DrawCards(ctx, req.(*DrawCardsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CarddeckService_WatchDeck_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDeckRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CarddeckServiceServer).WatchDeck(m, &grpc.GenericServerStream[WatchDeckRequest, DeckEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CarddeckService_WatchDeckServer = grpc.ServerStreamingServer[DeckEvent]
+
+// CarddeckService_ServiceDesc is the grpc.ServiceDesc for CarddeckService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CarddeckService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "carddeck.v1.CarddeckService",
+	HandlerType: (*CarddeckServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateDeck",
+			Handler:    _CarddeckService_CreateDeck_Handler,
+		},
+		{
+			MethodName: "GetDeck",
+			Handler:    _CarddeckService_GetDeck_Handler,
+		},
+		{
+			MethodName: "DrawCards",
+			Handler:    _CarddeckService_DrawCards_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDeck",
+			Handler:       _CarddeckService_WatchDeck_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "modules/carddeck/carddeckpb/carddeck.proto",
+}
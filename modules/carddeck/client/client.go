@@ -0,0 +1,372 @@
+// Package client implements Go client of carddeck REST API.
+//
+// Errors returned by the API are decoded into *entity.Error,
+// so callers can check the code, e.g. entity.ErrDeckNotFound, using errors.As.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+const (
+	// DefaultMaxRetries is number of retries of request failed with 5xx status code or network error
+	DefaultMaxRetries = 2
+	// DefaultRetryBackoff is delay before the first retry, doubled on every retry
+	DefaultRetryBackoff = 100 * time.Millisecond
+
+	// maxWatchBackoff caps delay between reconnections of WatchDeck
+	maxWatchBackoff = 10 * time.Second
+)
+
+// DeckSummary defines deck without its cards, returned by CreateDeck, ShuffleDeck and ReturnCards
+type DeckSummary struct {
+	ID        string `json:"id"`
+	Shuffled  bool   `json:"shuffled"`
+	Remaining int64  `json:"remaining"`
+	// Version is the deck version, pass it to DrawCards, ShuffleDeck or ReturnCards to only change this version
+	Version int64 `json:"-"`
+}
+
+// DrawnCards defines cards returned by DrawCards
+type DrawnCards struct {
+	Cards *entity.Cards `json:"cards"`
+	// Version is the deck version after drawing, pass it to the next DrawCards, ShuffleDeck or ReturnCards
+	Version int64 `json:"-"`
+}
+
+// Client defines client of carddeck REST API
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	apiKey       string
+}
+
+// Option configures optional settings of the client
+type Option func(*Client)
+
+// WithHTTPClient sets HTTP client used to send requests, http.DefaultClient is used by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetry sets number of retries and delay before the first retry, 0 retries disables retrying
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithAPIKey sets API key sent in X-API-Key header, decks created by the client are owned by the key
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// New creates new client of the API served at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   http.DefaultClient,
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// CreateDeck creates new deck, containing the full deck when cardCodes is empty
+func (c *Client) CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*DeckSummary, error) {
+	query := url.Values{}
+	query.Set("shuffled", strconv.FormatBool(shuffled))
+	if len(cardCodes) > 0 {
+		query.Set("cards", strings.Join(cardCodes, ","))
+	}
+
+	var deck DeckSummary
+	header, err := c.do(ctx, http.MethodPost, "/decks?"+query.Encode(), nil, http.StatusCreated, &deck)
+	if err != nil {
+		return nil, err
+	}
+
+	deck.Version = parseETag(header.Get("ETag"))
+	return &deck, nil
+}
+
+// GetDeck gets deck by ID, together with its remaining cards
+func (c *Client) GetDeck(ctx context.Context, id string) (*entity.Deck, error) {
+	var raw json.RawMessage
+	header, err := c.do(ctx, http.MethodGet, "/decks/"+url.PathEscape(id), nil, http.StatusOK, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var deck entity.Deck
+	if err := entity.JSONUnmarshalDeck(raw, &deck); err != nil {
+		return nil, err
+	}
+
+	deck.Version = parseETag(header.Get("ETag"))
+	return &deck, nil
+}
+
+// DrawCards draws count cards from the top of the deck.
+// version is the deck version the caller expects, 0 means any version.
+func (c *Client) DrawCards(ctx context.Context, id string, count int64, version int64) (*DrawnCards, error) {
+	path := fmt.Sprintf("/decks/%s/cards?count=%d", url.PathEscape(id), count)
+
+	var drawn DrawnCards
+	header, err := c.do(ctx, http.MethodPost, path, ifMatch(version), http.StatusOK, &drawn)
+	if err != nil {
+		return nil, err
+	}
+
+	drawn.Version = parseETag(header.Get("ETag"))
+	return &drawn, nil
+}
+
+// ShuffleDeck shuffles remaining cards of the deck.
+// version is the deck version the caller expects, 0 means any version.
+func (c *Client) ShuffleDeck(ctx context.Context, id string, version int64) (*DeckSummary, error) {
+	var deck DeckSummary
+	header, err := c.do(ctx, http.MethodPost, "/decks/"+url.PathEscape(id)+"/shuffle", ifMatch(version), http.StatusOK, &deck)
+	if err != nil {
+		return nil, err
+	}
+
+	deck.Version = parseETag(header.Get("ETag"))
+	return &deck, nil
+}
+
+// ReturnCards returns cards to the bottom of the deck.
+// version is the deck version the caller expects, 0 means any version.
+func (c *Client) ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*DeckSummary, error) {
+	query := url.Values{}
+	query.Set("cards", strings.Join(cardCodes, ","))
+	path := fmt.Sprintf("/decks/%s/return?%s", url.PathEscape(id), query.Encode())
+
+	var deck DeckSummary
+	header, err := c.do(ctx, http.MethodPost, path, ifMatch(version), http.StatusOK, &deck)
+	if err != nil {
+		return nil, err
+	}
+
+	deck.Version = parseETag(header.Get("ETag"))
+	return &deck, nil
+}
+
+// WatchDeck calls handle for every change of the deck until ctx is done, returning ctx error.
+// Dropped streams are resumed with Last-Event-ID header, so events persisted in between are not missed.
+// Returns *entity.Error when the deck can not be watched, e.g. the deck does not exist.
+func (c *Client) WatchDeck(ctx context.Context, id string, handle func(*entity.DeckEvent)) error {
+	var lastEventID int64
+	backoff := c.retryBackoff
+
+	for {
+		received, err := c.streamEvents(ctx, id, &lastEventID, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var perr *entity.Error
+		if errors.As(err, &perr) && perr.Code != entity.ErrInternal {
+			return err
+		}
+
+		if received {
+			backoff = c.retryBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxWatchBackoff)
+	}
+}
+
+// streamEvents reads events of single GET /decks/{id}/events stream until it ends,
+// returning whether the stream was established
+func (c *Client) streamEvents(ctx context.Context, id string, lastEventID *int64, handle func(*entity.DeckEvent)) (bool, error) {
+	header := http.Header{}
+	if *lastEventID > 0 {
+		header.Set("Last-Event-ID", strconv.FormatInt(*lastEventID, 10))
+	}
+
+	res, err := c.send(ctx, http.MethodGet, "/decks/"+url.PathEscape(id)+"/events", header)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, decodeResponse(res, http.StatusOK, nil)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var data []byte
+	for scanner.Scan() {
+		field, value, _ := strings.Cut(scanner.Text(), ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "data":
+			data = append(data, value...)
+		case "":
+			// blank line dispatches the event, line starting with colon is a comment
+			if scanner.Text() != "" || len(data) == 0 {
+				continue
+			}
+
+			var ev entity.DeckEvent
+			if err := json.Unmarshal(data, &ev); err != nil {
+				return true, err
+			}
+			data = data[:0]
+
+			if ev.ID != 0 {
+				*lastEventID = ev.ID
+			}
+			handle(&ev)
+		}
+	}
+
+	return true, scanner.Err()
+}
+
+// do sends the request, retrying it on 5xx status code or network error, and decodes the response into dst.
+// POST requests carry Idempotency-Key header, so retrying never creates or draws twice.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, expected int, dst interface{}) (http.Header, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	if method == http.MethodPost {
+		key, err := idempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Idempotency-Key", key)
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := c.send(ctx, method, path, header)
+		if err == nil && res.StatusCode < http.StatusInternalServerError {
+			defer res.Body.Close()
+			return res.Header, decodeResponse(res, expected, dst)
+		}
+
+		if attempt >= c.maxRetries || ctx.Err() != nil {
+			if err != nil {
+				return nil, err
+			}
+			defer res.Body.Close()
+			return nil, decodeResponse(res, expected, dst)
+		}
+
+		if res != nil {
+			// drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header.Clone()
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "carddeck-go-client")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// decodeResponse decodes body of expected status code into dst, and error body into *entity.Error
+func decodeResponse(res *http.Response, expected int, dst interface{}) error {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != expected {
+		var perr entity.Error
+		if err := json.Unmarshal(body, &perr); err != nil || perr.Code == "" {
+			return fmt.Errorf("unexpected status code %d: %s", res.StatusCode, bytes.TrimSpace(body))
+		}
+		return &perr
+	}
+
+	return json.Unmarshal(body, dst)
+}
+
+// ifMatch returns If-Match header expecting the deck version, no header when version is 0
+func ifMatch(version int64) http.Header {
+	header := http.Header{}
+	if version != 0 {
+		header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+	return header
+}
+
+// parseETag parses deck version from ETag header, returning 0 when it is absent or invalid
+func parseETag(etag string) int64 {
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(etag, "W/"))
+	if err != nil {
+		return 0
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func idempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,283 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/client"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	mock_rest "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var defaultDeck = func() *entity.Deck {
+	deck := entity.NewDeck(true, &entity.Cards{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"},
+		{Val: "2", Suit: "SPADE", Code: "2S"},
+	})
+	deck.ID = "some-uuid-abc-def"
+	deck.Version = 3
+
+	return deck
+}()
+
+type ClientTestSuite struct {
+	suite.Suite
+	svc *mock_rest.MockService
+	srv *httptest.Server
+	// idempotencyKeys records Idempotency-Key header of every request
+	idempotencyKeys []string
+	// apiKeys records X-API-Key header of every request
+	apiKeys []string
+	client  *client.Client
+}
+
+func (s *ClientTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	s.svc = mock_rest.NewMockService(ctrl)
+	handler := rest.NewHandler(s.svc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /decks", handler.CreateDeck)
+	mux.HandleFunc("GET /decks/{id}", handler.GetDeck)
+	mux.HandleFunc("POST /decks/{id}/cards", handler.DrawCards)
+	mux.HandleFunc("POST /decks/{id}/shuffle", handler.ShuffleDeck)
+	mux.HandleFunc("POST /decks/{id}/return", handler.ReturnCards)
+	mux.HandleFunc("GET /decks/{id}/events", handler.StreamDeckEvents)
+
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.idempotencyKeys = append(s.idempotencyKeys, r.Header.Get("Idempotency-Key"))
+		s.apiKeys = append(s.apiKeys, r.Header.Get("X-API-Key"))
+		mux.ServeHTTP(w, r)
+	}))
+	s.client = client.New(s.srv.URL+"/", client.WithHTTPClient(s.srv.Client()), client.WithRetry(2, time.Millisecond))
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	s.idempotencyKeys = nil
+	s.apiKeys = nil
+}
+
+func (s *ClientTestSuite) TearDownSuite() {
+	s.srv.Close()
+}
+
+func TestClient(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (s *ClientTestSuite) TestCreateDeck() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), true, []string{"AS", "2S"}).Return(defaultDeck, nil)
+
+		deck, err := s.client.CreateDeck(ctx, true, []string{"AS", "2S"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &client.DeckSummary{ID: "some-uuid-abc-def", Shuffled: true, Remaining: 2, Version: 3}, deck)
+	})
+
+	s.Run("success - retried with the same idempotency key after 5xx", func() {
+		s.idempotencyKeys = nil
+		gomock.InOrder(
+			s.svc.EXPECT().CreateDeck(gomock.Any(), false, nil).Return(nil, errors.New("connection refused")),
+			s.svc.EXPECT().CreateDeck(gomock.Any(), false, nil).Return(defaultDeck, nil),
+		)
+
+		deck, err := s.client.CreateDeck(ctx, false, nil)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "some-uuid-abc-def", deck.ID)
+
+		assert.Len(s.T(), s.idempotencyKeys, 2)
+		assert.NotEmpty(s.T(), s.idempotencyKeys[0])
+		assert.Equal(s.T(), s.idempotencyKeys[0], s.idempotencyKeys[1])
+	})
+
+	s.Run("failed - card code invalid", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), false, []string{"XX"}).Return(nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid))
+
+		_, err := s.client.CreateDeck(ctx, false, []string{"XX"})
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrCardCodeInvalid, perr.Code)
+	})
+}
+
+func (s *ClientTestSuite) TestGetDeck() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		deck, err := s.client.GetDeck(ctx, "some-uuid-abc-def")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "some-uuid-abc-def", deck.ID)
+		assert.Equal(s.T(), defaultDeck.Cards, deck.Cards)
+		assert.Equal(s.T(), 2, deck.Remaining())
+		assert.Equal(s.T(), int64(3), deck.Version)
+	})
+
+	s.Run("success - API key sent", func() {
+		s.apiKeys = nil
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		c := client.New(s.srv.URL, client.WithHTTPClient(s.srv.Client()), client.WithAPIKey("cdk_key"))
+		_, err := c.GetDeck(ctx, "some-uuid-abc-def")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"cdk_key"}, s.apiKeys)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		_, err := s.client.GetDeck(ctx, "some-uuid-abc-def")
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+		assert.Equal(s.T(), entity.ErrMsgDeckNotFound, perr.Message)
+	})
+
+	s.Run("failed - 5xx after every retry", func() {
+		s.idempotencyKeys = nil
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, errors.New("connection refused")).Times(3)
+
+		_, err := s.client.GetDeck(ctx, "some-uuid-abc-def")
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrInternal, perr.Code)
+
+		// GET is safe to retry, so it does not need idempotency key
+		assert.Equal(s.T(), []string{"", "", ""}, s.idempotencyKeys)
+	})
+}
+
+func (s *ClientTestSuite) TestDrawCards() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(3)).Return(&entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, defaultDeck, nil)
+
+		drawn, err := s.client.DrawCards(ctx, "some-uuid-abc-def", 1, 3)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &client.DrawnCards{Cards: &entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, Version: 3}, drawn)
+	})
+
+	s.Run("failed - version mismatch is not retried", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(2)).Return(nil, nil, entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch))
+
+		_, err := s.client.DrawCards(ctx, "some-uuid-abc-def", 1, 2)
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrDeckVersionMismatch, perr.Code)
+	})
+
+	s.Run("failed - context canceled", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := s.client.DrawCards(ctx, "some-uuid-abc-def", 1, 0)
+		assert.ErrorIs(s.T(), err, context.Canceled)
+	})
+}
+
+func (s *ClientTestSuite) TestShuffleDeck() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().ShuffleDeck(gomock.Any(), "some-uuid-abc-def", int64(3)).Return(defaultDeck, nil)
+
+		deck, err := s.client.ShuffleDeck(ctx, "some-uuid-abc-def", 3)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &client.DeckSummary{ID: "some-uuid-abc-def", Shuffled: true, Remaining: 2, Version: 3}, deck)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().ShuffleDeck(gomock.Any(), "some-uuid-abc-def", int64(0)).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		_, err := s.client.ShuffleDeck(ctx, "some-uuid-abc-def", 0)
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+}
+
+func (s *ClientTestSuite) TestReturnCards() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().ReturnCards(gomock.Any(), "some-uuid-abc-def", []string{"AS", "2S"}, int64(3)).Return(defaultDeck, nil)
+
+		deck, err := s.client.ReturnCards(ctx, "some-uuid-abc-def", []string{"AS", "2S"}, 3)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &client.DeckSummary{ID: "some-uuid-abc-def", Shuffled: true, Remaining: 2, Version: 3}, deck)
+	})
+
+	s.Run("failed - card code invalid", func() {
+		s.svc.EXPECT().ReturnCards(gomock.Any(), "some-uuid-abc-def", []string{"XX"}, int64(0)).Return(nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid))
+
+		_, err := s.client.ReturnCards(ctx, "some-uuid-abc-def", []string{"XX"}, 0)
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrCardCodeInvalid, perr.Code)
+	})
+}
+
+func (s *ClientTestSuite) TestWatchDeck() {
+	s.Run("success - dropped stream is resumed from the last event", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := make(chan *entity.DeckEvent, 1)
+		first <- &entity.DeckEvent{ID: 7, Type: entity.DeckEventDrawn, DeckID: "some-uuid-abc-def", Version: 4, Remaining: 1}
+		close(first)
+		second := make(chan *entity.DeckEvent)
+
+		gomock.InOrder(
+			s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").Return(first, func() {}, nil),
+			s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").Return(second, func() {}, nil),
+		)
+		s.svc.EXPECT().ListDeckEvents(gomock.Any(), "some-uuid-abc-def", int64(7)).Return([]*entity.DeckEvent{
+			{ID: 8, Type: entity.DeckEventShuffled, DeckID: "some-uuid-abc-def", Version: 5, Shuffled: true, Remaining: 1},
+		}, nil)
+
+		var received []*entity.DeckEvent
+		err := s.client.WatchDeck(ctx, "some-uuid-abc-def", func(ev *entity.DeckEvent) {
+			received = append(received, ev)
+			if len(received) == 2 {
+				cancel()
+			}
+		})
+		assert.ErrorIs(s.T(), err, context.Canceled)
+		assert.Len(s.T(), received, 2)
+		assert.Equal(s.T(), int64(7), received[0].ID)
+		assert.Equal(s.T(), entity.DeckEventDrawn, received[0].Type)
+		assert.Equal(s.T(), int64(8), received[1].ID)
+		assert.Equal(s.T(), true, received[1].Shuffled)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		err := s.client.WatchDeck(context.Background(), "some-uuid-abc-def", func(*entity.DeckEvent) {})
+		var perr *entity.Error
+		assert.True(s.T(), errors.As(err, &perr))
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+}
+
+func Test_Client_UnexpectedResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := client.New(srv.URL, client.WithRetry(0, 0)).GetDeck(context.Background(), "some-uuid-abc-def")
+	assert.EqualError(t, err, "unexpected status code 502: bad gateway")
+}
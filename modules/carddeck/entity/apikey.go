@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// apiKeyPrefix prefixes every API key, so leaked keys are easy to recognize
+	apiKeyPrefix = "cdk_"
+	// apiKeyBytes is number of random bytes of API key
+	apiKeyBytes = 32
+
+	// APIKeyNameMaxLength is maximum length of API key name
+	APIKeyNameMaxLength = 255
+)
+
+// APIKey defines key authenticating API clients of a tenant, decks created with the key belong to the tenant.
+// Only SHA-256 hash of the key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID        string     `json:"id" db:"id"`
+	TenantID  string     `json:"tenant_id" db:"tenant_id"`
+	Name      string     `json:"name" db:"name"`
+	Hash      string     `json:"-" db:"key_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	// Key is only filled by NewAPIKey, it can not be recovered afterwards
+	Key string `json:"key,omitempty" db:"-"`
+}
+
+// NewAPIKey generates new random API key
+func NewAPIKey(name string) (*APIKey, error) {
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	return &APIKey{
+		Name: name,
+		Hash: HashAPIKey(key),
+		Key:  key,
+	}, nil
+}
+
+// HashAPIKey returns hex encoded SHA-256 hash of the key, used to look the key up.
+// Keys are random, so unlike passwords they do not need salt or slow hashing.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Revoked reports whether the key can no longer be used
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
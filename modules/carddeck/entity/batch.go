@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	BatchOperationCreate  = "create"
+	BatchOperationDraw    = "draw"
+	BatchOperationShuffle = "shuffle"
+	BatchOperationReturn  = "return"
+
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+
+	// BatchMaxOperations is the maximum number of operations in a single batch
+	BatchMaxOperations = 100
+
+	batchReferencePrefix = "$"
+)
+
+// BatchOperation defines single deck operation executed as part of a batch.
+// DeckID may reference deck of previous operation in the same batch using "$<index>", e.g. "$0".
+type BatchOperation struct {
+	Op       string   `json:"op"`
+	DeckID   string   `json:"deck_id,omitempty"`
+	Shuffled bool     `json:"shuffled,omitempty"`
+	Cards    []string `json:"cards,omitempty"`
+	Count    int64    `json:"count,omitempty"`
+	// Version is the deck version expected by the client, equivalent to If-Match header. 0 means any version.
+	Version int64 `json:"version,omitempty"`
+}
+
+// BatchResult defines result of single operation of a batch
+type BatchResult struct {
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Deck   *Deck  `json:"deck,omitempty"`
+	Cards  *Cards `json:"cards,omitempty"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+// ResolveDeckID returns deck ID of the operation, resolving "$<index>" reference
+// against results of previous operations.
+func (o *BatchOperation) ResolveDeckID(index int, results []*BatchResult) (string, error) {
+	if !strings.HasPrefix(o.DeckID, batchReferencePrefix) {
+		return o.DeckID, nil
+	}
+
+	ref, err := strconv.Atoi(strings.TrimPrefix(o.DeckID, batchReferencePrefix))
+	if err != nil || ref < 0 || ref >= index || ref >= len(results) || results[ref].Deck == nil {
+		err := NewError(ErrParamInvalid, ErrMsgParamInvalid)
+		err.AddDetail(NewErrorDetail(fmt.Sprintf("operations[%d].deck_id", index), "deck_id must reference previous operation"))
+		return "", err
+	}
+
+	return results[ref].Deck.ID, nil
+}
@@ -0,0 +1,47 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BatchOperation_ResolveDeckID(t *testing.T) {
+	results := []*entity.BatchResult{
+		{Op: entity.BatchOperationCreate, Deck: &entity.Deck{ID: "created-id"}},
+		{Op: entity.BatchOperationDraw},
+	}
+
+	t.Run("success - plain deck id", func(t *testing.T) {
+		op := &entity.BatchOperation{Op: entity.BatchOperationDraw, DeckID: "some-id"}
+		id, err := op.ResolveDeckID(2, results)
+		assert.NoError(t, err)
+		assert.Equal(t, "some-id", id)
+	})
+
+	t.Run("success - reference previous operation", func(t *testing.T) {
+		op := &entity.BatchOperation{Op: entity.BatchOperationDraw, DeckID: "$0"}
+		id, err := op.ResolveDeckID(2, results)
+		assert.NoError(t, err)
+		assert.Equal(t, "created-id", id)
+	})
+
+	for name, ref := range map[string]string{
+		"not a number":            "$abc",
+		"reference itself":        "$2",
+		"operation without deck":  "$1",
+		"reference out of bounds": "$-1",
+	} {
+		t.Run("failed - "+name, func(t *testing.T) {
+			op := &entity.BatchOperation{Op: entity.BatchOperationDraw, DeckID: ref}
+			id, err := op.ResolveDeckID(2, results)
+			assert.Empty(t, id)
+
+			perr, ok := err.(*entity.Error)
+			assert.True(t, ok)
+			assert.Equal(t, entity.ErrParamInvalid, perr.Code)
+			assert.Equal(t, "operations[2].deck_id", perr.Details[0].Field)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package entity
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	// StandardCardSetID is the ID of standard 52 French playing cards set.
+	StandardCardSetID = "standard"
+)
+
+var (
+	standardSuits = []struct{ name, code string }{
+		{"SPADE", "S"}, {"DIAMOND", "D"}, {"CLUB", "C"}, {"HEART", "H"},
+	}
+	standardValues = []struct{ name, code string }{
+		{"ACE", "A"}, {"2", "2"}, {"3", "3"}, {"4", "4"}, {"5", "5"}, {"6", "6"}, {"7", "7"},
+		{"8", "8"}, {"9", "9"}, {"10", "10"}, {"JACK", "J"}, {"QUEEN", "Q"}, {"KING", "K"},
+	}
+
+	// StandardCardSet contains standard 52 French playing cards,
+	// ordered by suit (spade, diamond, club, heart) then by value (ace to king).
+	StandardCardSet = func() *CardSet {
+		cards := make([]Card, 0, len(standardSuits)*len(standardValues))
+		for _, suit := range standardSuits {
+			for _, value := range standardValues {
+				cards = append(cards, Card{Val: value.name, Suit: suit.name, Code: value.code + suit.code})
+			}
+		}
+		return NewCardSet(StandardCardSetID, cards)
+	}()
+
+	// cardSetsMu guards cardSets, card sets may be registered while decks are encoded
+	cardSetsMu sync.RWMutex
+	cardSets   = map[string]*CardSet{
+		StandardCardSetID: StandardCardSet,
+	}
+)
+
+// CardSet defines an ordered collection of cards that a deck can be built from.
+// Position of a card inside the set is stable and used as the card index in compact encoding.
+type CardSet struct {
+	ID    string
+	Cards []Card
+
+	index map[string]int
+}
+
+// NewCardSet creates new card set and build the code index.
+func NewCardSet(id string, cards []Card) *CardSet {
+	index := make(map[string]int, len(cards))
+	for i, card := range cards {
+		index[card.Code] = i
+	}
+
+	return &CardSet{
+		ID:    id,
+		Cards: cards,
+		index: index,
+	}
+}
+
+// Index returns the position of card code inside the set.
+// Return false if the code is not part of the set.
+func (cs *CardSet) Index(code string) (int, bool) {
+	i, ok := cs.index[code]
+	return i, ok
+}
+
+// Card returns copy of card by code.
+// Return false if the code is not part of the set.
+func (cs *CardSet) Card(code string) (Card, bool) {
+	i, ok := cs.index[code]
+	if !ok {
+		return Card{}, false
+	}
+	return cs.Cards[i], true
+}
+
+// GetCardSet returns registered card set by ID.
+func GetCardSet(id string) (*CardSet, bool) {
+	cardSetsMu.RLock()
+	defer cardSetsMu.RUnlock()
+
+	cs, ok := cardSets[id]
+	return cs, ok
+}
+
+// RegisterCardSet registers card set by its ID, replacing card set already registered with the same ID.
+func RegisterCardSet(set *CardSet) {
+	cardSetsMu.Lock()
+	defer cardSetsMu.Unlock()
+
+	cardSets[set.ID] = set
+}
+
+// UnregisterCardSet removes card set registered by RegisterCardSet, the standard card set is never removed.
+func UnregisterCardSet(id string) {
+	if id == StandardCardSetID {
+		return
+	}
+
+	cardSetsMu.Lock()
+	defer cardSetsMu.Unlock()
+
+	delete(cardSets, id)
+}
+
+// CardSetIDs returns sorted IDs of all registered card sets.
+func CardSetIDs() []string {
+	cardSetsMu.RLock()
+	defer cardSetsMu.RUnlock()
+
+	ids := make([]string, 0, len(cardSets))
+	for id := range cardSets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
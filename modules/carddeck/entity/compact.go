@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	compactSeparator = "."
+	// compactShuffled is the flag segment appended by EncodeCompactDeck to encoding of shuffled deck
+	compactShuffled = "s"
+)
+
+// EncodeCompact encodes cards into compact string "<card set id>.<packed card indexes>".
+// Each card is packed into a single byte holding its index inside the card set,
+// then the bytes are encoded using unpadded URL-safe base64 so the result can be used in query string.
+// Packed indexes (instead of permutation index) are used because a deck may contain
+// a subset of the set or the same card more than once.
+// Return error if any card is not part of the card set.
+func EncodeCompact(set *CardSet, cards Cards) (string, error) {
+	packed := make([]byte, len(cards))
+	for i, card := range cards {
+		idx, ok := set.Index(card.Code)
+		if !ok || idx > 255 {
+			return "", NewError(ErrCardCodeInvalid, ErrMsgCardCodeInvalid)
+		}
+		packed[i] = byte(idx)
+	}
+
+	return set.ID + compactSeparator + base64.RawURLEncoding.EncodeToString(packed), nil
+}
+
+// DecodeCompact decodes compact string produced by EncodeCompact back to the card set and cards.
+// Return error if the string is malformed, or the card set is unknown.
+func DecodeCompact(s string) (*CardSet, Cards, error) {
+	setID, encoded, ok := strings.Cut(s, compactSeparator)
+	if !ok {
+		return nil, nil, NewError(ErrDeckCompactInvalid, ErrMsgDeckCompactInvalid)
+	}
+
+	set, ok := GetCardSet(setID)
+	if !ok {
+		return nil, nil, NewError(ErrDeckCompactInvalid, ErrMsgDeckCompactInvalid)
+	}
+
+	packed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, NewError(ErrDeckCompactInvalid, ErrMsgDeckCompactInvalid)
+	}
+
+	cards := make(Cards, len(packed))
+	for i, idx := range packed {
+		if int(idx) >= len(set.Cards) {
+			return nil, nil, NewError(ErrDeckCompactInvalid, ErrMsgDeckCompactInvalid)
+		}
+		card := set.Cards[idx]
+		cards[i] = &card
+	}
+
+	return set, cards, nil
+}
+
+// EncodeCompactDeck encodes deck position like EncodeCompact, appending ".s" when the deck is shuffled,
+// so the deck restored from it by DecodeCompactDeck reports shuffled too.
+func EncodeCompactDeck(set *CardSet, cards Cards, shuffled bool) (string, error) {
+	encoded, err := EncodeCompact(set, cards)
+	if err != nil {
+		return "", err
+	}
+	if shuffled {
+		encoded += compactSeparator + compactShuffled
+	}
+	return encoded, nil
+}
+
+// DecodeCompactDeck decodes string produced by EncodeCompactDeck or EncodeCompact back to the card set, cards and shuffled flag.
+// Return error if the string is malformed, or the card set is unknown.
+func DecodeCompactDeck(s string) (*CardSet, Cards, bool, error) {
+	shuffled := false
+	if strings.Count(s, compactSeparator) == 2 {
+		i := strings.LastIndex(s, compactSeparator)
+		if s[i+1:] != compactShuffled {
+			return nil, nil, false, NewError(ErrDeckCompactInvalid, ErrMsgDeckCompactInvalid)
+		}
+		s, shuffled = s[:i], true
+	}
+
+	set, cards, err := DecodeCompact(s)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return set, cards, shuffled, nil
+}
@@ -0,0 +1,185 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StandardCardSet(t *testing.T) {
+	assert.Equal(t, 52, len(entity.StandardCardSet.Cards))
+
+	idx, ok := entity.StandardCardSet.Index("AS")
+	assert.True(t, ok)
+	assert.Equal(t, 0, idx)
+
+	card, ok := entity.StandardCardSet.Card("QH")
+	assert.True(t, ok)
+	assert.Equal(t, entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"}, card)
+
+	_, ok = entity.StandardCardSet.Card("XX")
+	assert.False(t, ok)
+
+	cs, ok := entity.GetCardSet(entity.StandardCardSetID)
+	assert.True(t, ok)
+	assert.Equal(t, entity.StandardCardSet, cs)
+	assert.Equal(t, []string{entity.StandardCardSetID}, entity.CardSetIDs())
+}
+
+func Test_RegisterCardSet(t *testing.T) {
+	jokers := entity.NewCardSet("test-jokers", []entity.Card{{Val: "JOKER", Suit: "RED", Code: "JR"}})
+	entity.RegisterCardSet(jokers)
+	t.Cleanup(func() { entity.UnregisterCardSet(jokers.ID) })
+
+	cs, ok := entity.GetCardSet(jokers.ID)
+	assert.True(t, ok)
+	assert.Equal(t, jokers, cs)
+	assert.Equal(t, []string{entity.StandardCardSetID, jokers.ID}, entity.CardSetIDs())
+
+	entity.UnregisterCardSet(jokers.ID)
+	_, ok = entity.GetCardSet(jokers.ID)
+	assert.False(t, ok)
+
+	entity.UnregisterCardSet(entity.StandardCardSetID)
+	_, ok = entity.GetCardSet(entity.StandardCardSetID)
+	assert.True(t, ok)
+}
+
+func Test_Compact(t *testing.T) {
+	t.Run("success encode and decode", func(t *testing.T) {
+		cards := entity.Cards{
+			{Val: "KING", Suit: "HEART", Code: "KH"},
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+			{Val: "10", Suit: "DIAMOND", Code: "10D"},
+		}
+
+		encoded, err := entity.EncodeCompact(entity.StandardCardSet, cards)
+		assert.NoError(t, err)
+		assert.Equal(t, "standard.MwAAFg", encoded)
+
+		set, decoded, err := entity.DecodeCompact(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.StandardCardSet, set)
+		assert.Equal(t, cards, decoded)
+	})
+
+	t.Run("success encode and decode empty cards", func(t *testing.T) {
+		encoded, err := entity.EncodeCompact(entity.StandardCardSet, entity.Cards{})
+		assert.NoError(t, err)
+		assert.Equal(t, "standard.", encoded)
+
+		_, decoded, err := entity.DecodeCompact(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cards{}, decoded)
+	})
+
+	t.Run("failed encode - unknown card", func(t *testing.T) {
+		_, err := entity.EncodeCompact(entity.StandardCardSet, entity.Cards{{Val: "JOKER", Suit: "", Code: "X"}})
+		assert.Error(t, err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, entity.ErrCardCodeInvalid, perr.Code)
+	})
+
+	for name, encoded := range map[string]string{
+		"missing separator":    "standardAAE",
+		"unknown card set":     "unknown.AAE",
+		"invalid base64":       "standard.!!",
+		"index out of the set": "standard._w",
+	} {
+		t.Run("failed decode - "+name, func(t *testing.T) {
+			_, _, err := entity.DecodeCompact(encoded)
+			assert.Error(t, err)
+
+			perr, ok := err.(*entity.Error)
+			assert.True(t, ok)
+			assert.Equal(t, entity.ErrDeckCompactInvalid, perr.Code)
+			assert.Equal(t, entity.ErrMsgDeckCompactInvalid, perr.Message)
+		})
+	}
+}
+
+func Test_CompactDeck(t *testing.T) {
+	cards := entity.Cards{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"},
+		{Val: "2", Suit: "SPADE", Code: "2S"},
+	}
+
+	t.Run("success encode and decode shuffled deck", func(t *testing.T) {
+		encoded, err := entity.EncodeCompactDeck(entity.StandardCardSet, cards, true)
+		assert.NoError(t, err)
+		assert.Equal(t, "standard.AAE.s", encoded)
+
+		set, decoded, shuffled, err := entity.DecodeCompactDeck(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.StandardCardSet, set)
+		assert.Equal(t, cards, decoded)
+		assert.True(t, shuffled)
+	})
+
+	t.Run("success encode and decode deck not shuffled", func(t *testing.T) {
+		encoded, err := entity.EncodeCompactDeck(entity.StandardCardSet, cards, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "standard.AAE", encoded)
+
+		_, decoded, shuffled, err := entity.DecodeCompactDeck(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, cards, decoded)
+		assert.False(t, shuffled)
+	})
+
+	t.Run("success decode empty shuffled deck", func(t *testing.T) {
+		_, decoded, shuffled, err := entity.DecodeCompactDeck("standard..s")
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cards{}, decoded)
+		assert.True(t, shuffled)
+	})
+
+	for name, encoded := range map[string]string{
+		"unknown flag":   "standard.AAE.x",
+		"too many flags": "standard.AAE.s.s",
+	} {
+		t.Run("failed decode - "+name, func(t *testing.T) {
+			_, _, _, err := entity.DecodeCompactDeck(encoded)
+			assert.Error(t, err)
+
+			perr, ok := err.(*entity.Error)
+			assert.True(t, ok)
+			assert.Equal(t, entity.ErrDeckCompactInvalid, perr.Code)
+		})
+	}
+}
+
+func Test_Cards_Scan(t *testing.T) {
+	t.Run("success scan array of card", func(t *testing.T) {
+		var cards entity.Cards
+		err := cards.Scan([]byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"}]`))
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, cards)
+	})
+
+	t.Run("success scan compact encoding", func(t *testing.T) {
+		var cards entity.Cards
+		err := cards.Scan(`"standard.AAE"`)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cards{
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+			{Val: "2", Suit: "SPADE", Code: "2S"},
+		}, cards)
+	})
+
+	t.Run("failed scan invalid compact encoding", func(t *testing.T) {
+		var cards entity.Cards
+		err := cards.Scan(`"standard.!!"`)
+		assert.Error(t, err)
+	})
+
+	t.Run("failed scan unsupported type", func(t *testing.T) {
+		var cards entity.Cards
+		err := cards.Scan(1)
+		assert.Error(t, err)
+	})
+}
@@ -7,6 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // remainingFunc is a custom type for compute-function that return
@@ -26,12 +29,35 @@ func (f remainingFunc) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// MarshalCBOR marshal the return value of remainingFunc to CBOR
+func (f remainingFunc) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(f())
+}
+
+// UnmarshalCBOR only validates the value, remaining is always computed from the cards
+func (f remainingFunc) UnmarshalCBOR(b []byte) error {
+	var i int
+	return cbor.Unmarshal(b, &i)
+}
+
+// EncodeMsgpack encodes the return value of remainingFunc to MessagePack
+func (f remainingFunc) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeInt(int64(f()))
+}
+
+// DecodeMsgpack only validates the value, remaining is always computed from the cards
+func (f *remainingFunc) DecodeMsgpack(dec *msgpack.Decoder) error {
+	_, err := dec.DecodeInt()
+	return err
+}
+
 // Deck defines a deck of card
 type Deck struct {
 	ID        string        `json:"id" db:"id"`
 	Shuffled  bool          `json:"shuffled" db:"shuffled"`
 	Remaining remainingFunc `json:"remaining" db:"-"`
 	Cards     *Cards        `json:"cards,omitempty" db:"cards"`
+	Version   int64         `json:"-" db:"version"`
 	CreatedAt time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
 }
@@ -39,20 +65,36 @@ type Deck struct {
 // Cards defines array of card
 type Cards []*Card
 
-// Scan implements scanner interface
+// Scan implements scanner interface.
+// It accepts both JSON array of card and JSON string containing compact encoding (see EncodeCompact).
 func (c *Cards) Scan(val interface{}) error {
 	switch v := val.(type) {
 	case []byte:
-		_ = json.Unmarshal(v, &c)
-		return nil
+		return c.scanJSON(v)
 	case string:
-		_ = json.Unmarshal([]byte(v), &c)
-		return nil
+		return c.scanJSON([]byte(v))
 	default:
 		return fmt.Errorf("unsupported type: %T", v)
 	}
 }
 
+func (c *Cards) scanJSON(data []byte) error {
+	var compact string
+	if err := json.Unmarshal(data, &compact); err != nil {
+		// not a compact encoding, treat it as array of card
+		_ = json.Unmarshal(data, &c)
+		return nil
+	}
+
+	_, cards, err := DecodeCompact(compact)
+	if err != nil {
+		return err
+	}
+
+	*c = cards
+	return nil
+}
+
 // Value implements valuer interface
 func (c *Cards) Value() (driver.Value, error) {
 	return json.Marshal(c)
@@ -112,3 +154,42 @@ func RemainingFunc(d *Deck) remainingFunc {
 		return d.Cards.Len()
 	}
 }
+
+const (
+	// DefaultDeckListLimit is number of decks listed when DeckFilter.Limit is not set
+	DefaultDeckListLimit = 20
+	// MaxDeckListLimit is maximum number of decks listed at once
+	MaxDeckListLimit = 100
+)
+
+// DeckFilter defines filter of listed decks, ordered from the newest.
+// Empty IDs and nil Shuffled match every deck.
+type DeckFilter struct {
+	IDs      []string
+	Shuffled *bool
+	Limit    int
+	Offset   int
+}
+
+// Validate validates the filter, setting default limit when it is not set
+func (f *DeckFilter) Validate() error {
+	err := NewError(ErrParamInvalid, ErrMsgParamInvalid)
+
+	if f.Limit == 0 {
+		f.Limit = DefaultDeckListLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxDeckListLimit {
+		err.AddDetail(NewErrorDetail("limit", fmt.Sprintf("limit must be between 1 and %d", MaxDeckListLimit)))
+	}
+	if f.Offset < 0 {
+		err.AddDetail(NewErrorDetail("offset", "offset must not be negative"))
+	}
+	if len(f.IDs) > MaxDeckListLimit {
+		err.AddDetail(NewErrorDetail("ids", fmt.Sprintf("at most %d ids are allowed", MaxDeckListLimit)))
+	}
+
+	if len(err.Details) > 0 {
+		return err
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
 	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 func Test_NewDeck(t *testing.T) {
@@ -192,3 +194,65 @@ func Test_JSON(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func Test_BinaryEncoding(t *testing.T) {
+	deck := entity.NewDeck(false, &entity.Cards{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"},
+		{Val: "2", Suit: "SPADE", Code: "2S"},
+	})
+	deck.ID = "some-uuid-abc-def"
+
+	t.Run("success cbor", func(t *testing.T) {
+		marshaled, err := cbor.Marshal(deck)
+		assert.NoError(t, err)
+
+		var decoded map[string]any
+		assert.NoError(t, cbor.Unmarshal(marshaled, &decoded))
+		assert.EqualValues(t, 2, decoded["remaining"])
+
+		var deck entity.Deck
+		assert.NoError(t, cbor.Unmarshal(marshaled, &deck))
+		assert.Equal(t, "some-uuid-abc-def", deck.ID)
+	})
+
+	t.Run("success msgpack", func(t *testing.T) {
+		marshaled, err := msgpack.Marshal(deck)
+		assert.NoError(t, err)
+
+		var decoded map[string]any
+		assert.NoError(t, msgpack.Unmarshal(marshaled, &decoded))
+		assert.EqualValues(t, 2, decoded["Remaining"])
+
+		var deck entity.Deck
+		assert.NoError(t, msgpack.Unmarshal(marshaled, &deck))
+		assert.Equal(t, "some-uuid-abc-def", deck.ID)
+	})
+}
+
+func Test_DeckFilter_Validate(t *testing.T) {
+	t.Run("success - default limit", func(t *testing.T) {
+		filter := &entity.DeckFilter{}
+		assert.NoError(t, filter.Validate())
+		assert.Equal(t, entity.DefaultDeckListLimit, filter.Limit)
+	})
+
+	t.Run("success - limit is kept", func(t *testing.T) {
+		filter := &entity.DeckFilter{Limit: entity.MaxDeckListLimit, Offset: 40}
+		assert.NoError(t, filter.Validate())
+		assert.Equal(t, entity.MaxDeckListLimit, filter.Limit)
+	})
+
+	t.Run("failed - every invalid field is reported", func(t *testing.T) {
+		filter := &entity.DeckFilter{IDs: make([]string, entity.MaxDeckListLimit+1), Limit: entity.MaxDeckListLimit + 1, Offset: -1}
+
+		perr, ok := filter.Validate().(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, entity.ErrParamInvalid, perr.Code)
+
+		var fields []string
+		for _, detail := range perr.Details {
+			fields = append(fields, detail.Field)
+		}
+		assert.Equal(t, []string{"limit", "offset", "ids"}, fields)
+	})
+}
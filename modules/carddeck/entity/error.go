@@ -8,14 +8,65 @@ const (
 	ErrInternal        = "common.internal"
 	ErrMsgInternal     = "something wrong happened"
 
+	ErrIdempotencyKeyConflict      = "common.idempotency_key_conflict"
+	ErrMsgIdempotencyKeyConflict   = "idempotency key is already used for a different request"
+	ErrIdempotencyKeyInProgress    = "common.idempotency_key_in_progress"
+	ErrMsgIdempotencyKeyInProgress = "request with the same idempotency key is still in progress"
+	ErrRequestTooLarge             = "common.request_too_large"
+	ErrMsgRequestTooLarge          = "request body is too large"
+
+	ErrAPIKeyRequired    = "common.api_key_required"
+	ErrMsgAPIKeyRequired = "API key is required"
+	ErrAPIKeyInvalid     = "common.api_key_invalid"
+	ErrMsgAPIKeyInvalid  = "API key is invalid or revoked"
+
+	ErrTokenInvalid         = "common.token_invalid"
+	ErrMsgTokenInvalid      = "bearer token is invalid or expired"
+	ErrTokenRequired        = "common.token_required"
+	ErrMsgTokenRequired     = "bearer token or API key is required"
+	ErrScopeInsufficient    = "common.scope_insufficient"
+	ErrMsgScopeInsufficient = "bearer token does not have scope required by the request"
+
+	ErrRequestQuotaExceeded    = "common.request_quota_exceeded"
+	ErrMsgRequestQuotaExceeded = "tenant has exceeded its quota of requests per minute"
+	ErrRateLimited             = "common.rate_limited"
+	ErrMsgRateLimited          = "too many requests, retry later"
+
 	ErrCardCodeInvalid    = "carddeck.card.code_invalid"
 	ErrMsgCardCodeInvalid = "unknown card code"
 
+	ErrCardNotFound    = "carddeck.card.not_found"
+	ErrMsgCardNotFound = "card not found"
+
 	ErrDeckNotFound    = "carddeck.deck.not_found"
 	ErrMsgDeckNotFound = "deck not found"
 
 	ErrDeckCardInsufficient    = "carddeck.deck.card_insufficient"
 	ErrMsgDeckCardInsufficient = "card inside deck is not enough"
+
+	ErrDeckVersionMismatch    = "carddeck.deck.version_mismatch"
+	ErrMsgDeckVersionMismatch = "deck has been modified by another request"
+
+	ErrDeckCompactInvalid    = "carddeck.deck.compact_invalid"
+	ErrMsgDeckCompactInvalid = "invalid compact deck encoding"
+
+	ErrDeckImportInvalid    = "carddeck.deck.import_invalid"
+	ErrMsgDeckImportInvalid = "invalid deck import document"
+
+	ErrDeckEventNotFound    = "carddeck.deck_event.not_found"
+	ErrMsgDeckEventNotFound = "deck event not found"
+
+	ErrWebhookNotFound    = "carddeck.webhook.not_found"
+	ErrMsgWebhookNotFound = "webhook not found"
+
+	ErrAPIKeyNotFound    = "carddeck.api_key.not_found"
+	ErrMsgAPIKeyNotFound = "API key not found"
+
+	ErrTenantNotFound    = "carddeck.tenant.not_found"
+	ErrMsgTenantNotFound = "tenant not found"
+
+	ErrTenantDeckQuotaExceeded    = "carddeck.tenant.deck_quota_exceeded"
+	ErrMsgTenantDeckQuotaExceeded = "tenant has reached its quota of active decks"
 )
 
 type Error struct {
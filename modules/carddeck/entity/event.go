@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+const (
+	DeckEventDrawn    = "deck.drawn"
+	DeckEventShuffled = "deck.shuffled"
+	DeckEventReturned = "deck.returned"
+)
+
+// DeckEvent defines change happened to a deck, pushed to clients watching the deck.
+type DeckEvent struct {
+	// ID is assigned when the event is persisted, it increases with every event.
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	DeckID    string `json:"deck_id"`
+	Version   int64  `json:"version"`
+	Shuffled  bool   `json:"shuffled"`
+	Remaining int    `json:"remaining"`
+	// Cards contains the drawn or returned cards, empty for shuffle.
+	Cards      *Cards    `json:"cards,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// NewDeckEvent creates event describing deck state right after the change.
+func NewDeckEvent(eventType string, deck *Deck, cards *Cards) *DeckEvent {
+	remaining := 0
+	if deck.Cards != nil {
+		remaining = deck.Cards.Len()
+	}
+
+	return &DeckEvent{
+		Type:       eventType,
+		DeckID:     deck.ID,
+		Version:    deck.Version,
+		Shuffled:   deck.Shuffled,
+		Remaining:  remaining,
+		Cards:      cards,
+		OccurredAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// DeckExportVersion is the current version of deck export document.
+	DeckExportVersion = 2
+	// deckExportVersionPiles is the version of documents written with piles, which were always empty.
+	// Such documents are still imported.
+	deckExportVersionPiles = 1
+
+	checksumPrefix = "sha256:"
+)
+
+// DeckExport defines portable document of a deck state.
+// It is used to move deck between environments or to seed pre-arranged deck.
+type DeckExport struct {
+	Version  int                `json:"version"`
+	CardSet  string             `json:"card_set"`
+	Shuffled bool               `json:"shuffled"`
+	Cards    []string           `json:"cards"`
+	Metadata DeckExportMetadata `json:"metadata"`
+	Checksum string             `json:"checksum"`
+}
+
+// deckExportWithPiles is the hashed form of version 1 documents, written with empty piles
+type deckExportWithPiles struct {
+	Version  int                 `json:"version"`
+	CardSet  string              `json:"card_set"`
+	Shuffled bool                `json:"shuffled"`
+	Cards    []string            `json:"cards"`
+	Piles    map[string][]string `json:"piles"`
+	Metadata DeckExportMetadata  `json:"metadata"`
+	Checksum string              `json:"checksum"`
+}
+
+// DeckExportMetadata defines informational fields of deck export document.
+type DeckExportMetadata struct {
+	SourceID   string    `json:"source_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// NewDeckExport creates export document from deck, including its checksum.
+func NewDeckExport(deck *Deck, exportedAt time.Time) (*DeckExport, error) {
+	codes := []string{}
+	if deck.Cards != nil {
+		for _, card := range *deck.Cards {
+			codes = append(codes, card.Code)
+		}
+	}
+
+	doc := &DeckExport{
+		Version:  DeckExportVersion,
+		CardSet:  StandardCardSetID,
+		Shuffled: deck.Shuffled,
+		Cards:    codes,
+		Metadata: DeckExportMetadata{
+			SourceID:   deck.ID,
+			CreatedAt:  deck.CreatedAt,
+			UpdatedAt:  deck.UpdatedAt,
+			ExportedAt: exportedAt,
+		},
+	}
+
+	checksum, err := doc.ComputeChecksum()
+	if err != nil {
+		return nil, err
+	}
+	doc.Checksum = checksum
+
+	return doc, nil
+}
+
+// ComputeChecksum computes sha256 checksum of the document, excluding the checksum field itself.
+// Version 1 documents are hashed with their empty piles, so checksums of documents exported before stay valid.
+func (d *DeckExport) ComputeChecksum() (string, error) {
+	doc := *d
+	doc.Checksum = ""
+
+	var hashed interface{} = &doc
+	if doc.Version == deckExportVersionPiles {
+		hashed = &deckExportWithPiles{
+			Version:  doc.Version,
+			CardSet:  doc.CardSet,
+			Shuffled: doc.Shuffled,
+			Cards:    doc.Cards,
+			Piles:    map[string][]string{},
+			Metadata: doc.Metadata,
+		}
+	}
+
+	b, err := json.Marshal(hashed)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return checksumPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// Validate validates the document against its checksum and the card set registry,
+// then returns the cards in the document order.
+// Returned error contains details for every invalid field.
+func (d *DeckExport) Validate() (Cards, error) {
+	err := NewError(ErrDeckImportInvalid, ErrMsgDeckImportInvalid)
+
+	if d.Version != DeckExportVersion && d.Version != deckExportVersionPiles {
+		err.AddDetail(NewErrorDetail("version", fmt.Sprintf("unsupported version, expected %d", DeckExportVersion)))
+	}
+
+	checksum, checksumErr := d.ComputeChecksum()
+	if checksumErr != nil {
+		return nil, checksumErr
+	}
+	if checksum != d.Checksum {
+		err.AddDetail(NewErrorDetail("checksum", "checksum does not match document content"))
+	}
+
+	set, ok := GetCardSet(d.CardSet)
+	if !ok {
+		err.AddDetail(NewErrorDetail("card_set", "unknown card set"))
+		return nil, err
+	}
+
+	cards := make(Cards, len(d.Cards))
+	for i, code := range d.Cards {
+		card, ok := set.Card(code)
+		if !ok {
+			err.AddDetail(NewErrorDetail(fmt.Sprintf("cards[%d]", i), ErrMsgCardCodeInvalid))
+			continue
+		}
+		cards[i] = &card
+	}
+
+	if len(err.Details) > 0 {
+		return nil, err
+	}
+
+	return cards, nil
+}
@@ -0,0 +1,128 @@
+package entity_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeckExport(t *testing.T) {
+	timeTemp := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	newDoc := func() *entity.DeckExport {
+		deck := entity.NewDeck(true, &entity.Cards{
+			{Val: "3", Suit: "SPADE", Code: "3S"},
+			{Val: "ACE", Suit: "HEART", Code: "AH"},
+		})
+		deck.ID = "some-uuid-abc-def"
+		deck.CreatedAt = timeTemp
+		deck.UpdatedAt = timeTemp
+
+		doc, err := entity.NewDeckExport(deck, timeTemp)
+		assert.NoError(t, err)
+		return doc
+	}
+
+	t.Run("success export and validate", func(t *testing.T) {
+		doc := newDoc()
+		assert.Equal(t, entity.DeckExportVersion, doc.Version)
+		assert.Equal(t, entity.StandardCardSetID, doc.CardSet)
+		assert.Equal(t, true, doc.Shuffled)
+		assert.Equal(t, []string{"3S", "AH"}, doc.Cards)
+		assert.Equal(t, "some-uuid-abc-def", doc.Metadata.SourceID)
+		assert.Contains(t, doc.Checksum, "sha256:")
+
+		cards, err := doc.Validate()
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cards{
+			{Val: "3", Suit: "SPADE", Code: "3S"},
+			{Val: "ACE", Suit: "HEART", Code: "AH"},
+		}, cards)
+	})
+
+	t.Run("success validate after json round trip", func(t *testing.T) {
+		b, err := json.Marshal(newDoc())
+		assert.NoError(t, err)
+
+		var doc entity.DeckExport
+		assert.NoError(t, json.Unmarshal(b, &doc))
+
+		_, err = doc.Validate()
+		assert.NoError(t, err)
+	})
+
+	t.Run("success validate version 1 document with empty piles", func(t *testing.T) {
+		doc := newDoc()
+		b, err := json.Marshal(doc)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(b), `"piles"`)
+
+		// version 1 documents were hashed with empty piles between cards and metadata
+		metadata, err := json.Marshal(doc.Metadata)
+		assert.NoError(t, err)
+		hashed := `{"version":1,"card_set":"standard","shuffled":true,"cards":["3S","AH"],"piles":{},` +
+			`"metadata":` + string(metadata) + `,"checksum":""}`
+		sum := sha256.Sum256([]byte(hashed))
+
+		legacy := `{"version":1,"card_set":"standard","shuffled":true,"cards":["3S","AH"],"piles":{},` +
+			`"metadata":` + string(metadata) + `,"checksum":"sha256:` + hex.EncodeToString(sum[:]) + `"}`
+		var v1 entity.DeckExport
+		assert.NoError(t, json.Unmarshal([]byte(legacy), &v1))
+
+		cards, err := v1.Validate()
+		assert.NoError(t, err)
+		assert.Len(t, cards, 2)
+	})
+
+	t.Run("failed validate - tampered document", func(t *testing.T) {
+		doc := newDoc()
+		doc.Cards = []string{"AH", "3S"}
+
+		_, err := doc.Validate()
+		assert.Error(t, err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, entity.ErrDeckImportInvalid, perr.Code)
+		assert.Equal(t, []*entity.ErrorDetail{
+			entity.NewErrorDetail("checksum", "checksum does not match document content"),
+		}, perr.Details)
+	})
+
+	t.Run("failed validate - invalid fields", func(t *testing.T) {
+		doc := newDoc()
+		doc.Version = 99
+		doc.Cards = []string{"3S", "XX"}
+		doc.Checksum, _ = doc.ComputeChecksum()
+
+		_, err := doc.Validate()
+		assert.Error(t, err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, entity.ErrDeckImportInvalid, perr.Code)
+		assert.Equal(t, []*entity.ErrorDetail{
+			entity.NewErrorDetail("version", "unsupported version, expected 2"),
+			entity.NewErrorDetail("cards[1]", entity.ErrMsgCardCodeInvalid),
+		}, perr.Details)
+	})
+
+	t.Run("failed validate - unknown card set", func(t *testing.T) {
+		doc := newDoc()
+		doc.CardSet = "tarot"
+		doc.Checksum, _ = doc.ComputeChecksum()
+
+		_, err := doc.Validate()
+		assert.Error(t, err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, []*entity.ErrorDetail{
+			entity.NewErrorDetail("card_set", "unknown card set"),
+		}, perr.Details)
+	})
+}
@@ -0,0 +1,33 @@
+package entity
+
+var (
+	// glyphSuitBase is the code point of ace of the suit in Unicode playing cards block minus one
+	glyphSuitBase = map[string]rune{
+		"SPADE":   0x1F0A0,
+		"HEART":   0x1F0B0,
+		"DIAMOND": 0x1F0C0,
+		"CLUB":    0x1F0D0,
+	}
+	// glyphValueOffset skips the knight (C), which is not part of French playing cards
+	glyphValueOffset = map[string]rune{
+		"ACE": 0x1, "2": 0x2, "3": 0x3, "4": 0x4, "5": 0x5, "6": 0x6, "7": 0x7,
+		"8": 0x8, "9": 0x9, "10": 0xA, "JACK": 0xB, "QUEEN": 0xD, "KING": 0xE,
+	}
+)
+
+// GlyphBack is the glyph of the back of a card
+const GlyphBack = "\U0001F0A0"
+
+// Glyph returns the Unicode playing card of the card, e.g. 🂡 for ace of spades.
+// Returns GlyphBack for card outside standard French playing cards.
+func (c Card) Glyph() string {
+	base, ok := glyphSuitBase[c.Suit]
+	if !ok {
+		return GlyphBack
+	}
+	offset, ok := glyphValueOffset[c.Val]
+	if !ok {
+		return GlyphBack
+	}
+	return string(base + offset)
+}
@@ -0,0 +1,28 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Card_Glyph(t *testing.T) {
+	for card, glyph := range map[entity.Card]string{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"}:     "🂡",
+		{Val: "10", Suit: "HEART", Code: "10H"}:     "🂺",
+		{Val: "QUEEN", Suit: "DIAMOND", Code: "QD"}: "🃍",
+		{Val: "KING", Suit: "CLUB", Code: "KC"}:     "🃞",
+		{Val: "JOKER", Suit: "", Code: "X"}:         entity.GlyphBack,
+	} {
+		assert.Equal(t, glyph, card.Glyph(), card.Code)
+	}
+
+	// every standard card has its own glyph
+	glyphs := map[string]bool{}
+	for _, card := range entity.StandardCardSet.Cards {
+		glyphs[card.Glyph()] = true
+	}
+	assert.Len(t, glyphs, 52)
+	assert.False(t, glyphs[entity.GlyphBack])
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// IdempotencyRecord defines stored response of a request made with Idempotency-Key header.
+// StatusCode is 0 while the first request is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      map[string][]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
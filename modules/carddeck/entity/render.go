@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	suitSymbols = map[string]string{"SPADE": "♠", "HEART": "♥", "DIAMOND": "♦", "CLUB": "♣"}
+	suitLetters = map[string]string{"SPADE": "S", "HEART": "H", "DIAMOND": "D", "CLUB": "C"}
+	suitNames   = map[string]string{"SPADE": "Spades", "HEART": "Hearts", "DIAMOND": "Diamonds", "CLUB": "Clubs"}
+
+	valueLabels = map[string]string{"ACE": "A", "JACK": "J", "QUEEN": "Q", "KING": "K"}
+	valueNames  = map[string]string{"ACE": "Ace", "JACK": "Jack", "QUEEN": "Queen", "KING": "King"}
+)
+
+// Name returns English name of the card, e.g. Queen of Hearts
+func (c Card) Name() string {
+	value, ok := valueNames[c.Val]
+	if !ok {
+		value = c.Val
+	}
+	suit, ok := suitNames[c.Suit]
+	if !ok {
+		suit = c.Suit
+	}
+	return fmt.Sprintf("%s of %s", value, suit)
+}
+
+// Short returns short notation of the card, e.g. Q♥.
+// Returns the card code for card outside standard French playing cards.
+func (c Card) Short() string {
+	suit, ok := suitSymbols[c.Suit]
+	if !ok {
+		return c.Code
+	}
+	return c.valueLabel() + suit
+}
+
+// ASCIIArt returns multi-line ASCII art of the card, showing the suit by its letter:
+//
+//	+-----+
+//	|Q    |
+//	|  H  |
+//	|    Q|
+//	+-----+
+func (c Card) ASCIIArt() string {
+	suit, ok := suitLetters[c.Suit]
+	if !ok {
+		suit = "?"
+	}
+	label := c.valueLabel()
+
+	return strings.Join([]string{
+		"+-----+",
+		fmt.Sprintf("|%-5s|", label),
+		fmt.Sprintf("|  %s  |", suit),
+		fmt.Sprintf("|%5s|", label),
+		"+-----+",
+	}, "\n")
+}
+
+// ImagePath returns path of SVG image of the card served by REST API, e.g. /cards/QH.svg
+func (c Card) ImagePath() string {
+	return "/cards/" + url.PathEscape(c.Code) + ".svg"
+}
+
+func (c Card) valueLabel() string {
+	if label, ok := valueLabels[c.Val]; ok {
+		return label
+	}
+	return c.Val
+}
@@ -0,0 +1,41 @@
+package entity_test
+
+import (
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Card_Name(t *testing.T) {
+	for card, name := range map[entity.Card]string{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"}:     "Ace of Spades",
+		{Val: "10", Suit: "HEART", Code: "10H"}:     "10 of Hearts",
+		{Val: "QUEEN", Suit: "DIAMOND", Code: "QD"}: "Queen of Diamonds",
+		{Val: "KING", Suit: "CLUB", Code: "KC"}:     "King of Clubs",
+	} {
+		assert.Equal(t, name, card.Name(), card.Code)
+	}
+}
+
+func Test_Card_Short(t *testing.T) {
+	for card, short := range map[entity.Card]string{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"}:     "A♠",
+		{Val: "10", Suit: "HEART", Code: "10H"}:     "10♥",
+		{Val: "QUEEN", Suit: "DIAMOND", Code: "QD"}: "Q♦",
+		{Val: "KING", Suit: "CLUB", Code: "KC"}:     "K♣",
+		{Val: "JOKER", Suit: "", Code: "X"}:         "X",
+	} {
+		assert.Equal(t, short, card.Short(), card.Code)
+	}
+}
+
+func Test_Card_ASCIIArt(t *testing.T) {
+	assert.Equal(t, "+-----+\n|Q    |\n|  H  |\n|    Q|\n+-----+", entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"}.ASCIIArt())
+	assert.Equal(t, "+-----+\n|10   |\n|  S  |\n|   10|\n+-----+", entity.Card{Val: "10", Suit: "SPADE", Code: "10S"}.ASCIIArt())
+}
+
+func Test_Card_ImagePath(t *testing.T) {
+	assert.Equal(t, "/cards/QH.svg", entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"}.ImagePath())
+	assert.Equal(t, "/cards/10S.svg", entity.Card{Val: "10", Suit: "SPADE", Code: "10S"}.ImagePath())
+}
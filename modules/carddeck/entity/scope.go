@@ -0,0 +1,41 @@
+package entity
+
+import "context"
+
+// Scopes granted to bearer tokens, see Scopes.Allow
+const (
+	// ScopeDeckRead allows reading and watching decks
+	ScopeDeckRead = "deck:read"
+	// ScopeDeckDraw allows creating decks and playing with them: drawing, shuffling and returning cards
+	ScopeDeckDraw = "deck:draw"
+	// ScopeDeckAdmin allows every operation, including deleting and importing decks and managing webhooks
+	ScopeDeckAdmin = "deck:admin"
+)
+
+// Scopes is list of scopes granted to a bearer token
+type Scopes []string
+
+// Allow reports whether the scopes grant scope, ScopeDeckAdmin grants every scope
+func (s Scopes) Allow(scope string) bool {
+	for _, granted := range s {
+		if granted == scope || granted == ScopeDeckAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// scopesKey is context key holding scopes of the bearer token making the request
+type scopesKey struct{}
+
+// WithScopes returns ctx carrying scopes of the bearer token making the request
+func WithScopes(ctx context.Context, scopes Scopes) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// ScopesFromContext returns scopes carried by ctx.
+// ok is false for requests without bearer token, which are not limited by scopes.
+func ScopesFromContext(ctx context.Context) (scopes Scopes, ok bool) {
+	scopes, ok = ctx.Value(scopesKey{}).(Scopes)
+	return scopes, ok
+}
@@ -0,0 +1,38 @@
+package entity_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scopes_Allow(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes entity.Scopes
+		scope  string
+		want   bool
+	}{
+		{"granted", entity.Scopes{entity.ScopeDeckRead, entity.ScopeDeckDraw}, entity.ScopeDeckDraw, true},
+		{"not granted", entity.Scopes{entity.ScopeDeckRead}, entity.ScopeDeckDraw, false},
+		{"admin grants every scope", entity.Scopes{entity.ScopeDeckAdmin}, entity.ScopeDeckRead, true},
+		{"empty", nil, entity.ScopeDeckRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.scopes.Allow(tt.scope))
+		})
+	}
+}
+
+func Test_ScopesFromContext(t *testing.T) {
+	_, ok := entity.ScopesFromContext(context.Background())
+	assert.False(t, ok)
+
+	scopes, ok := entity.ScopesFromContext(entity.WithScopes(context.Background(), entity.Scopes{entity.ScopeDeckRead}))
+	assert.True(t, ok)
+	assert.Equal(t, entity.Scopes{entity.ScopeDeckRead}, scopes)
+}
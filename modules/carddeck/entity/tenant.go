@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TenantNameMaxLength is maximum length of tenant name
+const TenantNameMaxLength = 255
+
+// Tenant is a studio hosting its decks on the deployment, decks of other tenants are not visible to it.
+// Decks created without tenant are visible to every tenant.
+type Tenant struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// MaxActiveDecks and MaxRequestsPerMinute override default quota of the server when not nil
+	MaxActiveDecks       *int      `json:"max_active_decks,omitempty" db:"max_active_decks"`
+	MaxRequestsPerMinute *int      `json:"max_requests_per_minute,omitempty" db:"max_requests_per_minute"`
+	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+}
+
+// TenantQuota limits usage of a tenant, 0 means unlimited
+type TenantQuota struct {
+	// MaxActiveDecks is maximum number of decks the tenant has not deleted
+	MaxActiveDecks int `json:"max_active_decks"`
+	// MaxRequestsPerMinute is maximum number of API requests of the tenant per minute
+	MaxRequestsPerMinute int `json:"max_requests_per_minute"`
+}
+
+// Validate validates name and quota of the tenant
+func (t *Tenant) Validate() error {
+	err := NewError(ErrParamInvalid, ErrMsgParamInvalid)
+	if len(t.Name) > TenantNameMaxLength {
+		err.AddDetail(NewErrorDetail("name", fmt.Sprintf("name must not be longer than %d characters", TenantNameMaxLength)))
+	}
+	if t.MaxActiveDecks != nil && *t.MaxActiveDecks < 0 {
+		err.AddDetail(NewErrorDetail("max_active_decks", "max_active_decks must not be negative"))
+	}
+	if t.MaxRequestsPerMinute != nil && *t.MaxRequestsPerMinute < 0 {
+		err.AddDetail(NewErrorDetail("max_requests_per_minute", "max_requests_per_minute must not be negative"))
+	}
+
+	if len(err.Details) > 0 {
+		return err
+	}
+	return nil
+}
+
+// Quota returns quota of the tenant, using defaults for limits the tenant does not override
+func (t *Tenant) Quota(defaults TenantQuota) TenantQuota {
+	quota := defaults
+	if t.MaxActiveDecks != nil {
+		quota.MaxActiveDecks = *t.MaxActiveDecks
+	}
+	if t.MaxRequestsPerMinute != nil {
+		quota.MaxRequestsPerMinute = *t.MaxRequestsPerMinute
+	}
+	return quota
+}
+
+// tenantKey is context key holding ID of the tenant making the request
+type tenantKey struct{}
+
+// WithTenant returns ctx carrying ID of the tenant making the request, set by authentication of the request.
+// Decks created with ctx belong to the tenant, and decks of other tenants are not visible.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns ID of the tenant carried by ctx, empty for anonymous requests
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
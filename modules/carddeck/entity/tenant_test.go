@@ -0,0 +1,35 @@
+package entity_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Tenant_Quota(t *testing.T) {
+	defaults := entity.TenantQuota{MaxActiveDecks: 100, MaxRequestsPerMinute: 600}
+	unlimited := 0
+
+	assert.Equal(t, defaults, (&entity.Tenant{}).Quota(defaults))
+	assert.Equal(t, entity.TenantQuota{MaxActiveDecks: 0, MaxRequestsPerMinute: 600}, (&entity.Tenant{MaxActiveDecks: &unlimited}).Quota(defaults))
+}
+
+func Test_Tenant_Validate(t *testing.T) {
+	negative := -1
+
+	assert.NoError(t, (&entity.Tenant{Name: "studio"}).Validate())
+
+	err := (&entity.Tenant{Name: strings.Repeat("a", 256), MaxRequestsPerMinute: &negative}).Validate()
+	perr, ok := err.(*entity.Error)
+	assert.True(t, ok)
+	assert.Equal(t, entity.ErrParamInvalid, perr.Code)
+	assert.Len(t, perr.Details, 2)
+}
+
+func Test_TenantFromContext(t *testing.T) {
+	assert.Empty(t, entity.TenantFromContext(context.Background()))
+	assert.Equal(t, "tenant-1", entity.TenantFromContext(entity.WithTenant(context.Background(), "tenant-1")))
+}
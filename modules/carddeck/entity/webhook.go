@@ -0,0 +1,165 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	WebhookEventDeckCreated   = "deck.created"
+	WebhookEventDeckExhausted = "deck.exhausted"
+	WebhookEventDeckDeleted   = "deck.deleted"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+
+	// WebhookSignatureHeader contains timestamp and HMAC-SHA256 signature of the delivery, see SignWebhook
+	WebhookSignatureHeader = "X-Carddeck-Signature"
+	WebhookEventHeader     = "X-Carddeck-Event"
+	WebhookDeliveryHeader  = "X-Carddeck-Delivery"
+
+	webhookSecretMinLength = 16
+)
+
+// WebhookEvents lists events a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventDeckCreated, WebhookEventDeckExhausted, WebhookEventDeckDeleted}
+
+// Webhook defines subscription of an URL to deck lifecycle events
+type Webhook struct {
+	ID        string           `json:"id" db:"id"`
+	URL       string           `json:"url" db:"url"`
+	Events    WebhookEventList `json:"events" db:"events"`
+	Secret    string           `json:"-" db:"secret"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
+
+// Validate validates url, events and secret of the webhook.
+// Returned error contains details for every invalid field.
+func (w *Webhook) Validate() error {
+	err := NewError(ErrParamInvalid, ErrMsgParamInvalid)
+
+	u, parseErr := url.Parse(w.URL)
+	if parseErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		err.AddDetail(NewErrorDetail("url", "url must be an absolute http or https URL"))
+	}
+
+	if len(w.Events) == 0 {
+		err.AddDetail(NewErrorDetail("events", "events must not be empty"))
+	}
+	for i, event := range w.Events {
+		if !isWebhookEvent(event) {
+			err.AddDetail(NewErrorDetail(fmt.Sprintf("events[%d]", i), fmt.Sprintf("unknown event, supported events are %v", WebhookEvents)))
+		}
+	}
+
+	if len(w.Secret) < webhookSecretMinLength {
+		err.AddDetail(NewErrorDetail("secret", fmt.Sprintf("secret must be at least %d characters", webhookSecretMinLength)))
+	}
+
+	if len(err.Details) > 0 {
+		return err
+	}
+	return nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventList defines events subscribed by a webhook, stored as JSON array
+type WebhookEventList []string
+
+// Scan implements scanner interface
+func (l *WebhookEventList) Scan(val interface{}) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// Value implements valuer interface
+func (l WebhookEventList) Value() (driver.Value, error) {
+	return json.Marshal([]string(l))
+}
+
+// WebhookPayload defines body sent to webhook URL
+type WebhookPayload struct {
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Deck       WebhookDeck `json:"deck"`
+}
+
+// WebhookDeck defines deck state sent in webhook payload
+type WebhookDeck struct {
+	ID        string `json:"id"`
+	Shuffled  bool   `json:"shuffled"`
+	Remaining int    `json:"remaining"`
+}
+
+// NewWebhookPayload creates payload describing deck state right after the event
+func NewWebhookPayload(eventType string, deck *Deck) *WebhookPayload {
+	remaining := 0
+	if deck.Cards != nil {
+		remaining = deck.Cards.Len()
+	}
+
+	return &WebhookPayload{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Deck: WebhookDeck{
+			ID:        deck.ID,
+			Shuffled:  deck.Shuffled,
+			Remaining: remaining,
+		},
+	}
+}
+
+// WebhookDelivery defines a single delivery of webhook payload, stored in the outbox until it is delivered.
+// Delivery becomes dead (see WebhookDeliveryDead) after every attempt failed.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      string          `json:"webhook_id" db:"webhook_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload" swaggertype:"object"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	LastStatusCode int             `json:"last_status_code" db:"last_status_code"`
+	LastError      string          `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+
+	// URL and Secret of the webhook, only loaded for dispatching
+	URL    string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+}
+
+// SignWebhook returns value of WebhookSignatureHeader: "t=<unix timestamp>,v1=<signature>",
+// where signature is hex encoded HMAC-SHA256 of "<unix timestamp>.<body>" using the webhook secret.
+// Receivers should compute the same signature and reject old timestamps to prevent replays.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package entity_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Webhook_Validate(t *testing.T) {
+	valid := func() *entity.Webhook {
+		return &entity.Webhook{
+			URL:    "https://example.com/hook",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated, entity.WebhookEventDeckExhausted},
+			Secret: "0123456789abcdef",
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		assert.NoError(t, valid().Validate())
+	})
+
+	t.Run("failed - every invalid field is reported", func(t *testing.T) {
+		webhook := &entity.Webhook{
+			URL:    "/relative",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated, "deck.drawn"},
+			Secret: "short",
+		}
+
+		perr, ok := webhook.Validate().(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, entity.ErrParamInvalid, perr.Code)
+
+		var fields []string
+		for _, detail := range perr.Details {
+			fields = append(fields, detail.Field)
+		}
+		assert.Equal(t, []string{"url", "events[1]", "secret"}, fields)
+	})
+
+	t.Run("failed - events empty", func(t *testing.T) {
+		webhook := valid()
+		webhook.Events = nil
+
+		perr, ok := webhook.Validate().(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, "events", perr.Details[0].Field)
+	})
+
+	t.Run("failed - unsupported scheme", func(t *testing.T) {
+		webhook := valid()
+		webhook.URL = "ftp://example.com/hook"
+
+		perr, ok := webhook.Validate().(*entity.Error)
+		assert.True(t, ok)
+		assert.Equal(t, "url", perr.Details[0].Field)
+	})
+}
+
+func Test_WebhookEventList_Scan(t *testing.T) {
+	var events entity.WebhookEventList
+	assert.NoError(t, events.Scan([]byte(`["deck.created","deck.deleted"]`)))
+	assert.Equal(t, entity.WebhookEventList{entity.WebhookEventDeckCreated, entity.WebhookEventDeckDeleted}, events)
+
+	assert.Error(t, events.Scan(42))
+}
+
+func Test_SignWebhook(t *testing.T) {
+	ts := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"deck.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("0123456789abcdef"))
+	mac.Write([]byte(`1640998800.{"type":"deck.created"}`))
+	expected := "t=1640998800,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, entity.SignWebhook("0123456789abcdef", ts, body))
+	assert.NotEqual(t, expected, entity.SignWebhook("another-secret-value", ts, body))
+}
+
+func Test_NewWebhookPayload(t *testing.T) {
+	deck := entity.NewDeck(true, &entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}})
+	deck.ID = "deck-1"
+
+	payload := entity.NewWebhookPayload(entity.WebhookEventDeckCreated, deck)
+	assert.Equal(t, entity.WebhookEventDeckCreated, payload.Type)
+	assert.Equal(t, entity.WebhookDeck{ID: "deck-1", Shuffled: true, Remaining: 1}, payload.Deck)
+
+	payload = entity.NewWebhookPayload(entity.WebhookEventDeckDeleted, &entity.Deck{ID: "deck-1"})
+	assert.Equal(t, 0, payload.Deck.Remaining)
+}
@@ -0,0 +1,227 @@
+// Package cardimage generates SVG and PNG images of card faces and backs.
+// Labels are drawn as glyph outlines of Go font, hence images look the same everywhere and need no font to rasterize.
+package cardimage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Width and Height are size of the image in pixels
+	Width  = 250
+	Height = 350
+
+	// DefaultBackColor is color of card back when none is specified
+	DefaultBackColor = "#1f4e9c"
+
+	red   = "#c8102e"
+	black = "#1a1a1a"
+)
+
+var (
+	labelFont = func() *sfnt.Font {
+		f, err := sfnt.Parse(gobold.TTF)
+		if err != nil {
+			panic(err)
+		}
+		return f
+	}()
+
+	// suitShapes draws suit inside 100x100 box
+	suitShapes = map[string]string{
+		"HEART":   `<path d="M50 92 C20 66 0 46 0 28 C0 12 12 0 27 0 C38 0 46 7 50 17 C54 7 62 0 73 0 C88 0 100 12 100 28 C100 46 80 66 50 92 Z"/>`,
+		"DIAMOND": `<path d="M50 0 L86 50 L50 100 L14 50 Z"/>`,
+		"SPADE":   `<path d="M50 0 C80 26 100 44 100 62 C100 78 88 88 74 88 C64 88 57 83 53 76 C54 86 58 94 66 100 L34 100 C42 94 46 86 47 76 C43 83 36 88 26 88 C12 88 0 78 0 62 C0 44 20 26 50 0 Z"/>`,
+		"CLUB":    `<circle cx="50" cy="25" r="23"/><circle cx="25" cy="60" r="23"/><circle cx="75" cy="60" r="23"/><path d="M50 40 C52 74 56 90 66 100 L34 100 C44 90 48 74 50 40 Z"/>`,
+	}
+
+	// pips lists center of suit symbols drawn on number cards
+	pips = map[string][][2]float64{
+		"2":  {{125, 85}, {125, 265}},
+		"3":  {{125, 85}, {125, 175}, {125, 265}},
+		"4":  {{80, 85}, {170, 85}, {80, 265}, {170, 265}},
+		"5":  {{80, 85}, {170, 85}, {125, 175}, {80, 265}, {170, 265}},
+		"6":  {{80, 85}, {170, 85}, {80, 175}, {170, 175}, {80, 265}, {170, 265}},
+		"7":  {{80, 85}, {170, 85}, {125, 130}, {80, 175}, {170, 175}, {80, 265}, {170, 265}},
+		"8":  {{80, 85}, {170, 85}, {125, 130}, {80, 175}, {170, 175}, {125, 220}, {80, 265}, {170, 265}},
+		"9":  {{80, 85}, {170, 85}, {80, 145}, {170, 145}, {125, 175}, {80, 205}, {170, 205}, {80, 265}, {170, 265}},
+		"10": {{80, 85}, {170, 85}, {125, 115}, {80, 145}, {170, 145}, {80, 205}, {170, 205}, {125, 235}, {80, 265}, {170, 265}},
+	}
+
+	faceLetters = map[string]string{"JACK": "J", "QUEEN": "Q", "KING": "K"}
+)
+
+// Face returns SVG image of card face, showing its value and suit
+func Face(card entity.Card) []byte {
+	color := black
+	if card.Suit == "HEART" || card.Suit == "DIAMOND" {
+		color = red
+	}
+
+	label, ok := faceLetters[card.Val]
+	switch {
+	case card.Val == "ACE":
+		label = "A"
+	case !ok:
+		label = card.Val
+	}
+
+	var b strings.Builder
+	writeHeader(&b)
+	b.WriteString(`<rect x="2" y="2" width="246" height="346" rx="16" fill="#ffffff" stroke="#9a9a9a" stroke-width="2"/>`)
+	fmt.Fprintf(&b, `<g fill="%s">`, color)
+
+	// corner indexes, the bottom one is upside down
+	for _, rotate := range []bool{false, true} {
+		if rotate {
+			fmt.Fprintf(&b, `<g transform="rotate(180 %d %d)">`, Width/2, Height/2)
+		} else {
+			b.WriteString(`<g>`)
+		}
+		b.WriteString(textPath(label, 34, 24, 44))
+		writeSuit(&b, card.Suit, 24, 62, 22, false)
+		b.WriteString(`</g>`)
+	}
+
+	switch {
+	case card.Val == "ACE":
+		writeSuit(&b, card.Suit, Width/2, Height/2, 110, false)
+	case ok:
+		fmt.Fprintf(&b, `<rect x="55" y="70" width="140" height="210" rx="8" fill="none" stroke="%s" stroke-width="3"/>`, color)
+		b.WriteString(textPath(label, 110, Width/2, 215))
+		writeSuit(&b, card.Suit, 80, 100, 30, false)
+		writeSuit(&b, card.Suit, 170, 250, 30, true)
+	default:
+		for _, pip := range pips[card.Val] {
+			writeSuit(&b, card.Suit, pip[0], pip[1], 44, pip[1] > Height/2)
+		}
+	}
+
+	b.WriteString(`</g></svg>`)
+	return []byte(b.String())
+}
+
+// Back returns SVG image of card back filled with color, which must be a valid hex color like #1f4e9c
+func Back(color string) []byte {
+	var b strings.Builder
+	writeHeader(&b)
+	b.WriteString(`<rect x="2" y="2" width="246" height="346" rx="16" fill="#ffffff" stroke="#9a9a9a" stroke-width="2"/>`)
+	fmt.Fprintf(&b, `<rect x="14" y="14" width="222" height="322" rx="10" fill="%s"/>`, color)
+	b.WriteString(`<rect x="24" y="24" width="202" height="302" rx="6" fill="none" stroke="#ffffff" stroke-width="3"/>`)
+
+	// lattice of diamonds
+	b.WriteString(`<g fill="#ffffff" fill-opacity="0.35">`)
+	for y := 45; y <= 305; y += 26 {
+		for x := 45; x <= 205; x += 32 {
+			fmt.Fprintf(&b, `<path d="M%d %d l10 13 l-10 13 l-10 -13 Z"/>`, x, y-13)
+		}
+	}
+	b.WriteString(`</g>`)
+	fmt.Fprintf(&b, `<path d="M125 120 L175 175 L125 230 L75 175 Z" fill="#ffffff" stroke="%s" stroke-width="4"/>`, color)
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// PNG rasterizes SVG image generated by this package into PNG
+func PNG(svg []byte) ([]byte, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(svg), oksvg.StrictErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("parsing svg: %w", err)
+	}
+	icon.SetTarget(0, 0, Width, Height)
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	scanner := rasterx.NewScannerGV(Width, Height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(Width, Height, scanner), 1)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(b *strings.Builder) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, Width, Height, Width, Height)
+}
+
+// writeSuit draws suit centered at x, y scaled into size, rotated upside down if flipped
+func writeSuit(b *strings.Builder, suit string, x, y, size float64, flipped bool) {
+	shape, ok := suitShapes[suit]
+	if !ok {
+		return
+	}
+
+	rotate := ""
+	if flipped {
+		rotate = " rotate(180)"
+	}
+	fmt.Fprintf(b, `<g transform="translate(%g %g)%s scale(%g %g) translate(-50 -50)">%s</g>`, x, y, rotate, size/100, size/100, shape)
+}
+
+// textPath returns path drawing text centered horizontally at x, with baseline at y
+func textPath(text string, size, x, y float64) string {
+	var (
+		buf     sfnt.Buffer
+		ppem    = fixed.Int26_6(size * 64)
+		advance fixed.Int26_6
+		glyphs  []sfnt.GlyphIndex
+	)
+	for _, r := range text {
+		idx, err := labelFont.GlyphIndex(&buf, r)
+		if err != nil || idx == 0 {
+			continue
+		}
+		adv, err := labelFont.GlyphAdvance(&buf, idx, ppem, 0)
+		if err != nil {
+			continue
+		}
+		glyphs = append(glyphs, idx)
+		advance += adv
+	}
+
+	var d strings.Builder
+	originX := x - float64(advance)/128
+	for _, idx := range glyphs {
+		segments, err := labelFont.LoadGlyph(&buf, idx, ppem, nil)
+		if err != nil {
+			continue
+		}
+		for _, seg := range segments {
+			d.WriteString(segmentPath(seg, originX, y))
+		}
+
+		adv, _ := labelFont.GlyphAdvance(&buf, idx, ppem, 0)
+		originX += float64(adv) / 64
+	}
+
+	return fmt.Sprintf(`<path d="%s"/>`, strings.TrimSpace(d.String()))
+}
+
+func segmentPath(seg sfnt.Segment, x, y float64) string {
+	point := func(p fixed.Point26_6) string {
+		return fmt.Sprintf("%.2f %.2f", x+float64(p.X)/64, y+float64(p.Y)/64)
+	}
+
+	switch seg.Op {
+	case sfnt.SegmentOpMoveTo:
+		return "M" + point(seg.Args[0]) + " "
+	case sfnt.SegmentOpLineTo:
+		return "L" + point(seg.Args[0]) + " "
+	case sfnt.SegmentOpQuadTo:
+		return "Q" + point(seg.Args[0]) + " " + point(seg.Args[1]) + " "
+	default:
+		return "C" + point(seg.Args[0]) + " " + point(seg.Args[1]) + " " + point(seg.Args[2]) + " "
+	}
+}
@@ -0,0 +1,74 @@
+package cardimage_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/cardimage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertValidSVG asserts svg is well-formed XML sized as the card
+func assertValidSVG(t *testing.T, svg []byte) {
+	var root struct {
+		XMLName xml.Name
+		Width   int `xml:"width,attr"`
+		Height  int `xml:"height,attr"`
+	}
+	require.NoError(t, xml.Unmarshal(svg, &root))
+	assert.Equal(t, "svg", root.XMLName.Local)
+	assert.Equal(t, cardimage.Width, root.Width)
+	assert.Equal(t, cardimage.Height, root.Height)
+}
+
+func Test_Face(t *testing.T) {
+	t.Run("success - every standard card is valid and distinct", func(t *testing.T) {
+		faces := map[string]bool{}
+		for _, card := range entity.StandardCardSet.Cards {
+			svg := cardimage.Face(card)
+			assertValidSVG(t, svg)
+			faces[string(svg)] = true
+		}
+		assert.Len(t, faces, 52)
+	})
+
+	t.Run("success - red suits are drawn red", func(t *testing.T) {
+		assert.Contains(t, string(cardimage.Face(entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"})), `fill="#c8102e"`)
+		assert.NotContains(t, string(cardimage.Face(entity.Card{Val: "QUEEN", Suit: "SPADE", Code: "QS"})), `fill="#c8102e"`)
+	})
+}
+
+func Test_Back(t *testing.T) {
+	svg := cardimage.Back("#00ff00")
+
+	assertValidSVG(t, svg)
+	assert.Contains(t, string(svg), `fill="#00ff00"`)
+}
+
+func Test_PNG(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		raw, err := cardimage.PNG(cardimage.Face(entity.Card{Val: "10", Suit: "CLUB", Code: "10C"}))
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(raw))
+		require.NoError(t, err)
+		assert.Equal(t, cardimage.Width, img.Bounds().Dx())
+		assert.Equal(t, cardimage.Height, img.Bounds().Dy())
+
+		// top lobe of the center pip of ten of clubs
+		r, g, b, a := img.At(125, 104).RGBA()
+		assert.Less(t, r, uint32(0x8000))
+		assert.Less(t, g, uint32(0x8000))
+		assert.Less(t, b, uint32(0x8000))
+		assert.NotZero(t, a)
+	})
+
+	t.Run("failed - svg unsupported", func(t *testing.T) {
+		_, err := cardimage.PNG([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><unknown/></svg>`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,118 @@
+// Package event implements in-process publish/subscribe of deck events
+package event
+
+import (
+	"sync"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// SubscriberBuffer is the number of events buffered for each subscriber.
+// Subscriber that falls behind by more than this is dropped, so one slow client never blocks publishers.
+const SubscriberBuffer = 16
+
+// Hub fans out deck events to subscribers of the deck
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *entity.DeckEvent]struct{}
+	closed      bool
+}
+
+// NewHub creates new hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[string]map[chan *entity.DeckEvent]struct{}{},
+	}
+}
+
+// Publish sends event to every subscriber of the deck without blocking.
+// Subscriber with full buffer is dropped and its channel is closed.
+func (h *Hub) Publish(event *entity.DeckEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.DeckID] {
+		select {
+		case ch <- event:
+		default:
+			log.Warn().Str("deck_id", event.DeckID).Msg("[event] dropping slow subscriber")
+			h.remove(event.DeckID, ch)
+		}
+	}
+}
+
+// Subscribe returns channel receiving events of the deck and function to stop the subscription.
+// The channel is closed when the subscription stops, the subscriber is too slow or the hub is closed.
+func (h *Hub) Subscribe(deckID string) (<-chan *entity.DeckEvent, func()) {
+	ch := make(chan *entity.DeckEvent, SubscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if h.subscribers[deckID] == nil {
+		h.subscribers[deckID] = map[chan *entity.DeckEvent]struct{}{}
+	}
+	h.subscribers[deckID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(deckID, ch)
+	}
+}
+
+// Close closes every subscription and rejects new ones, used on server shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for deckID, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			h.remove(deckID, ch)
+		}
+	}
+}
+
+// remove closes the subscriber channel if it is still subscribed, caller must hold the lock
+func (h *Hub) remove(deckID string, ch chan *entity.DeckEvent) {
+	subscribers, ok := h.subscribers[deckID]
+	if !ok {
+		return
+	}
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, deckID)
+	}
+}
+
+// SubscribeOnly exposes subscriptions of the hub while ignoring published events.
+// It is used when events reach the hub from another source, such as database notifications,
+// so events published by the service are not delivered twice.
+type SubscribeOnly struct {
+	hub *Hub
+}
+
+// NewSubscribeOnly wraps the hub
+func NewSubscribeOnly(hub *Hub) *SubscribeOnly {
+	return &SubscribeOnly{hub: hub}
+}
+
+// Publish ignores the event
+func (s *SubscribeOnly) Publish(*entity.DeckEvent) {}
+
+// Subscribe subscribes to the wrapped hub
+func (s *SubscribeOnly) Subscribe(deckID string) (<-chan *entity.DeckEvent, func()) {
+	return s.hub.Subscribe(deckID)
+}
@@ -0,0 +1,85 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Hub(t *testing.T) {
+	t.Run("success - event is sent to subscribers of the deck only", func(t *testing.T) {
+		hub := event.NewHub()
+		first, unsubscribeFirst := hub.Subscribe("deck-1")
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := hub.Subscribe("deck-1")
+		defer unsubscribeSecond()
+		other, unsubscribeOther := hub.Subscribe("deck-2")
+		defer unsubscribeOther()
+
+		ev := &entity.DeckEvent{Type: entity.DeckEventDrawn, DeckID: "deck-1"}
+		hub.Publish(ev)
+
+		assert.Equal(t, ev, <-first)
+		assert.Equal(t, ev, <-second)
+		assert.Len(t, other, 0)
+	})
+
+	t.Run("success - unsubscribe closes the channel", func(t *testing.T) {
+		hub := event.NewHub()
+		ch, unsubscribe := hub.Subscribe("deck-1")
+		unsubscribe()
+		// calling it twice is safe
+		unsubscribe()
+
+		_, ok := <-ch
+		assert.False(t, ok)
+
+		hub.Publish(&entity.DeckEvent{Type: entity.DeckEventDrawn, DeckID: "deck-1"})
+	})
+
+	t.Run("success - slow subscriber is dropped", func(t *testing.T) {
+		hub := event.NewHub()
+		slow, unsubscribeSlow := hub.Subscribe("deck-1")
+		defer unsubscribeSlow()
+
+		for i := 0; i < event.SubscriberBuffer+1; i++ {
+			hub.Publish(&entity.DeckEvent{Type: entity.DeckEventDrawn, DeckID: "deck-1", Version: int64(i)})
+		}
+
+		received := 0
+		for range slow {
+			received++
+		}
+		assert.Equal(t, event.SubscriberBuffer, received)
+	})
+
+	t.Run("success - close ends every subscription", func(t *testing.T) {
+		hub := event.NewHub()
+		ch, unsubscribe := hub.Subscribe("deck-1")
+		defer unsubscribe()
+
+		hub.Close()
+		_, ok := <-ch
+		assert.False(t, ok)
+
+		late, _ := hub.Subscribe("deck-1")
+		_, ok = <-late
+		assert.False(t, ok)
+	})
+}
+
+func Test_SubscribeOnly(t *testing.T) {
+	hub := event.NewHub()
+	broker := event.NewSubscribeOnly(hub)
+	ch, unsubscribe := broker.Subscribe("deck-1")
+	defer unsubscribe()
+
+	broker.Publish(&entity.DeckEvent{Type: entity.DeckEventDrawn, DeckID: "deck-1"})
+	assert.Len(t, ch, 0)
+
+	ev := &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1"}
+	hub.Publish(ev)
+	assert.Equal(t, ev, <-ch)
+}
@@ -0,0 +1,37 @@
+package graphql
+
+import (
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// resolverError exposes entity.Error code and details as GraphQL error extensions
+type resolverError struct {
+	err *entity.Error
+}
+
+// Error implements error interface
+func (e *resolverError) Error() string {
+	return e.err.Message
+}
+
+// Extensions implements extensions of GraphQL error
+func (e *resolverError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code": e.err.Code,
+	}
+	if len(e.err.Details) > 0 {
+		extensions["error_details"] = e.err.Details
+	}
+	return extensions
+}
+
+// toError converts error returned by service to GraphQL error,
+// errors other than entity.Error are not exposed
+func toError(err error) error {
+	perr, ok := err.(*entity.Error)
+	if !ok {
+		perr = entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	return &resolverError{err: perr}
+}
@@ -0,0 +1,103 @@
+// Package graphql contains implementation for GraphQL API handler for carddeck module
+package graphql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Request defines request body for POST /graphql
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+const (
+	// DefaultMaxBodySize is maximum size in bytes of request bodies, unless WithMaxBodySize is used
+	DefaultMaxBodySize = 1 << 20
+	// maxDepth is maximum nesting of fields in a query. Fields of the schema are nested at most 3 levels,
+	// the rest leaves room for introspection queries of GraphQL tools.
+	maxDepth = 12
+	// maxParallelism is maximum number of resolvers of a request running in parallel
+	maxParallelism = 4
+)
+
+// Handler defines GraphQL API handler for card deck
+type Handler struct {
+	schema      *graphql.Schema
+	maxBodySize int64
+}
+
+// HandlerOption configures GraphQL API handler
+type HandlerOption func(*Handler)
+
+// WithMaxBodySize limits size in bytes of request bodies, larger requests are rejected with ErrRequestTooLarge
+func WithMaxBodySize(size int64) HandlerOption {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+// NewHandler creates new GraphQL API handler, panics if the schema does not match the resolvers.
+// Queries nested deeper than maxDepth are rejected.
+func NewHandler(svc Service, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		schema: graphql.MustParseSchema(schema, &resolver{svc: svc},
+			graphql.MaxDepth(maxDepth),
+			graphql.MaxParallelism(maxParallelism),
+		),
+		maxBodySize: DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP executes the query of the request.
+// Errors of the query are returned in the response body with 200 status code.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodySize)).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("[POST /graphql] error decoding request body")
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, entity.NewError(entity.ErrRequestTooLarge, entity.ErrMsgRequestTooLarge))
+			return
+		}
+
+		paramErr := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		paramErr.AddDetail(entity.NewErrorDetail("body", "request body is not a valid GraphQL request"))
+		writeError(w, http.StatusBadRequest, paramErr)
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		// status is already written, the response can only be logged
+		log.Error().Err(err).Msg("[POST /graphql] error encoding response")
+	}
+}
+
+// writeError writes err as response of request that could not be executed
+func writeError(w http.ResponseWriter, status int, err *entity.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(err); err != nil {
+		log.Error().Err(err).Msg("[POST /graphql] error encoding response")
+	}
+}
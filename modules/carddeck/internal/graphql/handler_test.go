@@ -0,0 +1,287 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/graphql"
+	mock_graphql "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	defaultTime = time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	defaultDeck = func() *entity.Deck {
+		deck := entity.NewDeck(true, &entity.Cards{
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+			{Val: "2", Suit: "SPADE", Code: "2S"},
+		})
+		deck.ID = "some-uuid-abc-def"
+		deck.Version = 3
+		deck.CreatedAt = defaultTime
+		deck.UpdatedAt = defaultTime
+
+		return deck
+	}()
+)
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type HandlerTestSuite struct {
+	suite.Suite
+	svc     *mock_graphql.MockService
+	handler *graphql.Handler
+}
+
+func (s *HandlerTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	s.svc = mock_graphql.NewMockService(ctrl)
+	s.handler = graphql.NewHandler(s.svc)
+}
+
+func TestHandler(t *testing.T) {
+	suite.Run(t, new(HandlerTestSuite))
+}
+
+// exec posts the GraphQL request and decodes the response
+func (s *HandlerTestSuite) exec(query string, variables map[string]interface{}) *response {
+	return s.execWithScopes(nil, query, variables)
+}
+
+// execWithScopes posts the GraphQL request made by bearer token having scopes, nil scopes are for API key
+func (s *HandlerTestSuite) execWithScopes(scopes entity.Scopes, query string, variables map[string]interface{}) *response {
+	body, err := json.Marshal(&graphql.Request{Query: query, Variables: variables})
+	assert.NoError(s.T(), err)
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	if scopes != nil {
+		r = r.WithContext(entity.WithScopes(r.Context(), scopes))
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+
+	var res response
+	assert.NoError(s.T(), json.NewDecoder(w.Body).Decode(&res))
+	return &res
+}
+
+func (s *HandlerTestSuite) TestDeck() {
+	s.Run("success - only selected fields are returned", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		res := s.exec(`query ($id: ID!) { deck(id: $id) { id remaining cards { code } } }`, map[string]interface{}{"id": "some-uuid-abc-def"})
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"deck": {"id": "some-uuid-abc-def", "remaining": 2, "cards": [{"code": "AS"}, {"code": "2S"}]}}`, string(res.Data))
+	})
+
+	s.Run("success - version above 32 bits", func() {
+		deck := *defaultDeck
+		deck.Version = 1 << 32
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(&deck, nil)
+
+		res := s.exec(`{ deck(id: "some-uuid-abc-def") { version } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"deck": {"version": 4294967296}}`, string(res.Data))
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		res := s.exec(`{ deck(id: "some-uuid-abc-def") { id } }`, nil)
+		assert.JSONEq(s.T(), `{"deck": null}`, string(res.Data))
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrMsgDeckNotFound, res.Errors[0].Message)
+		assert.Equal(s.T(), []interface{}{"deck"}, res.Errors[0].Path)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, res.Errors[0].Extensions["code"])
+	})
+
+	s.Run("failed - unexpected error is not exposed", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, errors.New("connection refused"))
+
+		res := s.exec(`{ deck(id: "some-uuid-abc-def") { id } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrMsgInternal, res.Errors[0].Message)
+		assert.Equal(s.T(), entity.ErrInternal, res.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *HandlerTestSuite) TestDecks() {
+	s.Run("success - filter", func() {
+		shuffled := true
+		s.svc.EXPECT().ListDecks(gomock.Any(), &entity.DeckFilter{IDs: []string{"some-uuid-abc-def"}, Shuffled: &shuffled, Limit: 5, Offset: 10}).Return([]*entity.Deck{defaultDeck}, nil)
+
+		res := s.exec(`{ decks(filter: {ids: ["some-uuid-abc-def"], shuffled: true, limit: 5, offset: 10}) { id shuffled version createdAt } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"decks": [{"id": "some-uuid-abc-def", "shuffled": true, "version": 3, "createdAt": "2022-01-01T01:00:00Z"}]}`, string(res.Data))
+	})
+
+	s.Run("success - no filter", func() {
+		s.svc.EXPECT().ListDecks(gomock.Any(), &entity.DeckFilter{}).Return([]*entity.Deck{}, nil)
+
+		res := s.exec(`{ decks { id } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"decks": []}`, string(res.Data))
+	})
+
+	s.Run("failed - error details are sent as extensions", func() {
+		perr := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		perr.AddDetail(entity.NewErrorDetail("limit", "limit must be between 1 and 100"))
+		s.svc.EXPECT().ListDecks(gomock.Any(), &entity.DeckFilter{Limit: 1000}).Return(nil, perr)
+
+		res := s.exec(`{ decks(filter: {limit: 1000}) { id } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrParamInvalid, res.Errors[0].Extensions["code"])
+		assert.Equal(s.T(), []interface{}{
+			map[string]interface{}{"field": "limit", "message": "limit must be between 1 and 100"},
+		}, res.Errors[0].Extensions["error_details"])
+	})
+}
+
+func (s *HandlerTestSuite) TestCreateDeck() {
+	s.Run("success", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), true, []string{"AS", "2S"}).Return(defaultDeck, nil)
+
+		res := s.exec(`mutation { createDeck(shuffled: true, cards: ["AS", "2S"]) { id remaining } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"createDeck": {"id": "some-uuid-abc-def", "remaining": 2}}`, string(res.Data))
+	})
+
+	s.Run("success - default arguments", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), false, nil).Return(defaultDeck, nil)
+
+		res := s.exec(`mutation { createDeck { id } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+	})
+
+	s.Run("failed - card code invalid", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), false, []string{"XX"}).Return(nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid))
+
+		res := s.exec(`mutation { createDeck(cards: ["XX"]) { id } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrCardCodeInvalid, res.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *HandlerTestSuite) TestDrawCards() {
+	s.Run("success", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(3)).Return(&entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, defaultDeck, nil)
+
+		res := s.exec(`mutation { drawCards(id: "some-uuid-abc-def", count: 1, expectedVersion: 3) { value suit code image } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"drawCards": [{"value": "ACE", "suit": "SPADE", "code": "AS", "image": "/cards/AS.svg"}]}`, string(res.Data))
+	})
+
+	s.Run("success - expected version above 32 bits", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(1<<32)).Return(&entity.Cards{}, defaultDeck, nil).Times(2)
+
+		res := s.exec(`mutation { drawCards(id: "some-uuid-abc-def", count: 1, expectedVersion: "4294967296") { code } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+
+		res = s.exec(`mutation ($version: Int64) { drawCards(id: "some-uuid-abc-def", count: 1, expectedVersion: $version) { code } }`,
+			map[string]interface{}{"version": 4294967296})
+		assert.Empty(s.T(), res.Errors)
+	})
+
+	s.Run("failed - expected version not an integer", func() {
+		res := s.exec(`mutation { drawCards(id: "some-uuid-abc-def", count: 1, expectedVersion: "three") { code } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+	})
+
+	s.Run("failed - version mismatch", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(0)).Return(nil, nil, entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch))
+
+		res := s.exec(`mutation { drawCards(id: "some-uuid-abc-def", count: 1) { code } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrDeckVersionMismatch, res.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *HandlerTestSuite) TestScopes() {
+	s.Run("success - query with read scope", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		res := s.execWithScopes(entity.Scopes{entity.ScopeDeckRead}, `{ deck(id: "some-uuid-abc-def") { id } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+		assert.JSONEq(s.T(), `{"deck": {"id": "some-uuid-abc-def"}}`, string(res.Data))
+	})
+
+	s.Run("success - mutation with draw scope", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(0)).Return(&entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, defaultDeck, nil)
+
+		res := s.execWithScopes(entity.Scopes{entity.ScopeDeckDraw}, `mutation { drawCards(id: "some-uuid-abc-def", count: 1) { code } }`, nil)
+		assert.Empty(s.T(), res.Errors)
+	})
+
+	s.Run("failed - query without read scope", func() {
+		res := s.execWithScopes(entity.Scopes{entity.ScopeDeckDraw}, `{ decks { id } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrScopeInsufficient, res.Errors[0].Extensions["code"])
+	})
+
+	s.Run("failed - mutation without draw scope", func() {
+		res := s.execWithScopes(entity.Scopes{entity.ScopeDeckRead}, `mutation { createDeck { id } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Equal(s.T(), entity.ErrScopeInsufficient, res.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *HandlerTestSuite) TestServeHTTP() {
+	s.Run("failed - request body invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("not json"))
+		w := httptest.NewRecorder()
+		s.handler.ServeHTTP(w, r)
+		assert.Equal(s.T(), http.StatusBadRequest, w.Code)
+		assert.Equal(s.T(), "application/json", w.Header().Get("Content-Type"))
+
+		var perr entity.Error
+		assert.NoError(s.T(), json.NewDecoder(w.Body).Decode(&perr))
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+
+	s.Run("failed - query invalid", func() {
+		res := s.exec(`{ deck(id: "some-uuid-abc-def") { unknown } }`, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Nil(s.T(), res.Data)
+	})
+
+	s.Run("failed - request body too large", func() {
+		body := `{"query": "{ deck(id: \"some-uuid-abc-def\") { id } }"}`
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		graphql.NewHandler(s.svc, graphql.WithMaxBodySize(int64(len(body)-1))).ServeHTTP(w, r)
+		assert.Equal(s.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+		var perr entity.Error
+		assert.NoError(s.T(), json.NewDecoder(w.Body).Decode(&perr))
+		assert.Equal(s.T(), entity.ErrRequestTooLarge, perr.Code)
+	})
+
+	s.Run("failed - query too deep", func() {
+		query := `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } } }`
+		res := s.exec(query, nil)
+		assert.Len(s.T(), res.Errors, 1)
+		assert.Nil(s.T(), res.Data)
+	})
+
+	s.Run("success - introspection query of GraphQL tools", func() {
+		query := `{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } }`
+		res := s.exec(query, nil)
+		assert.Empty(s.T(), res.Errors)
+	})
+}
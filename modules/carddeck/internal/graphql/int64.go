@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Int64 is custom GraphQL scalar of 64-bit integers such as deck versions, which do not fit GraphQL Int of 32 bits.
+// It is written as JSON number, and read from Int literals, JSON numbers of variables or decimal strings,
+// strings being the only way to pass values that do not fit 32 bits in a query literal.
+type Int64 int64
+
+// ImplementsGraphQLType maps Int64 to the Int64 scalar of the schema
+func (Int64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+// UnmarshalGraphQL reads Int64 from input of a query
+func (i *Int64) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case int32:
+		*i = Int64(input)
+	case int64:
+		*i = Int64(input)
+	case float64:
+		// variables are decoded as float64, which only holds integers up to 2^53 exactly
+		if input != math.Trunc(input) || math.Abs(input) > 1<<53 {
+			return fmt.Errorf("Int64 must be an integer, got %v", input)
+		}
+		*i = Int64(input)
+	case string:
+		n, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Int64 must be a decimal integer, got %q", input)
+		}
+		*i = Int64(n)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", input)
+	}
+	return nil
+}
+
+// MarshalJSON writes Int64 as JSON number
+func (i Int64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(int64(i))
+}
@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// Service defines interfaces for carddeck usecases served by GraphQL API
+type Service interface {
+	CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error)
+	GetDeck(ctx context.Context, id string) (*entity.Deck, error)
+	ListDecks(ctx context.Context, filter *entity.DeckFilter) ([]*entity.Deck, error)
+	DrawCards(ctx context.Context, id string, n int64, version int64) (*entity.Cards, *entity.Deck, error)
+}
+
+// resolver resolves root Query and Mutation fields
+type resolver struct {
+	svc Service
+}
+
+// authorize rejects bearer tokens without scope. A request may have both queries and mutations,
+// so queries require entity.ScopeDeckRead and mutations entity.ScopeDeckDraw, see middleware.RequireScope
+func authorize(ctx context.Context, scope string) error {
+	if scopes, ok := entity.ScopesFromContext(ctx); ok && !scopes.Allow(scope) {
+		return toError(entity.NewError(entity.ErrScopeInsufficient, entity.ErrMsgScopeInsufficient))
+	}
+	return nil
+}
+
+type deckFilterInput struct {
+	IDs      *[]graphql.ID
+	Shuffled *bool
+	Limit    *int32
+	Offset   *int32
+}
+
+func (r *resolver) Deck(ctx context.Context, args struct{ ID graphql.ID }) (*deckResolver, error) {
+	if err := authorize(ctx, entity.ScopeDeckRead); err != nil {
+		return nil, err
+	}
+
+	deck, err := r.svc.GetDeck(ctx, string(args.ID))
+	if err != nil {
+		log.Error().Err(err).Msg("[graphql Query.deck] error getting deck")
+		return nil, toError(err)
+	}
+
+	return &deckResolver{deck: deck}, nil
+}
+
+func (r *resolver) Decks(ctx context.Context, args struct{ Filter *deckFilterInput }) (*[]*deckResolver, error) {
+	if err := authorize(ctx, entity.ScopeDeckRead); err != nil {
+		return nil, err
+	}
+
+	filter := &entity.DeckFilter{}
+	if f := args.Filter; f != nil {
+		if f.IDs != nil {
+			for _, id := range *f.IDs {
+				filter.IDs = append(filter.IDs, string(id))
+			}
+		}
+		filter.Shuffled = f.Shuffled
+		if f.Limit != nil {
+			filter.Limit = int(*f.Limit)
+		}
+		if f.Offset != nil {
+			filter.Offset = int(*f.Offset)
+		}
+	}
+
+	decks, err := r.svc.ListDecks(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("[graphql Query.decks] error listing decks")
+		return nil, toError(err)
+	}
+
+	resolvers := make([]*deckResolver, len(decks))
+	for i, deck := range decks {
+		resolvers[i] = &deckResolver{deck: deck}
+	}
+	return &resolvers, nil
+}
+
+func (r *resolver) CreateDeck(ctx context.Context, args struct {
+	Shuffled bool
+	Cards    *[]string
+}) (*deckResolver, error) {
+	if err := authorize(ctx, entity.ScopeDeckDraw); err != nil {
+		return nil, err
+	}
+
+	var cardCodes []string
+	if args.Cards != nil {
+		cardCodes = *args.Cards
+	}
+
+	deck, err := r.svc.CreateDeck(ctx, args.Shuffled, cardCodes)
+	if err != nil {
+		log.Error().Err(err).Msg("[graphql Mutation.createDeck] error creating deck")
+		return nil, toError(err)
+	}
+
+	return &deckResolver{deck: deck}, nil
+}
+
+func (r *resolver) DrawCards(ctx context.Context, args struct {
+	ID              graphql.ID
+	Count           int32
+	ExpectedVersion Int64
+}) (*[]*cardResolver, error) {
+	if err := authorize(ctx, entity.ScopeDeckDraw); err != nil {
+		return nil, err
+	}
+
+	cards, _, err := r.svc.DrawCards(ctx, string(args.ID), int64(args.Count), int64(args.ExpectedVersion))
+	if err != nil {
+		log.Error().Err(err).Msg("[graphql Mutation.drawCards] error drawing cards")
+		return nil, toError(err)
+	}
+
+	resolvers := toCardResolvers(cards)
+	return &resolvers, nil
+}
+
+// deckResolver resolves Deck fields, cards are only copied when selected
+type deckResolver struct {
+	deck *entity.Deck
+}
+
+func (r *deckResolver) ID() graphql.ID { return graphql.ID(r.deck.ID) }
+
+func (r *deckResolver) Shuffled() bool { return r.deck.Shuffled }
+
+func (r *deckResolver) Remaining() int32 {
+	if r.deck.Cards == nil {
+		return 0
+	}
+	return int32(r.deck.Cards.Len())
+}
+
+func (r *deckResolver) Version() Int64 { return Int64(r.deck.Version) }
+
+func (r *deckResolver) Cards() []*cardResolver { return toCardResolvers(r.deck.Cards) }
+
+func (r *deckResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.deck.CreatedAt} }
+
+func (r *deckResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.deck.UpdatedAt} }
+
+// cardResolver resolves Card fields
+type cardResolver struct {
+	card *entity.Card
+}
+
+func (r *cardResolver) Value() string { return r.card.Val }
+
+func (r *cardResolver) Suit() string { return r.card.Suit }
+
+func (r *cardResolver) Code() string { return r.card.Code }
+
+func (r *cardResolver) Image() string { return r.card.ImagePath() }
+
+func toCardResolvers(cards *entity.Cards) []*cardResolver {
+	if cards == nil {
+		return []*cardResolver{}
+	}
+
+	resolvers := make([]*cardResolver, len(*cards))
+	for i, card := range *cards {
+		resolvers[i] = &cardResolver{card: card}
+	}
+	return resolvers
+}
@@ -0,0 +1,172 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// apiKeyMetadata is metadata key carrying API key, the same as X-API-Key header of REST API
+	apiKeyMetadata = "x-api-key"
+	// authorizationMetadata is metadata key carrying bearer token, the same as Authorization header of REST API
+	authorizationMetadata = "authorization"
+	bearerScheme          = "Bearer"
+)
+
+// methodScopes are scopes bearer tokens need to call each method, the same as the matching REST routes.
+// Methods not listed need entity.ScopeDeckAdmin.
+var methodScopes = map[string]string{
+	carddeckpb.CarddeckService_CreateDeck_FullMethodName: entity.ScopeDeckDraw,
+	carddeckpb.CarddeckService_GetDeck_FullMethodName:    entity.ScopeDeckRead,
+	carddeckpb.CarddeckService_DrawCards_FullMethodName:  entity.ScopeDeckDraw,
+	carddeckpb.CarddeckService_WatchDeck_FullMethodName:  entity.ScopeDeckRead,
+}
+
+// Authenticator authenticates API keys sent by clients
+type Authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error)
+}
+
+// TokenVerifier verifies bearer tokens sent by clients, implemented by middleware.TokenVerifier
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*middleware.TokenClaims, error)
+}
+
+// Auth authenticates calls by x-api-key or "authorization: Bearer" metadata and checks scopes of bearer tokens,
+// the gRPC counterpart of middleware.APIKey, middleware.Bearer and middleware.RequireScope
+type Auth struct {
+	auth     Authenticator
+	required bool
+	verifier TokenVerifier
+}
+
+// AuthOption configures optional behaviour of Auth
+type AuthOption func(*Auth)
+
+// WithTokenVerifier accepts bearer tokens verified by verifier.
+// Calls without API key nor bearer token are then rejected, the same as REST API once a key set is configured.
+func WithTokenVerifier(verifier TokenVerifier) AuthOption {
+	return func(a *Auth) {
+		a.verifier = verifier
+	}
+}
+
+// NewAuth creates authentication of calls.
+// Calls without API key are rejected when required is true, otherwise they only see decks created without API key.
+func NewAuth(auth Authenticator, required bool, opts ...AuthOption) *Auth {
+	a := &Auth{
+		auth:     auth,
+		required: required,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Unary implements grpc.UnaryServerInterceptor
+func (a *Auth) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream implements grpc.StreamServerInterceptor
+func (a *Auth) Stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate returns ctx carrying tenant of the API key or bearer token, see entity.WithTenant,
+// and scopes of the bearer token, which must allow calling method
+func (a *Auth) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if token, ok := a.bearerToken(ctx); ok {
+		return a.authenticateToken(ctx, token, method)
+	}
+
+	var key string
+	if values := metadata.ValueFromIncomingContext(ctx, apiKeyMetadata); len(values) > 0 {
+		key = values[0]
+	}
+
+	if key == "" {
+		if a.required {
+			return nil, toStatus(entity.NewError(entity.ErrAPIKeyRequired, entity.ErrMsgAPIKeyRequired))
+		}
+		if a.verifier != nil {
+			return nil, toStatus(entity.NewError(entity.ErrTokenRequired, entity.ErrMsgTokenRequired))
+		}
+		return ctx, nil
+	}
+
+	apiKey, err := a.auth.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		log.Error().Err(err).Msg("[grpc] error authenticating API key")
+		return nil, toStatus(err)
+	}
+
+	return entity.WithTenant(ctx, apiKey.TenantID), nil
+}
+
+// bearerToken returns bearer token of the call, only when bearer tokens are accepted
+func (a *Auth) bearerToken(ctx context.Context) (string, bool) {
+	if a.verifier == nil {
+		return "", false
+	}
+
+	values := metadata.ValueFromIncomingContext(ctx, authorizationMetadata)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, bearerScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateToken verifies the bearer token and checks its scopes allow calling method
+func (a *Auth) authenticateToken(ctx context.Context, token, method string) (context.Context, error) {
+	claims, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		if _, ok := err.(*entity.Error); !ok {
+			log.Error().Err(err).Msg("[grpc] error verifying bearer token")
+		}
+		return nil, toStatus(err)
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = entity.ScopeDeckAdmin
+	}
+	if !claims.Scopes().Allow(scope) {
+		return nil, toStatus(entity.NewError(entity.ErrScopeInsufficient, entity.ErrMsgScopeInsufficient))
+	}
+
+	ctx = entity.WithTenant(ctx, claims.Tenant)
+	return entity.WithScopes(ctx, claims.Scopes()), nil
+}
+
+// authenticatedStream overrides context of the stream with the authenticated one
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
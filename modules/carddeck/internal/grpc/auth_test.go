@@ -0,0 +1,183 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	carddeckgrpc "github.com/raymondwongso/carddeck/modules/carddeck/internal/grpc"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	mock_grpc "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	svc      *mock_grpc.MockService
+	auth     *mock_grpc.MockAuthenticator
+	verifier *mock_grpc.MockTokenVerifier
+	server   *grpc.Server
+	conn     *grpc.ClientConn
+	client   carddeckpb.CarddeckServiceClient
+}
+
+func (s *AuthTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	s.svc = mock_grpc.NewMockService(ctrl)
+	s.auth = mock_grpc.NewMockAuthenticator(ctrl)
+	s.verifier = mock_grpc.NewMockTokenVerifier(ctrl)
+
+	auth := carddeckgrpc.NewAuth(s.auth, true, carddeckgrpc.WithTokenVerifier(s.verifier))
+	listener := bufconn.Listen(1 << 20)
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(auth.Unary), grpc.StreamInterceptor(auth.Stream))
+	carddeckpb.RegisterCarddeckServiceServer(s.server, carddeckgrpc.NewServer(s.svc))
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(s.T(), err)
+	s.conn = conn
+	s.client = carddeckpb.NewCarddeckServiceClient(conn)
+}
+
+func (s *AuthTestSuite) TearDownSuite() {
+	s.conn.Close()
+	s.server.Stop()
+}
+
+func TestAuth(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
+
+func (s *AuthTestSuite) TestUnary() {
+	s.Run("success - tenant passed to service", func() {
+		s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(&entity.APIKey{ID: "api-key-1", TenantID: "tenant-1"}, nil)
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").DoAndReturn(func(ctx context.Context, _ string) (*entity.Deck, error) {
+			assert.Equal(s.T(), "tenant-1", entity.TenantFromContext(ctx))
+			return defaultDeck, nil
+		})
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cdk_valid")
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - API key required", func() {
+		_, err := s.client.GetDeck(context.Background(), &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("failed - API key invalid", func() {
+		s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_revoked").Return(nil, entity.NewError(entity.ErrAPIKeyInvalid, entity.ErrMsgAPIKeyInvalid))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cdk_revoked")
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.Unauthenticated, st.Code())
+		assert.Equal(s.T(), entity.ErrMsgAPIKeyInvalid, st.Message())
+	})
+}
+
+func (s *AuthTestSuite) TestStream() {
+	s.Run("success - tenant passed to service", func() {
+		events := make(chan *entity.DeckEvent)
+		close(events)
+		s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(&entity.APIKey{ID: "api-key-1", TenantID: "tenant-1"}, nil)
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").DoAndReturn(
+			func(ctx context.Context, _ string) (<-chan *entity.DeckEvent, func(), error) {
+				assert.Equal(s.T(), "tenant-1", entity.TenantFromContext(ctx))
+				return events, func() {}, nil
+			})
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cdk_valid")
+		stream, err := s.client.WatchDeck(ctx, &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.Unavailable, status.Code(err))
+	})
+
+	s.Run("failed - API key required", func() {
+		stream, err := s.client.WatchDeck(context.Background(), &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func (s *AuthTestSuite) TestBearerToken() {
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	s.Run("success - tenant and scopes passed to service", func() {
+		s.verifier.EXPECT().Verify(gomock.Any(), "valid-token").Return(&middleware.TokenClaims{Tenant: "tenant-1", Scope: "deck:read"}, nil)
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").DoAndReturn(func(ctx context.Context, _ string) (*entity.Deck, error) {
+			assert.Equal(s.T(), "tenant-1", entity.TenantFromContext(ctx))
+			scopes, ok := entity.ScopesFromContext(ctx)
+			assert.True(s.T(), ok)
+			assert.Equal(s.T(), entity.Scopes{entity.ScopeDeckRead}, scopes)
+			return defaultDeck, nil
+		})
+
+		_, err := s.client.GetDeck(withToken("valid-token"), &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - token invalid", func() {
+		s.verifier.EXPECT().Verify(gomock.Any(), "expired-token").Return(nil, entity.NewError(entity.ErrTokenInvalid, entity.ErrMsgTokenInvalid))
+
+		_, err := s.client.GetDeck(withToken("expired-token"), &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.Equal(s.T(), codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("failed - draw without draw scope", func() {
+		s.verifier.EXPECT().Verify(gomock.Any(), "read-token").Return(&middleware.TokenClaims{Tenant: "tenant-1", Scope: "deck:read"}, nil)
+
+		_, err := s.client.DrawCards(withToken("read-token"), &carddeckpb.DrawCardsRequest{Id: "some-uuid-abc-def", Count: 1})
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.PermissionDenied, st.Code())
+		assert.Equal(s.T(), entity.ErrMsgScopeInsufficient, st.Message())
+	})
+
+	s.Run("failed - watch without read scope", func() {
+		s.verifier.EXPECT().Verify(gomock.Any(), "draw-token").Return(&middleware.TokenClaims{Tenant: "tenant-1", Scope: "deck:draw"}, nil)
+
+		stream, err := s.client.WatchDeck(withToken("draw-token"), &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestAuthWithoutCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	auth := carddeckgrpc.NewAuth(mock_grpc.NewMockAuthenticator(ctrl), false, carddeckgrpc.WithTokenVerifier(mock_grpc.NewMockTokenVerifier(ctrl)))
+
+	_, err := auth.Unary(context.Background(), &carddeckpb.DrawCardsRequest{}, &grpc.UnaryServerInfo{FullMethod: carddeckpb.CarddeckService_DrawCards_FullMethodName},
+		func(ctx context.Context, req any) (any, error) {
+			t.Error("handler called without credentials")
+			return nil, nil
+		})
+
+	// calls without credentials are rejected once bearer tokens are accepted, even when API key is not required
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	assert.Equal(t, entity.ErrMsgTokenRequired, st.Message())
+}
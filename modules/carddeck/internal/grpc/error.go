@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain is domain of google.rpc.ErrorInfo attached to every error
+const errorDomain = "carddeck"
+
+// toStatus converts error into gRPC status.
+// entity.Error code is sent as ErrorInfo reason and its details as BadRequest field violations.
+func toStatus(err error) error {
+	perr, ok := err.(*entity.Error)
+	if !ok {
+		// error is not in custom error, assume unknown error
+		perr = entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	st := status.New(statusCode(perr.Code), perr.Message)
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: perr.Code, Domain: errorDomain}}
+	if len(perr.Details) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(perr.Details))
+		for i, detail := range perr.Details {
+			violations[i] = &errdetails.BadRequest_FieldViolation{
+				Field:       detail.Field,
+				Description: detail.Message,
+			}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// statusCode maps entity.Error code into gRPC status code
+func statusCode(code string) codes.Code {
+	switch code {
+	case entity.ErrParamInvalid, entity.ErrCardCodeInvalid, entity.ErrDeckCompactInvalid, entity.ErrDeckImportInvalid:
+		return codes.InvalidArgument
+	case entity.ErrCardNotFound, entity.ErrDeckNotFound, entity.ErrDeckEventNotFound, entity.ErrWebhookNotFound, entity.ErrAPIKeyNotFound, entity.ErrTenantNotFound:
+		return codes.NotFound
+	case entity.ErrAPIKeyRequired, entity.ErrAPIKeyInvalid, entity.ErrTokenInvalid, entity.ErrTokenRequired:
+		return codes.Unauthenticated
+	case entity.ErrScopeInsufficient:
+		return codes.PermissionDenied
+	case entity.ErrRequestQuotaExceeded, entity.ErrRateLimited, entity.ErrTenantDeckQuotaExceeded:
+		return codes.ResourceExhausted
+	case entity.ErrDeckCardInsufficient:
+		return codes.FailedPrecondition
+	case entity.ErrDeckVersionMismatch:
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// retryAfterMetadata is metadata key telling when the tenant can call again, the same as Retry-After header of REST API
+const retryAfterMetadata = "retry-after"
+
+// QuotaCounter counts calls of tenants against MaxRequestsPerMinute of their quota
+type QuotaCounter interface {
+	// Take counts call of the tenant, returns how long until the tenant can call again when the call exceeds its quota
+	Take(ctx context.Context, tenantID string) (time.Duration, error)
+}
+
+// RequestQuota rejects calls of tenants exceeding their quota, the gRPC counterpart of middleware.RequestQuota.
+// Calls without tenant are passed as is, so it must run after Auth.
+type RequestQuota struct {
+	counter QuotaCounter
+}
+
+// NewRequestQuota creates request quota of calls counted by counter
+func NewRequestQuota(counter QuotaCounter) *RequestQuota {
+	return &RequestQuota{
+		counter: counter,
+	}
+}
+
+// Unary implements grpc.UnaryServerInterceptor
+func (q *RequestQuota) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := q.take(ctx, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream implements grpc.StreamServerInterceptor
+func (q *RequestQuota) Stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := q.take(ss.Context(), ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// take counts the call, sending retry-after header with setHeader when the call exceeds the quota
+func (q *RequestQuota) take(ctx context.Context, setHeader func(metadata.MD) error) error {
+	tenantID := entity.TenantFromContext(ctx)
+	if tenantID == "" {
+		return nil
+	}
+
+	retryAfter, err := q.counter.Take(ctx, tenantID)
+	if err != nil {
+		log.Error().Err(err).Msg("[grpc] error loading tenant quota")
+		return toStatus(entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+	}
+
+	if retryAfter > 0 {
+		if err := setHeader(metadata.Pairs(retryAfterMetadata, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))); err != nil {
+			log.Error().Err(err).Msg("[grpc] error sending retry-after header")
+		}
+		return toStatus(entity.NewError(entity.ErrRequestQuotaExceeded, entity.ErrMsgRequestQuotaExceeded))
+	}
+	return nil
+}
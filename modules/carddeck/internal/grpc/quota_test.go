@@ -0,0 +1,129 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	carddeckgrpc "github.com/raymondwongso/carddeck/modules/carddeck/internal/grpc"
+	mock_grpc "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type RequestQuotaTestSuite struct {
+	suite.Suite
+	svc     *mock_grpc.MockService
+	auth    *mock_grpc.MockAuthenticator
+	counter *mock_grpc.MockQuotaCounter
+	server  *grpc.Server
+	conn    *grpc.ClientConn
+	client  carddeckpb.CarddeckServiceClient
+}
+
+func (s *RequestQuotaTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	s.svc = mock_grpc.NewMockService(ctrl)
+	s.auth = mock_grpc.NewMockAuthenticator(ctrl)
+	s.counter = mock_grpc.NewMockQuotaCounter(ctrl)
+
+	auth := carddeckgrpc.NewAuth(s.auth, false)
+	quota := carddeckgrpc.NewRequestQuota(s.counter)
+	listener := bufconn.Listen(1 << 20)
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.Unary, quota.Unary),
+		grpc.ChainStreamInterceptor(auth.Stream, quota.Stream),
+	)
+	carddeckpb.RegisterCarddeckServiceServer(s.server, carddeckgrpc.NewServer(s.svc))
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(s.T(), err)
+	s.conn = conn
+	s.client = carddeckpb.NewCarddeckServiceClient(conn)
+}
+
+func (s *RequestQuotaTestSuite) TearDownSuite() {
+	s.conn.Close()
+	s.server.Stop()
+}
+
+func TestRequestQuota(t *testing.T) {
+	suite.Run(t, new(RequestQuotaTestSuite))
+}
+
+// withAPIKey returns ctx of calls made by API key of tenant-1
+func (s *RequestQuotaTestSuite) withAPIKey() context.Context {
+	s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(&entity.APIKey{ID: "api-key-1", TenantID: "tenant-1"}, nil)
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "cdk_valid")
+}
+
+func (s *RequestQuotaTestSuite) TestUnary() {
+	s.Run("success - call within quota", func() {
+		ctx := s.withAPIKey()
+		s.counter.EXPECT().Take(gomock.Any(), "tenant-1").Return(time.Duration(0), nil)
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("success - call without tenant is not counted", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		_, err := s.client.GetDeck(context.Background(), &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - quota exceeded", func() {
+		ctx := s.withAPIKey()
+		s.counter.EXPECT().Take(gomock.Any(), "tenant-1").Return(1500*time.Millisecond, nil)
+
+		var header metadata.MD
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"}, grpc.Header(&header))
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.ResourceExhausted, st.Code())
+		assert.Equal(s.T(), entity.ErrMsgRequestQuotaExceeded, st.Message())
+		assert.Equal(s.T(), []string{"2"}, header.Get("retry-after"))
+	})
+
+	s.Run("failed - error loading quota", func() {
+		ctx := s.withAPIKey()
+		s.counter.EXPECT().Take(gomock.Any(), "tenant-1").Return(time.Duration(0), errors.New("connection refused"))
+
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.Equal(s.T(), codes.Internal, status.Code(err))
+	})
+}
+
+func (s *RequestQuotaTestSuite) TestStream() {
+	s.Run("failed - quota exceeded", func() {
+		ctx := s.withAPIKey()
+		s.counter.EXPECT().Take(gomock.Any(), "tenant-1").Return(time.Second, nil)
+
+		stream, err := s.client.WatchDeck(ctx, &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.ResourceExhausted, status.Code(err))
+		header, err := stream.Header()
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{"1"}, header.Get("retry-after"))
+	})
+}
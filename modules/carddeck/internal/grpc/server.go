@@ -0,0 +1,147 @@
+// Package grpc contains implementation for gRPC API of carddeck module, see carddeckpb for the service definition
+package grpc
+
+import (
+	"context"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Service defines interfaces for carddeck usecases served by gRPC
+type Service interface {
+	CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error)
+	GetDeck(ctx context.Context, id string) (*entity.Deck, error)
+	DrawCards(ctx context.Context, id string, n int64, version int64) (*entity.Cards, *entity.Deck, error)
+	SubscribeDeck(ctx context.Context, id string) (<-chan *entity.DeckEvent, func(), error)
+}
+
+// Server implements carddeckpb.CarddeckServiceServer
+type Server struct {
+	carddeckpb.UnimplementedCarddeckServiceServer
+	svc Service
+}
+
+// NewServer creates new gRPC server implementation
+func NewServer(svc Service) *Server {
+	return &Server{
+		svc: svc,
+	}
+}
+
+// CreateDeck creates new deck
+func (s *Server) CreateDeck(ctx context.Context, req *carddeckpb.CreateDeckRequest) (*carddeckpb.CreateDeckResponse, error) {
+	var cardCodes []string
+	if len(req.GetCards()) > 0 {
+		cardCodes = req.GetCards()
+	}
+
+	deck, err := s.svc.CreateDeck(ctx, req.GetShuffled(), cardCodes)
+	if err != nil {
+		log.Error().Err(err).Msg("[CarddeckService/CreateDeck] error creating deck")
+		return nil, toStatus(err)
+	}
+
+	return &carddeckpb.CreateDeckResponse{
+		Id:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+		Version:   deck.Version,
+	}, nil
+}
+
+// GetDeck returns deck with its remaining cards
+func (s *Server) GetDeck(ctx context.Context, req *carddeckpb.GetDeckRequest) (*carddeckpb.GetDeckResponse, error) {
+	deck, err := s.svc.GetDeck(ctx, req.GetId())
+	if err != nil {
+		log.Error().Err(err).Msg("[CarddeckService/GetDeck] error getting deck")
+		return nil, toStatus(err)
+	}
+
+	return &carddeckpb.GetDeckResponse{
+		Deck: &carddeckpb.Deck{
+			Id:        deck.ID,
+			Shuffled:  deck.Shuffled,
+			Remaining: int64(deck.Remaining()),
+			Cards:     toCards(deck.Cards),
+			Version:   deck.Version,
+			CreatedAt: timestamppb.New(deck.CreatedAt),
+			UpdatedAt: timestamppb.New(deck.UpdatedAt),
+		},
+	}, nil
+}
+
+// DrawCards draws cards from the top of the deck
+func (s *Server) DrawCards(ctx context.Context, req *carddeckpb.DrawCardsRequest) (*carddeckpb.DrawCardsResponse, error) {
+	cards, _, err := s.svc.DrawCards(ctx, req.GetId(), req.GetCount(), req.GetExpectedVersion())
+	if err != nil {
+		log.Error().Err(err).Msg("[CarddeckService/DrawCards] error drawing cards")
+		return nil, toStatus(err)
+	}
+
+	return &carddeckpb.DrawCardsResponse{
+		Cards: toCards(cards),
+	}, nil
+}
+
+// WatchDeck streams events of the deck until the client cancels or the subscription ends
+func (s *Server) WatchDeck(req *carddeckpb.WatchDeckRequest, stream carddeckpb.CarddeckService_WatchDeckServer) error {
+	ctx := stream.Context()
+
+	events, unsubscribe, err := s.svc.SubscribeDeck(ctx, req.GetId())
+	if err != nil {
+		log.Error().Err(err).Msg("[CarddeckService/WatchDeck] error subscribing deck")
+		return toStatus(err)
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				// hub is closed or the client is too slow
+				return status.Error(codes.Unavailable, "subscription closed, get the deck and watch it again")
+			}
+
+			if err := stream.Send(toDeckEvent(ev)); err != nil {
+				log.Error().Err(err).Msg("[CarddeckService/WatchDeck] error sending event")
+				return err
+			}
+		}
+	}
+}
+
+func toCards(cards *entity.Cards) []*carddeckpb.Card {
+	if cards == nil {
+		return nil
+	}
+
+	res := make([]*carddeckpb.Card, len(*cards))
+	for i, card := range *cards {
+		res[i] = &carddeckpb.Card{
+			Value: card.Val,
+			Suit:  card.Suit,
+			Code:  card.Code,
+		}
+	}
+	return res
+}
+
+func toDeckEvent(ev *entity.DeckEvent) *carddeckpb.DeckEvent {
+	return &carddeckpb.DeckEvent{
+		Id:         ev.ID,
+		Type:       ev.Type,
+		DeckId:     ev.DeckID,
+		Version:    ev.Version,
+		Shuffled:   ev.Shuffled,
+		Remaining:  int64(ev.Remaining),
+		Cards:      toCards(ev.Cards),
+		OccurredAt: timestamppb.New(ev.OccurredAt),
+	}
+}
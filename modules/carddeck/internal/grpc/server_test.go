@@ -0,0 +1,264 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	carddeckgrpc "github.com/raymondwongso/carddeck/modules/carddeck/internal/grpc"
+	mock_grpc "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var (
+	defaultTime = time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	defaultDeck = func() *entity.Deck {
+		deck := entity.NewDeck(true, &entity.Cards{
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+			{Val: "2", Suit: "SPADE", Code: "2S"},
+		})
+		deck.ID = "some-uuid-abc-def"
+		deck.Version = 3
+		deck.CreatedAt = time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+
+		return deck
+	}()
+)
+
+type ServerTestSuite struct {
+	suite.Suite
+	svc    *mock_grpc.MockService
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	client carddeckpb.CarddeckServiceClient
+}
+
+func (s *ServerTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	s.svc = mock_grpc.NewMockService(ctrl)
+
+	listener := bufconn.Listen(1 << 20)
+	s.server = grpc.NewServer()
+	carddeckpb.RegisterCarddeckServiceServer(s.server, carddeckgrpc.NewServer(s.svc))
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(s.T(), err)
+	s.conn = conn
+	s.client = carddeckpb.NewCarddeckServiceClient(conn)
+}
+
+func (s *ServerTestSuite) TearDownSuite() {
+	s.conn.Close()
+	s.server.Stop()
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func (s *ServerTestSuite) TestCreateDeck() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), true, []string{"AS", "2S"}).Return(defaultDeck, nil)
+
+		resp, err := s.client.CreateDeck(ctx, &carddeckpb.CreateDeckRequest{Shuffled: true, Cards: []string{"AS", "2S"}})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "some-uuid-abc-def", resp.GetId())
+		assert.Equal(s.T(), true, resp.GetShuffled())
+		assert.Equal(s.T(), int64(2), resp.GetRemaining())
+		assert.Equal(s.T(), int64(3), resp.GetVersion())
+	})
+
+	s.Run("success - no cards supplied", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), false, nil).Return(defaultDeck, nil)
+
+		_, err := s.client.CreateDeck(ctx, &carddeckpb.CreateDeckRequest{})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - card code invalid", func() {
+		s.svc.EXPECT().CreateDeck(gomock.Any(), false, []string{"XX"}).Return(nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid))
+
+		_, err := s.client.CreateDeck(ctx, &carddeckpb.CreateDeckRequest{Cards: []string{"XX"}})
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+		assert.Equal(s.T(), entity.ErrMsgCardCodeInvalid, st.Message())
+
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrCardCodeInvalid, info.GetReason())
+	})
+}
+
+func (s *ServerTestSuite) TestGetDeck() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(defaultDeck, nil)
+
+		resp, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "some-uuid-abc-def", resp.GetDeck().GetId())
+		assert.Equal(s.T(), int64(2), resp.GetDeck().GetRemaining())
+		assert.Equal(s.T(), "AS", resp.GetDeck().GetCards()[0].GetCode())
+		assert.Equal(s.T(), "SPADE", resp.GetDeck().GetCards()[0].GetSuit())
+		assert.Equal(s.T(), "ACE", resp.GetDeck().GetCards()[0].GetValue())
+		assert.Equal(s.T(), defaultDeck.CreatedAt, resp.GetDeck().GetCreatedAt().AsTime())
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		assert.Equal(s.T(), codes.NotFound, status.Code(err))
+	})
+
+	s.Run("failed - unexpected error is not exposed", func() {
+		s.svc.EXPECT().GetDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, errors.New("connection refused"))
+
+		_, err := s.client.GetDeck(ctx, &carddeckpb.GetDeckRequest{Id: "some-uuid-abc-def"})
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.Internal, st.Code())
+		assert.Equal(s.T(), entity.ErrMsgInternal, st.Message())
+	})
+}
+
+func (s *ServerTestSuite) TestDrawCards() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(1), int64(3)).Return(&entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, defaultDeck, nil)
+
+		resp, err := s.client.DrawCards(ctx, &carddeckpb.DrawCardsRequest{Id: "some-uuid-abc-def", Count: 1, ExpectedVersion: 3})
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), resp.GetCards(), 1)
+		assert.Equal(s.T(), "AS", resp.GetCards()[0].GetCode())
+	})
+
+	s.Run("failed - error details are sent as field violations", func() {
+		perr := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		perr.AddDetail(entity.NewErrorDetail("count", "count must be bigger than 0"))
+		s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(0), int64(0)).Return(nil, nil, perr)
+
+		_, err := s.client.DrawCards(ctx, &carddeckpb.DrawCardsRequest{Id: "some-uuid-abc-def"})
+		st := status.Convert(err)
+		assert.Equal(s.T(), codes.InvalidArgument, st.Code())
+
+		details := st.Details()
+		assert.Len(s.T(), details, 2)
+		badRequest, ok := details[1].(*errdetails.BadRequest)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), "count", badRequest.GetFieldViolations()[0].GetField())
+		assert.Equal(s.T(), "count must be bigger than 0", badRequest.GetFieldViolations()[0].GetDescription())
+	})
+
+	for name, tc := range map[string]struct {
+		err  *entity.Error
+		code codes.Code
+	}{
+		"failed - card insufficient": {entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient), codes.FailedPrecondition},
+		"failed - version mismatch":  {entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch), codes.Aborted},
+	} {
+		s.Run(name, func() {
+			s.svc.EXPECT().DrawCards(gomock.Any(), "some-uuid-abc-def", int64(60), int64(0)).Return(nil, nil, tc.err)
+
+			_, err := s.client.DrawCards(ctx, &carddeckpb.DrawCardsRequest{Id: "some-uuid-abc-def", Count: 60})
+			assert.Equal(s.T(), tc.code, status.Code(err))
+		})
+	}
+}
+
+func (s *ServerTestSuite) TestWatchDeck() {
+	s.Run("success - events are streamed until the subscription closes", func() {
+		events := make(chan *entity.DeckEvent, 2)
+		events <- &entity.DeckEvent{
+			ID:         7,
+			Type:       entity.DeckEventDrawn,
+			DeckID:     "some-uuid-abc-def",
+			Version:    4,
+			Remaining:  1,
+			Cards:      &entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}},
+			OccurredAt: defaultTime,
+		}
+		events <- &entity.DeckEvent{ID: 8, Type: entity.DeckEventShuffled, DeckID: "some-uuid-abc-def", Version: 5, Shuffled: true, Remaining: 1}
+		close(events)
+
+		unsubscribed := make(chan struct{})
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").Return((<-chan *entity.DeckEvent)(events), func() { close(unsubscribed) }, nil)
+
+		stream, err := s.client.WatchDeck(context.Background(), &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		ev, err := stream.Recv()
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(7), ev.GetId())
+		assert.Equal(s.T(), entity.DeckEventDrawn, ev.GetType())
+		assert.Equal(s.T(), "AS", ev.GetCards()[0].GetCode())
+		assert.Equal(s.T(), defaultTime, ev.GetOccurredAt().AsTime())
+
+		ev, err = stream.Recv()
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), entity.DeckEventShuffled, ev.GetType())
+		assert.Empty(s.T(), ev.GetCards())
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.Unavailable, status.Code(err))
+		<-unsubscribed
+	})
+
+	s.Run("success - client cancels", func() {
+		events := make(chan *entity.DeckEvent)
+		subscribed := make(chan struct{})
+		unsubscribed := make(chan struct{})
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").DoAndReturn(
+			func(context.Context, string) (<-chan *entity.DeckEvent, func(), error) {
+				close(subscribed)
+				return events, func() { close(unsubscribed) }, nil
+			})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := s.client.WatchDeck(ctx, &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		<-subscribed
+		cancel()
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.Canceled, status.Code(err))
+
+		select {
+		case <-unsubscribed:
+		case <-time.After(5 * time.Second):
+			s.T().Fatal("subscription is not stopped")
+		}
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), "some-uuid-abc-def").Return(nil, nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		stream, err := s.client.WatchDeck(context.Background(), &carddeckpb.WatchDeckRequest{Id: "some-uuid-abc-def"})
+		assert.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		assert.Equal(s.T(), codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,148 @@
+// Package i18n translates error messages and card names of carddeck module using message catalogs of supported languages
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// English is language of the messages defined by entity, used when no supported language is accepted
+var English = language.English
+
+var (
+	// supported lists languages having a catalog, English first as the fallback
+	supported = []language.Tag{English, language.Indonesian, language.Japanese, language.Spanish}
+	matcher   = language.NewMatcher(supported)
+	catalogs  = mustLoadCatalogs()
+
+	indexPattern = regexp.MustCompile(`\[\d+\]`)
+)
+
+// catalog defines translations of a language.
+// Errors are keyed by entity.Error code, details by entity.Error code then entity.ErrorDetail field.
+// Indexes of fields are written as "[]", e.g. "cards[]".
+type catalog struct {
+	Errors  map[string]string            `json:"errors"`
+	Details map[string]map[string]string `json:"details"`
+	Cards   cardCatalog                  `json:"cards"`
+}
+
+// cardCatalog defines translations of card names.
+// Name is format of the name receiving the value as %[1]s and the suit as %[2]s, values not listed are kept as is.
+type cardCatalog struct {
+	Name   string            `json:"name"`
+	Values map[string]string `json:"values"`
+	Suits  map[string]string `json:"suits"`
+}
+
+func mustLoadCatalogs() map[language.Tag]*catalog {
+	catalogs := make(map[language.Tag]*catalog, len(supported)-1)
+	for _, tag := range supported[1:] {
+		raw, err := locales.ReadFile(fmt.Sprintf("locales/%s.json", tag))
+		if err != nil {
+			panic(err)
+		}
+
+		var c catalog
+		if err := json.Unmarshal(raw, &c); err != nil {
+			panic(fmt.Errorf("parsing %s catalog: %w", tag, err))
+		}
+		catalogs[tag] = &c
+	}
+
+	return catalogs
+}
+
+// Languages returns supported languages, English first
+func Languages() []language.Tag {
+	return append([]language.Tag(nil), supported...)
+}
+
+// Localizer translates messages into a single language, falling back to English for missing translations
+type Localizer struct {
+	tag     language.Tag
+	catalog *catalog
+}
+
+// NewLocalizer creates localizer of the best supported language of Accept-Language header value.
+// Regional variants fall back to their base language, e.g. es-MX uses Spanish, and unsupported ones to English.
+func NewLocalizer(acceptLanguage string) *Localizer {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return &Localizer{tag: English}
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return &Localizer{tag: English}
+	}
+
+	tag := supported[index]
+	return &Localizer{tag: tag, catalog: catalogs[tag]}
+}
+
+// Language returns language of the localized messages
+func (l *Localizer) Language() language.Tag {
+	return l.tag
+}
+
+// Message returns translation of error code, or fallback when it is not translated
+func (l *Localizer) Message(code, fallback string) string {
+	if l.catalog == nil {
+		return fallback
+	}
+	if msg, ok := l.catalog.Errors[code]; ok {
+		return msg
+	}
+	return fallback
+}
+
+// Error returns translated copy of err, leaving untranslated messages in English
+func (l *Localizer) Error(err *entity.Error) *entity.Error {
+	if l.catalog == nil {
+		return err
+	}
+
+	localized := entity.NewError(err.Code, l.Message(err.Code, err.Message))
+	for _, detail := range err.Details {
+		localized.AddDetail(entity.NewErrorDetail(detail.Field, l.detail(err.Code, detail)))
+	}
+	return localized
+}
+
+// CardName returns name of the card, e.g. "Reina de corazones" in Spanish.
+// Falls back to English name for card outside standard French playing cards.
+func (l *Localizer) CardName(card entity.Card) string {
+	if l.catalog == nil {
+		return card.Name()
+	}
+
+	suit, ok := l.catalog.Cards.Suits[card.Suit]
+	if !ok {
+		return card.Name()
+	}
+	value, ok := l.catalog.Cards.Values[card.Val]
+	if !ok {
+		value = card.Val
+	}
+	return fmt.Sprintf(l.catalog.Cards.Name, value, suit)
+}
+
+func (l *Localizer) detail(code string, detail *entity.ErrorDetail) string {
+	details := l.catalog.Details[code]
+
+	for _, key := range []string{detail.Field, indexPattern.ReplaceAllString(detail.Field, "[]")} {
+		if msg, ok := details[key]; ok {
+			return msg
+		}
+	}
+	return detail.Message
+}
@@ -0,0 +1,175 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+// errorCodes returns every error code declared in entity package, so new codes cannot be added without translation
+func errorCodes(t *testing.T) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join("..", "..", "entity", "error.go"), nil, 0)
+	require.NoError(t, err)
+
+	var codes []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Err") || strings.HasPrefix(name.Name, "ErrMsg") {
+				continue
+			}
+			lit, ok := spec.Values[i].(*ast.BasicLit)
+			require.True(t, ok, "%s must be a string literal", name.Name)
+			code, err := strconv.Unquote(lit.Value)
+			require.NoError(t, err)
+			codes = append(codes, code)
+		}
+		return true
+	})
+	require.NotEmpty(t, codes)
+
+	return codes
+}
+
+func Test_Catalog_Complete(t *testing.T) {
+	codes := errorCodes(t)
+
+	details := map[language.Tag]map[string]map[string]string{}
+	for _, tag := range i18n.Languages()[1:] {
+		l := i18n.NewLocalizer(tag.String())
+		require.Equal(t, tag, l.Language())
+
+		for _, code := range codes {
+			assert.NotEmpty(t, l.Message(code, ""), "%s lacks translation of %s", tag, code)
+		}
+
+		// every standard card has its own name
+		names := map[string]bool{}
+		for _, card := range entity.StandardCardSet.Cards {
+			name := l.CardName(card)
+			assert.NotEqual(t, card.Name(), name, "%s lacks translation of %s", tag, card.Code)
+			names[name] = true
+		}
+		assert.Len(t, names, 52, "%s card names are not unique", tag)
+
+		raw, err := os.ReadFile(filepath.Join("locales", tag.String()+".json"))
+		require.NoError(t, err)
+		var c struct {
+			Details map[string]map[string]string `json:"details"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &c))
+		details[tag] = c.Details
+	}
+
+	// every language translates the same error details
+	for tag, byCode := range details {
+		for other, otherByCode := range details {
+			for code, fields := range byCode {
+				for field := range fields {
+					assert.Contains(t, otherByCode[code], field, "%s lacks translation of %s %s, translated by %s", other, code, field, tag)
+				}
+			}
+		}
+	}
+}
+
+func Test_NewLocalizer(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		expected       language.Tag
+	}{
+		{"", i18n.English},
+		{"id", language.Indonesian},
+		{"id-ID,id;q=0.9,en;q=0.8", language.Indonesian},
+		{"ja-JP", language.Japanese},
+		{"es-MX", language.Spanish},
+		{"fr, ja;q=0.5", language.Japanese},
+		{"en-US, es;q=0.5", i18n.English},
+		{"fr", i18n.English},
+		{"not a language;;", i18n.English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.expected, i18n.NewLocalizer(tt.acceptLanguage).Language())
+		})
+	}
+}
+
+func Test_Localizer_Error(t *testing.T) {
+	t.Run("success - message and details are translated", func(t *testing.T) {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		err.AddDetail(entity.NewErrorDetail("operations[3].op", "unknown operation"))
+
+		localized := i18n.NewLocalizer("es").Error(err)
+
+		assert.Equal(t, &entity.Error{
+			Code:    entity.ErrParamInvalid,
+			Message: "parámetro no válido",
+			Details: []*entity.ErrorDetail{
+				{Field: "id", Message: "el ID está vacío"},
+				{Field: "operations[3].op", Message: "operación desconocida"},
+			},
+		}, localized)
+		// original error is untouched
+		assert.Equal(t, entity.ErrMsgParamInvalid, err.Message)
+	})
+
+	t.Run("success - untranslated detail falls back to English", func(t *testing.T) {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("unknown_field", "unknown field is invalid"))
+
+		localized := i18n.NewLocalizer("ja").Error(err)
+
+		assert.Equal(t, "パラメータが不正です", localized.Message)
+		assert.Equal(t, "unknown field is invalid", localized.Details[0].Message)
+	})
+
+	t.Run("success - English is returned as is", func(t *testing.T) {
+		err := entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound)
+
+		assert.Same(t, err, i18n.NewLocalizer("en").Error(err))
+	})
+}
+
+func Test_Localizer_CardName(t *testing.T) {
+	queenOfHearts := entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"}
+	tenOfSpades := entity.Card{Val: "10", Suit: "SPADE", Code: "10S"}
+	joker := entity.Card{Val: "JOKER", Suit: "", Code: "X"}
+
+	tests := []struct {
+		acceptLanguage string
+		card           entity.Card
+		expected       string
+	}{
+		{"en", queenOfHearts, "Queen of Hearts"},
+		{"es", queenOfHearts, "Reina de corazones"},
+		{"ja", queenOfHearts, "ハートのクイーン"},
+		{"id", queenOfHearts, "Ratu Hati"},
+		{"es", tenOfSpades, "10 de picas"},
+		{"es", joker, joker.Name()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage+" "+tt.card.Code, func(t *testing.T) {
+			assert.Equal(t, tt.expected, i18n.NewLocalizer(tt.acceptLanguage).CardName(tt.card))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// APIKey defines repository for API keys
+type APIKey struct {
+	db *sqlx.DB
+}
+
+// NewAPIKey returns new API key repository
+func NewAPIKey(db *sqlx.DB) *APIKey {
+	return &APIKey{db: db}
+}
+
+// Insert persists hash of the API key and assigns its ID
+func (a *APIKey) Insert(ctx context.Context, apiKey *entity.APIKey) (*entity.APIKey, error) {
+	query := `INSERT INTO public.api_keys (tenant_id, name, key_hash) VALUES ($1, $2, $3) RETURNING id, tenant_id, name, key_hash, created_at, revoked_at`
+
+	row := conn(ctx, a.db).QueryRowxContext(ctx, query, apiKey.TenantID, apiKey.Name, apiKey.Hash)
+	if err := row.Scan(&apiKey.ID, &apiKey.TenantID, &apiKey.Name, &apiKey.Hash, &apiKey.CreatedAt, &apiKey.RevokedAt); err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// GetByHash returns API key by hash of the key, including revoked key
+func (a *APIKey) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	query := `SELECT id, tenant_id, name, key_hash, created_at, revoked_at FROM public.api_keys WHERE key_hash = $1`
+
+	var apiKey entity.APIKey
+	if err := conn(ctx, a.db).QueryRowxContext(ctx, query, hash).StructScan(&apiKey); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.NewError(entity.ErrAPIKeyNotFound, entity.ErrMsgAPIKeyNotFound)
+		}
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+// Revoke revokes the API key, revoking already revoked key keeps its revocation time
+func (a *APIKey) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE public.api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`
+
+	res, err := conn(ctx, a.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.NewError(entity.ErrAPIKeyNotFound, entity.ErrMsgAPIKeyNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyTestSuite struct {
+	suite.Suite
+	dbmock sqlmock.Sqlmock
+	dbx    *sqlx.DB
+}
+
+func (s *APIKeyTestSuite) SetupSuite() {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(s.T(), err)
+	s.dbmock = dbmock
+	s.dbx = sqlx.NewDb(db, "sqlmock")
+}
+
+func TestAPIKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyTestSuite))
+}
+
+func (s *APIKeyTestSuite) TestInsert() {
+	repo := postgres.NewAPIKey(s.dbx)
+	query := `INSERT INTO public.api_keys (tenant_id, name, key_hash) VALUES ($1, $2, $3) RETURNING id, tenant_id, name, key_hash, created_at, revoked_at`
+	cols := []string{"id", "tenant_id", "name", "key_hash", "created_at", "revoked_at"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow("key-1", "tenant-1", "studio", "hash", timeTemp, nil)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("tenant-1", "studio", "hash").WillReturnRows(rows)
+
+		apiKey, err := repo.Insert(context.Background(), &entity.APIKey{TenantID: "tenant-1", Name: "studio", Hash: "hash", Key: "cdk_secret"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.APIKey{ID: "key-1", TenantID: "tenant-1", Name: "studio", Hash: "hash", CreatedAt: timeTemp, Key: "cdk_secret"}, apiKey)
+	})
+
+	s.Run("failed - insert error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		apiKey, err := repo.Insert(context.Background(), &entity.APIKey{Name: "studio", Hash: "hash"})
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), apiKey)
+	})
+}
+
+func (s *APIKeyTestSuite) TestGetByHash() {
+	repo := postgres.NewAPIKey(s.dbx)
+	query := `SELECT id, tenant_id, name, key_hash, created_at, revoked_at FROM public.api_keys WHERE key_hash = $1`
+	cols := []string{"id", "tenant_id", "name", "key_hash", "created_at", "revoked_at"}
+
+	s.Run("success - revoked key", func() {
+		rows := sqlmock.NewRows(cols).AddRow("key-1", "tenant-1", "studio", "hash", timeTemp, timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+
+		apiKey, err := repo.GetByHash(context.Background(), "hash")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "key-1", apiKey.ID)
+		assert.Equal(s.T(), "tenant-1", apiKey.TenantID)
+		assert.True(s.T(), apiKey.Revoked())
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrNoRows)
+
+		apiKey, err := repo.GetByHash(context.Background(), "hash")
+		assert.Nil(s.T(), apiKey)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrAPIKeyNotFound, perr.Code)
+	})
+}
+
+func (s *APIKeyTestSuite) TestRevoke() {
+	repo := postgres.NewAPIKey(s.dbx)
+	query := `UPDATE public.api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("key-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(s.T(), repo.Revoke(context.Background(), "key-1"))
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.Revoke(context.Background(), "key-1")
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrAPIKeyNotFound, perr.Code)
+	})
+}
@@ -3,28 +3,90 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
-	"github.com/rs/zerolog/log"
 )
 
 // Deck defines deck repository
 type Deck struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	compact bool
+}
+
+// DeckOption defines optional configuration for deck repository
+type DeckOption func(*Deck)
+
+// WithCompactEncoding stores cards using compact encoding (see entity.EncodeCompact)
+// instead of array of card objects. Decks stored in either encoding can always be read.
+func WithCompactEncoding() DeckOption {
+	return func(d *Deck) {
+		d.compact = true
+	}
 }
 
 // NewDeck returns new deck repository
-func NewDeck(db *sqlx.DB) *Deck {
-	return &Deck{db: db}
+func NewDeck(db *sqlx.DB, opts ...DeckOption) *Deck {
+	d := &Deck{db: db}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// compactCards is valuer that stores cards as JSON string of compact encoding
+type compactCards entity.Cards
+
+// Value implements valuer interface
+func (c compactCards) Value() (driver.Value, error) {
+	encoded, err := entity.EncodeCompact(entity.StandardCardSet, entity.Cards(c))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// cardsValue returns cards valuer according to configured encoding
+func (d *Deck) cardsValue(cards *entity.Cards) driver.Valuer {
+	if d.compact && cards != nil {
+		return compactCards(*cards)
+	}
+	return cards
+}
+
+// Transaction runs fn inside database transaction.
+// Repository calls made using ctx passed to fn are part of the transaction,
+// which is rolled back if fn returns error.
+// carddeck.tenant_id setting of the transaction is the tenant carried by ctx,
+// so row level security policy of decks table only lets the transaction see decks of the tenant.
+func (d *Deck) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(tenantSetKey{}) != nil {
+		return fn(ctx)
+	}
+
+	return transaction(ctx, d.db, func(ctx context.Context) error {
+		query := `SELECT set_config('carddeck.tenant_id', $1, true)`
+		if _, err := conn(ctx, d.db).ExecContext(ctx, query, entity.TenantFromContext(ctx)); err != nil {
+			return err
+		}
+
+		return fn(context.WithValue(ctx, tenantSetKey{}, true))
+	})
 }
 
 // Insert insert new deck to database
 func (d *Deck) Insert(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
-	query := `INSERT INTO public.decks (cards, shuffled) VALUES ($1, $2) RETURNING id, cards, shuffled, created_at, updated_at`
+	query := `INSERT INTO public.decks (cards, shuffled, tenant_id) VALUES ($1, $2, $3) RETURNING id, cards, shuffled, version, created_at, updated_at`
 
-	row := d.db.QueryRowxContext(ctx, query, deck.Cards, deck.Shuffled)
-	if err := row.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		row := conn(ctx, d.db).QueryRowxContext(ctx, query, d.cardsValue(deck.Cards), deck.Shuffled, tenantArg(ctx))
+		return scanDeck(row, deck)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -33,11 +95,13 @@ func (d *Deck) Insert(ctx context.Context, deck *entity.Deck) (*entity.Deck, err
 
 // GetByID get deck by ID
 func (d *Deck) GetByID(ctx context.Context, id string) (*entity.Deck, error) {
-	query := `SELECT id, cards, shuffled, created_at, updated_at FROM public.decks WHERE id = $1`
+	query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND ` + visibleTo(2)
 
 	deck := entity.NewDeck(false, nil)
-	row := d.db.QueryRowxContext(ctx, query, id)
-	if err := row.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		return scanDeck(conn(ctx, d.db).QueryRowxContext(ctx, query, id, tenantArg(ctx)), deck)
+	})
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound)
 		}
@@ -47,45 +111,189 @@ func (d *Deck) GetByID(ctx context.Context, id string) (*entity.Deck, error) {
 	return deck, nil
 }
 
-func (d *Deck) DrawCards(ctx context.Context, id string, count int64) (cards *entity.Cards, err error) {
-	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{})
-	if err != nil {
-		return nil, err
+// List returns decks matching the filter, ordered from the newest
+func (d *Deck) List(ctx context.Context, filter *entity.DeckFilter) ([]*entity.Deck, error) {
+	args := []interface{}{tenantArg(ctx)}
+	conditions := []string{visibleTo(1)}
+
+	if len(filter.IDs) > 0 {
+		placeholders := make([]string, len(filter.IDs))
+		for i, id := range filter.IDs {
+			args = append(args, id)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf("id IN (%s)", strings.Join(placeholders, ", ")))
 	}
+	if filter.Shuffled != nil {
+		args = append(args, *filter.Shuffled)
+		conditions = append(conditions, fmt.Sprintf("shuffled = $%d", len(args)))
+	}
+
+	query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE ` + strings.Join(conditions, " AND ")
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
 
-	defer func() {
+	decks := []*entity.Deck{}
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		rows, err := conn(ctx, d.db).QueryxContext(ctx, query, args...)
 		if err != nil {
-			rollbackErr := tx.Rollback()
-			if rollbackErr != nil {
-				log.Error().Err(rollbackErr).Msg("error rollbacking draw cards")
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			deck := entity.NewDeck(false, nil)
+			if err := rows.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.Version, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+				return err
 			}
+			decks = append(decks, deck)
 		}
-	}()
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decks, nil
+}
+
+// GetByIDForUpdate get deck by ID and lock it until the end of transaction.
+// Should be called inside Transaction.
+func (d *Deck) GetByIDForUpdate(ctx context.Context, id string) (*entity.Deck, error) {
+	query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND ` + visibleTo(2) + ` FOR UPDATE`
 
-	selectForUpdateQuery := `SELECT id, cards, shuffled, created_at, updated_at FROM public.decks WHERE id = $1 FOR UPDATE`
 	deck := entity.NewDeck(false, nil)
-	row := tx.QueryRowContext(ctx, selectForUpdateQuery, id)
-	if err := row.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		return scanDeck(conn(ctx, d.db).QueryRowxContext(ctx, query, id, tenantArg(ctx)), deck)
+	})
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound)
 		}
 		return nil, err
 	}
 
-	drawwed, remaining, err := deck.Cards.Draw(count)
+	return deck, nil
+}
+
+// Update stores cards and shuffled state of the deck, incrementing its version
+func (d *Deck) Update(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
+	query := `UPDATE public.decks SET cards=$2, shuffled=$3, version=version+1, updated_at=NOW() WHERE id = $1 AND ` + visibleTo(4) +
+		` RETURNING id, cards, shuffled, version, created_at, updated_at`
+
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		return scanDeck(conn(ctx, d.db).QueryRowxContext(ctx, query, deck.ID, d.cardsValue(deck.Cards), deck.Shuffled, tenantArg(ctx)), deck)
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound)
+		}
 		return nil, err
 	}
 
-	updateQuery := `UPDATE public.decks SET cards=$2, updated_at=NOW() WHERE id = $1 RETURNING id, cards, shuffled, created_at, updated_at`
-	row = tx.QueryRowContext(ctx, updateQuery, id, &remaining)
-	if err := row.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.CreatedAt, &deck.UpdatedAt); err != nil {
-		return nil, err
+	return deck, nil
+}
+
+// Delete deletes the deck by ID
+func (d *Deck) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM public.decks WHERE id = $1 AND ` + visibleTo(2)
+
+	return d.Transaction(ctx, func(ctx context.Context) error {
+		res, err := conn(ctx, d.db).ExecContext(ctx, query, id, tenantArg(ctx))
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound)
+		}
+
+		return nil
+	})
+}
+
+// CountActiveForUpdate returns number of decks of the tenant carried by ctx, 0 for requests without tenant.
+// Concurrent calls for the tenant wait for the transaction to end, so inserts checked against the count are serialized.
+// Should be called inside Transaction.
+func (d *Deck) CountActiveForUpdate(ctx context.Context) (int, error) {
+	tenantID := entity.TenantFromContext(ctx)
+	if tenantID == "" {
+		return 0, nil
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	// COUNT can not lock rows, decks of the tenant are guarded by advisory lock of the tenant instead
+	lock := `SELECT pg_advisory_xact_lock(hashtext($1))`
+	query := `SELECT COUNT(*) FROM public.decks WHERE tenant_id = $1`
+
+	var count int
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := conn(ctx, d.db).ExecContext(ctx, lock, tenantID); err != nil {
+			return err
+		}
+		return conn(ctx, d.db).QueryRowxContext(ctx, query, tenantID).Scan(&count)
+	})
+	return count, err
+}
+
+// DrawCards draws cards from the top of the deck and stores the remaining cards.
+// When version is not 0, the draw only happens if the deck is still at that version.
+// Returns the drawn cards together with the deck after the draw.
+func (d *Deck) DrawCards(ctx context.Context, id string, count int64, version int64) (*entity.Cards, *entity.Deck, error) {
+	var (
+		drawwed entity.Cards
+		deck    *entity.Deck
+	)
+
+	err := d.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		deck, err = d.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if version != 0 && deck.Version != version {
+			return entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch)
+		}
+
+		var remaining entity.Cards
+		drawwed, remaining, err = deck.Cards.Draw(count)
+		if err != nil {
+			return err
+		}
+
+		updateQuery := `UPDATE public.decks SET cards=$2, version=version+1, updated_at=NOW() WHERE id = $1 RETURNING id, cards, shuffled, version, created_at, updated_at`
+		row := conn(ctx, d.db).QueryRowxContext(ctx, updateQuery, id, d.cardsValue(&remaining))
+		return scanDeck(row, deck)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &drawwed, deck, nil
+}
+
+// tenantSetKey is context key marking transaction whose carddeck.tenant_id setting is set, see Deck.Transaction
+type tenantSetKey struct{}
+
+// visibleTo returns condition matching decks visible to the tenant passed as the nth argument (see tenantArg).
+// Decks without tenant are visible to everyone, decks of other tenants are treated as not found.
+// Row level security policy of decks table enforces the same condition.
+func visibleTo(n int) string {
+	return fmt.Sprintf("(tenant_id IS NULL OR tenant_id = $%d)", n)
+}
+
+// tenantArg returns tenant ID carried by ctx (see entity.WithTenant), NULL for requests without tenant
+func tenantArg(ctx context.Context) interface{} {
+	if tenantID := entity.TenantFromContext(ctx); tenantID != "" {
+		return tenantID
 	}
+	return nil
+}
 
-	return &drawwed, nil
+func scanDeck(row *sqlx.Row, deck *entity.Deck) error {
+	return row.Scan(&deck.ID, &deck.Cards, &deck.Shuffled, &deck.Version, &deck.CreatedAt, &deck.UpdatedAt)
 }
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// DeckEventsChannel is the LISTEN/NOTIFY channel receiving ID of every persisted deck event
+const DeckEventsChannel = "deck_events"
+
+// DeckEvent defines repository for persisted deck events
+type DeckEvent struct {
+	db     *sqlx.DB
+	notify bool
+}
+
+// DeckEventOption configures deck event repository
+type DeckEventOption func(*DeckEvent)
+
+// WithNotify sends ID of every inserted event to DeckEventsChannel, see Listener.
+// The notification is delivered only when the transaction inserting the event is committed.
+func WithNotify() DeckEventOption {
+	return func(d *DeckEvent) {
+		d.notify = true
+	}
+}
+
+// NewDeckEvent returns new deck event repository
+func NewDeckEvent(db *sqlx.DB, opts ...DeckEventOption) *DeckEvent {
+	d := &DeckEvent{db: db}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Insert persists the event and assigns its ID.
+// Called inside the transaction of the deck mutation, so the event exists only if the mutation is committed.
+func (d *DeckEvent) Insert(ctx context.Context, event *entity.DeckEvent) (*entity.DeckEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO public.deck_events (deck_id, type, payload) VALUES ($1, $2, $3) RETURNING id`
+	if err := conn(ctx, d.db).QueryRowxContext(ctx, query, event.DeckID, event.Type, payload).Scan(&event.ID); err != nil {
+		return nil, err
+	}
+
+	if d.notify {
+		// payload of notification is limited to 8000 bytes, so only the ID is sent
+		notifyQuery := `SELECT pg_notify($1, $2)`
+		if _, err := conn(ctx, d.db).ExecContext(ctx, notifyQuery, DeckEventsChannel, strconv.FormatInt(event.ID, 10)); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// GetByID returns event by its ID
+func (d *DeckEvent) GetByID(ctx context.Context, id int64) (*entity.DeckEvent, error) {
+	query := `SELECT id, payload FROM public.deck_events WHERE id = $1`
+
+	event, err := scanDeckEvent(conn(ctx, d.db).QueryRowxContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.NewError(entity.ErrDeckEventNotFound, entity.ErrMsgDeckEventNotFound)
+		}
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// ListSince returns at most limit events of every deck having ID greater than afterID, ordered by ID
+func (d *DeckEvent) ListSince(ctx context.Context, afterID int64, limit int) ([]*entity.DeckEvent, error) {
+	query := `SELECT id, payload FROM public.deck_events WHERE id > $1 ORDER BY id LIMIT $2`
+
+	rows, err := conn(ctx, d.db).QueryxContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeckEvents(rows)
+}
+
+// ListAfter returns at most limit events of the deck having ID greater than afterID, ordered by ID
+func (d *DeckEvent) ListAfter(ctx context.Context, deckID string, afterID int64, limit int) ([]*entity.DeckEvent, error) {
+	query := `SELECT id, payload FROM public.deck_events WHERE deck_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+
+	rows, err := conn(ctx, d.db).QueryxContext(ctx, query, deckID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeckEvents(rows)
+}
+
+// DeleteOlderThan deletes events persisted more than age ago, returning number of deleted events.
+// Events of deleted decks are deleted together with the deck.
+func (d *DeckEvent) DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	query := `DELETE FROM public.deck_events WHERE created_at < NOW() - $1 * INTERVAL '1 second'`
+
+	res, err := conn(ctx, d.db).ExecContext(ctx, query, age.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// scanner is implemented by both sqlx.Row and sqlx.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDeckEvent scans id and payload columns into event
+func scanDeckEvent(row scanner) (*entity.DeckEvent, error) {
+	var (
+		id      int64
+		payload []byte
+		event   entity.DeckEvent
+	)
+	if err := row.Scan(&id, &payload); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	event.ID = id
+
+	return &event, nil
+}
+
+func scanDeckEvents(rows *sqlx.Rows) ([]*entity.DeckEvent, error) {
+	defer rows.Close()
+
+	events := []*entity.DeckEvent{}
+	for rows.Next() {
+		event, err := scanDeckEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,210 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeckEventTestSuite struct {
+	suite.Suite
+	dbmock sqlmock.Sqlmock
+	dbx    *sqlx.DB
+}
+
+func (s *DeckEventTestSuite) SetupSuite() {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(s.T(), err)
+	s.dbmock = dbmock
+	s.dbx = sqlx.NewDb(db, "sqlmock")
+}
+
+func TestDeckEventTestSuite(t *testing.T) {
+	suite.Run(t, new(DeckEventTestSuite))
+}
+
+func (s *DeckEventTestSuite) TestInsert() {
+	repo := postgres.NewDeckEvent(s.dbx)
+	query := `INSERT INTO public.deck_events (deck_id, type, payload) VALUES ($1, $2, $3) RETURNING id`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(42)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("deck-1", entity.DeckEventShuffled, sqlmock.AnyArg()).WillReturnRows(rows)
+
+		event, err := repo.Insert(context.Background(), &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1", OccurredAt: timeTemp})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(42), event.ID)
+	})
+
+	s.Run("success - joins transaction in context", func() {
+		s.dbmock.ExpectBegin()
+		s.dbmock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('carddeck.tenant_id', $1, true)`)).WithArgs("").WillReturnResult(sqlmock.NewResult(0, 1))
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(43)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
+
+		err := postgres.NewDeck(s.dbx).Transaction(context.Background(), func(ctx context.Context) error {
+			_, err := repo.Insert(ctx, &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1"})
+			return err
+		})
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+
+	s.Run("failed - insert error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		event, err := repo.Insert(context.Background(), &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1"})
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), event)
+	})
+}
+
+func (s *DeckEventTestSuite) TestListAfter() {
+	repo := postgres.NewDeckEvent(s.dbx)
+	query := `SELECT id, payload FROM public.deck_events WHERE deck_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(5, []byte(`{"id":0,"type":"deck.drawn","deck_id":"deck-1","version":2,"shuffled":false,"remaining":51,"cards":[{"value":"ACE","suit":"SPADE","code":"AS"}],"occurred_at":"2022-01-01T01:00:00Z"}`)).
+			AddRow(6, []byte(`{"id":0,"type":"deck.shuffled","deck_id":"deck-1","version":3,"shuffled":true,"remaining":51,"occurred_at":"2022-01-01T01:00:00Z"}`))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("deck-1", 4, 100).WillReturnRows(rows)
+
+		events, err := repo.ListAfter(context.Background(), "deck-1", 4, 100)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []*entity.DeckEvent{
+			{ID: 5, Type: entity.DeckEventDrawn, DeckID: "deck-1", Version: 2, Remaining: 51, Cards: &entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, OccurredAt: timeTemp},
+			{ID: 6, Type: entity.DeckEventShuffled, DeckID: "deck-1", Version: 3, Shuffled: true, Remaining: 51, OccurredAt: timeTemp},
+		}, events)
+	})
+
+	s.Run("success - no events", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}))
+
+		events, err := repo.ListAfter(context.Background(), "deck-1", 4, 100)
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), events)
+	})
+
+	s.Run("failed - query error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		events, err := repo.ListAfter(context.Background(), "deck-1", 4, 100)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), events)
+	})
+
+	s.Run("failed - payload invalid", func() {
+		rows := sqlmock.NewRows([]string{"id", "payload"}).AddRow(5, []byte(`not json`))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+
+		events, err := repo.ListAfter(context.Background(), "deck-1", 4, 100)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), events)
+	})
+}
+
+func (s *DeckEventTestSuite) TestInsert_Notify() {
+	repo := postgres.NewDeckEvent(s.dbx, postgres.WithNotify())
+	query := `INSERT INTO public.deck_events (deck_id, type, payload) VALUES ($1, $2, $3) RETURNING id`
+	notifyQuery := `SELECT pg_notify($1, $2)`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+		s.dbmock.ExpectExec(regexp.QuoteMeta(notifyQuery)).WithArgs(postgres.DeckEventsChannel, "42").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		event, err := repo.Insert(context.Background(), &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(42), event.ID)
+	})
+
+	s.Run("failed - notify error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+		s.dbmock.ExpectExec(regexp.QuoteMeta(notifyQuery)).WillReturnError(errors.New("some error"))
+
+		event, err := repo.Insert(context.Background(), &entity.DeckEvent{Type: entity.DeckEventShuffled, DeckID: "deck-1"})
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), event)
+	})
+}
+
+func (s *DeckEventTestSuite) TestGetByID() {
+	repo := postgres.NewDeckEvent(s.dbx)
+	query := `SELECT id, payload FROM public.deck_events WHERE id = $1`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows([]string{"id", "payload"}).AddRow(5, []byte(`{"type":"deck.shuffled","deck_id":"deck-1","version":3}`))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(5).WillReturnRows(rows)
+
+		event, err := repo.GetByID(context.Background(), 5)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.DeckEvent{ID: 5, Type: entity.DeckEventShuffled, DeckID: "deck-1", Version: 3}, event)
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}))
+
+		event, err := repo.GetByID(context.Background(), 5)
+		assert.Nil(s.T(), event)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckEventNotFound, perr.Code)
+	})
+}
+
+func (s *DeckEventTestSuite) TestListSince() {
+	repo := postgres.NewDeckEvent(s.dbx)
+	query := `SELECT id, payload FROM public.deck_events WHERE id > $1 ORDER BY id LIMIT $2`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows([]string{"id", "payload"}).
+			AddRow(5, []byte(`{"type":"deck.shuffled","deck_id":"deck-1"}`)).
+			AddRow(6, []byte(`{"type":"deck.shuffled","deck_id":"deck-2"}`))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(4, 10).WillReturnRows(rows)
+
+		events, err := repo.ListSince(context.Background(), 4, 10)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []*entity.DeckEvent{
+			{ID: 5, Type: entity.DeckEventShuffled, DeckID: "deck-1"},
+			{ID: 6, Type: entity.DeckEventShuffled, DeckID: "deck-2"},
+		}, events)
+	})
+
+	s.Run("failed - query error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		events, err := repo.ListSince(context.Background(), 4, 10)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), events)
+	})
+}
+
+func (s *DeckEventTestSuite) TestDeleteOlderThan() {
+	repo := postgres.NewDeckEvent(s.dbx)
+	query := `DELETE FROM public.deck_events WHERE created_at < NOW() - $1 * INTERVAL '1 second'`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(float64(86400)).WillReturnResult(sqlmock.NewResult(0, 7))
+
+		deleted, err := repo.DeleteOlderThan(context.Background(), 24*time.Hour)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(7), deleted)
+	})
+
+	s.Run("failed - unknown error from repository", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		_, err := repo.DeleteOlderThan(context.Background(), 24*time.Hour)
+		assert.Error(s.T(), err)
+	})
+}
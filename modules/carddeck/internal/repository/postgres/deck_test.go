@@ -52,19 +52,31 @@ func (s *DeckTestSuite) SetupSuite() {
 	s.dbx = sqlx.NewDb(db, "sqlmock")
 }
 
+// expectTransaction expects transaction setting carddeck.tenant_id to tenantID, see Deck.Transaction
+func (s *DeckTestSuite) expectTransaction(tenantID string) {
+	s.dbmock.ExpectBegin()
+	s.dbmock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('carddeck.tenant_id', $1, true)`)).WithArgs(tenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func (s *DeckTestSuite) TearDownTest() {
+	assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+}
+
 func TestDeckTestSuite(t *testing.T) {
 	suite.Run(t, new(DeckTestSuite))
 }
 
 func (s *DeckTestSuite) TestInsert() {
 	repo := postgres.NewDeck(s.dbx)
-	returningCols := []string{"id", "cards", "shuffled", "created_at", "updated_at"}
-	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, timeTemp, timeTemp}
-	query := `INSERT INTO public.decks (cards, shuffled) VALUES ($1, $2) RETURNING id, cards, shuffled, created_at, updated_at`
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	query := `INSERT INTO public.decks (cards, shuffled, tenant_id) VALUES ($1, $2, $3) RETURNING id, cards, shuffled, version, created_at, updated_at`
 
 	s.Run("success", func() {
 		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
 
 		deck, err := repo.Insert(context.Background(), defaultDeck)
 		assert.NoError(s.T(), err)
@@ -75,8 +87,21 @@ func (s *DeckTestSuite) TestInsert() {
 		assert.Equal(s.T(), afterInsertDeck.UpdatedAt, deck.UpdatedAt)
 	})
 
+	s.Run("success - belongs to tenant of the context", func() {
+		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("tenant-1")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(sqlmock.AnyArg(), false, "tenant-1").WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
+
+		_, err := repo.Insert(entity.WithTenant(context.Background(), "tenant-1"), defaultDeck)
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+
 	s.Run("failed - unknown error from repository", func() {
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
 
 		deck, err := repo.Insert(context.Background(), defaultDeck)
 		assert.Error(s.T(), err)
@@ -84,15 +109,47 @@ func (s *DeckTestSuite) TestInsert() {
 	})
 }
 
+func (s *DeckTestSuite) TestInsert_CompactEncoding() {
+	repo := postgres.NewDeck(s.dbx, postgres.WithCompactEncoding())
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`"standard.AAE"`), false, 1, timeTemp, timeTemp}
+	query := `INSERT INTO public.decks (cards, shuffled, tenant_id) VALUES ($1, $2, $3) RETURNING id, cards, shuffled, version, created_at, updated_at`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs([]byte(`"standard.AAE"`), false, nil).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
+
+		deck, err := repo.Insert(context.Background(), defaultDeck)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), afterInsertDeck.ID, deck.ID)
+		assert.Equal(s.T(), afterInsertDeck.Cards, deck.Cards)
+		assert.Equal(s.T(), afterInsertDeck.Remaining(), deck.Remaining())
+	})
+
+	s.Run("failed - card is not part of card set", func() {
+		deck := entity.NewDeck(false, &entity.Cards{{Val: "JOKER", Suit: "", Code: "X"}})
+		s.expectTransaction("")
+		s.dbmock.ExpectRollback()
+
+		_, err := repo.Insert(context.Background(), deck)
+		assert.Error(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+}
+
 func (s *DeckTestSuite) TestGetByID() {
 	repo := postgres.NewDeck(s.dbx)
-	returningCols := []string{"id", "cards", "shuffled", "created_at", "updated_at"}
-	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, timeTemp, timeTemp}
-	query := `SELECT id, cards, shuffled, created_at, updated_at FROM public.decks WHERE id = $1`
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $2)`
 
 	s.Run("success", func() {
 		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
 
 		deck, err := repo.GetByID(context.Background(), "temp-uuid-abc-def")
 		assert.NoError(s.T(), err)
@@ -104,15 +161,33 @@ func (s *DeckTestSuite) TestGetByID() {
 	})
 
 	s.Run("failed - unknown error from repository", func() {
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
 
 		deck, err := repo.GetByID(context.Background(), "abc")
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), deck)
 	})
 
+	s.Run("failed - deck of another tenant", func() {
+		s.expectTransaction("tenant-2")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("abc", "tenant-2").WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectRollback()
+
+		deck, err := repo.GetByID(entity.WithTenant(context.Background(), "tenant-2"), "abc")
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+
 	s.Run("failed - no rows result", func() {
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectRollback()
 
 		deck, err := repo.GetByID(context.Background(), "abc")
 		assert.Error(s.T(), err)
@@ -127,14 +202,14 @@ func (s *DeckTestSuite) TestGetByID() {
 
 func (s *DeckTestSuite) TestDrawCards() {
 	repo := postgres.NewDeck(s.dbx)
-	returningCols := []string{"id", "cards", "shuffled", "created_at", "updated_at"}
-	selectVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, timeTemp, timeTemp}
-	updateVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"}]`), false, timeTemp, timeTemp}
-	selectForUpdateQuery := `SELECT id, cards, shuffled, created_at, updated_at FROM public.decks WHERE id = $1 FOR UPDATE`
-	updateQuery := `UPDATE public.decks SET cards=$2, updated_at=NOW() WHERE id = $1 RETURNING id, cards, shuffled, created_at, updated_at`
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	selectVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	updateVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"}]`), false, 1, timeTemp, timeTemp}
+	selectForUpdateQuery := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $2) FOR UPDATE`
+	updateQuery := `UPDATE public.decks SET cards=$2, version=version+1, updated_at=NOW() WHERE id = $1 RETURNING id, cards, shuffled, version, created_at, updated_at`
 
 	s.Run("success", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 
 		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
@@ -143,32 +218,34 @@ func (s *DeckTestSuite) TestDrawCards() {
 
 		s.dbmock.ExpectCommit()
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, deck, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.NoError(s.T(), err)
 		assert.Equal(s.T(), &afterDrawCards, cards)
+		assert.Equal(s.T(), "temp-uuid-abc-def", deck.ID)
+		assert.Equal(s.T(), 1, deck.Remaining())
 	})
 
 	s.Run("failed - begin transaction failed", func() {
 		s.dbmock.ExpectBegin().WillReturnError(errors.New("some error"))
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 	})
 
 	s.Run("failed - select for update failed", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnError(errors.New("some error"))
 
 		s.dbmock.ExpectRollback()
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 	})
 
 	s.Run("failed - update failed", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 
 		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
@@ -176,13 +253,13 @@ func (s *DeckTestSuite) TestDrawCards() {
 
 		s.dbmock.ExpectRollback()
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 	})
 
 	s.Run("failed - commit failed", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 
 		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
@@ -191,13 +268,13 @@ func (s *DeckTestSuite) TestDrawCards() {
 
 		s.dbmock.ExpectCommit().WillReturnError(errors.New("some error"))
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 	})
 
 	s.Run("failed - rollback failed", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 
 		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
@@ -205,20 +282,53 @@ func (s *DeckTestSuite) TestDrawCards() {
 
 		s.dbmock.ExpectRollback().WillReturnError(errors.New("some error"))
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 	})
 
+	s.Run("success - expected version matches", func() {
+		s.expectTransaction("")
+
+		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
+		updateRows := sqlmock.NewRows(returningCols).AddRow(updateVals...)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(updateQuery)).WillReturnRows(updateRows)
+
+		s.dbmock.ExpectCommit()
+
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 1)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &afterDrawCards, cards)
+	})
+
+	s.Run("failed - expected version mismatch", func() {
+		s.expectTransaction("")
+
+		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
+
+		s.dbmock.ExpectRollback()
+
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 1, 2)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), cards)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckVersionMismatch, perr.Code)
+		assert.Equal(s.T(), entity.ErrMsgDeckVersionMismatch, perr.Message)
+	})
+
 	s.Run("failed - draw count is larger than available", func() {
-		s.dbmock.ExpectBegin()
+		s.expectTransaction("")
 
 		selectRows := sqlmock.NewRows(returningCols).AddRow(selectVals...)
 		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(selectRows)
 
 		s.dbmock.ExpectRollback()
 
-		cards, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 999)
+		cards, _, err := repo.DrawCards(context.Background(), "temp-uuid-abc-def", 999, 0)
 		assert.Error(s.T(), err)
 		assert.Nil(s.T(), cards)
 
@@ -228,3 +338,236 @@ func (s *DeckTestSuite) TestDrawCards() {
 		assert.Equal(s.T(), entity.ErrMsgDeckCardInsufficient, perr.Message)
 	})
 }
+
+func (s *DeckTestSuite) TestGetByIDForUpdate() {
+	repo := postgres.NewDeck(s.dbx)
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $2) FOR UPDATE`
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
+
+		deck, err := repo.GetByIDForUpdate(context.Background(), "temp-uuid-abc-def")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), afterInsertDeck.ID, deck.ID)
+		assert.Equal(s.T(), afterInsertDeck.Cards, deck.Cards)
+		assert.Equal(s.T(), int64(1), deck.Version)
+	})
+
+	s.Run("failed - no rows result", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectRollback()
+
+		deck, err := repo.GetByIDForUpdate(context.Background(), "abc")
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+}
+
+func (s *DeckTestSuite) TestUpdate() {
+	repo := postgres.NewDeck(s.dbx)
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "2", "suit": "SPADE", "code": "2S"},{"value": "ACE", "suit": "SPADE", "code": "AS"}]`), true, 2, timeTemp, timeTemp}
+	query := `UPDATE public.decks SET cards=$2, shuffled=$3, version=version+1, updated_at=NOW() WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $4) RETURNING id, cards, shuffled, version, created_at, updated_at`
+
+	s.Run("success", func() {
+		deck := entity.NewDeck(true, &entity.Cards{
+			{Val: "2", Suit: "SPADE", Code: "2S"},
+			{Val: "ACE", Suit: "SPADE", Code: "AS"},
+		})
+		deck.ID = "temp-uuid-abc-def"
+		deck.Version = 1
+
+		rows := sqlmock.NewRows(returningCols).AddRow(returningVals...)
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("temp-uuid-abc-def", sqlmock.AnyArg(), true, nil).WillReturnRows(rows)
+		s.dbmock.ExpectCommit()
+
+		updated, err := repo.Update(context.Background(), deck)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(2), updated.Version)
+		assert.Equal(s.T(), true, updated.Shuffled)
+		assert.Equal(s.T(), 2, updated.Remaining())
+	})
+
+	s.Run("failed - no rows result", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectRollback()
+
+		deck, err := repo.Update(context.Background(), entity.NewDeck(false, &entity.Cards{}))
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+}
+
+func (s *DeckTestSuite) TestDelete() {
+	repo := postgres.NewDeck(s.dbx)
+	query := `DELETE FROM public.decks WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $2)`
+
+	s.Run("success", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("temp-uuid-abc-def", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+		s.dbmock.ExpectCommit()
+
+		err := repo.Delete(context.Background(), "temp-uuid-abc-def")
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - not found", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 0))
+		s.dbmock.ExpectRollback()
+
+		err := repo.Delete(context.Background(), "temp-uuid-abc-def")
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+
+	s.Run("failed - exec error", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
+
+		err := repo.Delete(context.Background(), "temp-uuid-abc-def")
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *DeckTestSuite) TestList() {
+	repo := postgres.NewDeck(s.dbx)
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	shuffled := true
+
+	s.Run("success - no filter", func() {
+		query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE (tenant_id IS NULL OR tenant_id = $1) ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(nil, 20, 0).WillReturnRows(sqlmock.NewRows(returningCols).AddRow(returningVals...))
+		s.dbmock.ExpectCommit()
+
+		decks, err := repo.List(context.Background(), &entity.DeckFilter{Limit: 20})
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), decks, 1)
+		assert.Equal(s.T(), afterInsertDeck.ID, decks[0].ID)
+		assert.Equal(s.T(), afterInsertDeck.Cards, decks[0].Cards)
+		assert.Equal(s.T(), 2, decks[0].Remaining())
+	})
+
+	s.Run("success - every filter", func() {
+		query := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE (tenant_id IS NULL OR tenant_id = $1) AND id IN ($2, $3) AND shuffled = $4 ORDER BY created_at DESC, id LIMIT $5 OFFSET $6`
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(nil, "a", "b", true, 10, 5).WillReturnRows(sqlmock.NewRows(returningCols))
+		s.dbmock.ExpectCommit()
+
+		decks, err := repo.List(context.Background(), &entity.DeckFilter{IDs: []string{"a", "b"}, Shuffled: &shuffled, Limit: 10, Offset: 5})
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), decks)
+	})
+
+	s.Run("failed - query error", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(`SELECT (.+) FROM public.decks`).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
+
+		_, err := repo.List(context.Background(), &entity.DeckFilter{Limit: 20})
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *DeckTestSuite) TestTransaction() {
+	repo := postgres.NewDeck(s.dbx)
+	returningCols := []string{"id", "cards", "shuffled", "version", "created_at", "updated_at"}
+	returningVals := []driver.Value{"temp-uuid-abc-def", []byte(`[{"value": "ACE", "suit": "SPADE", "code": "AS"},{"value": "2", "suit": "SPADE", "code": "2S"}]`), false, 1, timeTemp, timeTemp}
+	selectForUpdateQuery := `SELECT id, cards, shuffled, version, created_at, updated_at FROM public.decks WHERE id = $1 AND (tenant_id IS NULL OR tenant_id = $2) FOR UPDATE`
+	updateQuery := `UPDATE public.decks SET cards=$2, version=version+1, updated_at=NOW() WHERE id = $1 RETURNING id, cards, shuffled, version, created_at, updated_at`
+
+	s.Run("success - nested calls join the transaction", func() {
+		s.expectTransaction("")
+		for i := 0; i < 2; i++ {
+			s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(sqlmock.NewRows(returningCols).AddRow(returningVals...))
+			s.dbmock.ExpectQuery(regexp.QuoteMeta(updateQuery)).WillReturnRows(sqlmock.NewRows(returningCols).AddRow(returningVals...))
+		}
+		s.dbmock.ExpectCommit()
+
+		err := repo.Transaction(context.Background(), func(ctx context.Context) error {
+			for i := 0; i < 2; i++ {
+				if _, _, err := repo.DrawCards(ctx, "temp-uuid-abc-def", 1, 0); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+
+	s.Run("failed - rollback when fn returns error", func() {
+		s.expectTransaction("")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectForUpdateQuery)).WillReturnRows(sqlmock.NewRows(returningCols).AddRow(returningVals...))
+		s.dbmock.ExpectRollback()
+
+		err := repo.Transaction(context.Background(), func(ctx context.Context) error {
+			_, _, err := repo.DrawCards(ctx, "temp-uuid-abc-def", 999, 0)
+			return err
+		})
+		assert.Error(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+}
+
+func (s *DeckTestSuite) TestCountActiveForUpdate() {
+	repo := postgres.NewDeck(s.dbx)
+	lock := `SELECT pg_advisory_xact_lock(hashtext($1))`
+	query := `SELECT COUNT(*) FROM public.decks WHERE tenant_id = $1`
+
+	s.Run("success", func() {
+		s.expectTransaction("tenant-1")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(lock)).WithArgs("tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("tenant-1").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		s.dbmock.ExpectCommit()
+
+		count, err := repo.CountActiveForUpdate(entity.WithTenant(context.Background(), "tenant-1"))
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 3, count)
+	})
+
+	s.Run("success - requests without tenant have no quota", func() {
+		count, err := repo.CountActiveForUpdate(context.Background())
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 0, count)
+	})
+
+	s.Run("failed - lock error", func() {
+		s.expectTransaction("tenant-1")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(lock)).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
+
+		_, err := repo.CountActiveForUpdate(entity.WithTenant(context.Background(), "tenant-1"))
+		assert.Error(s.T(), err)
+	})
+
+	s.Run("failed - query error", func() {
+		s.expectTransaction("tenant-1")
+		s.dbmock.ExpectExec(regexp.QuoteMeta(lock)).WithArgs("tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+		s.dbmock.ExpectRollback()
+
+		_, err := repo.CountActiveForUpdate(entity.WithTenant(context.Background(), "tenant-1"))
+		assert.Error(s.T(), err)
+	})
+}
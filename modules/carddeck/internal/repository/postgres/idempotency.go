@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// IdempotencyKey defines repository for responses stored by idempotency key
+type IdempotencyKey struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyKey returns new idempotency key repository
+func NewIdempotencyKey(db *sqlx.DB) *IdempotencyKey {
+	return &IdempotencyKey{db: db}
+}
+
+// Reserve inserts new key, or takes over the key if it is already expired
+// or has been reserved without response for longer than lockTimeout.
+// Returns the existing record if the key is still in use.
+func (i *IdempotencyKey) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time, lockTimeout time.Duration) (*entity.IdempotencyRecord, error) {
+	reserveQuery := `INSERT INTO public.idempotency_keys (key, fingerprint, expires_at) VALUES ($1, $2, $3) ` +
+		`ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = 0, header = NULL, body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at ` +
+		`WHERE idempotency_keys.expires_at <= NOW() ` +
+		`OR (idempotency_keys.status_code = 0 AND idempotency_keys.created_at <= NOW() - $4 * INTERVAL '1 second') RETURNING key`
+
+	var reserved string
+	err := i.db.QueryRowxContext(ctx, reserveQuery, key, fingerprint, expiresAt.UTC(), lockTimeout.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	selectQuery := `SELECT key, fingerprint, status_code, header, body, expires_at FROM public.idempotency_keys WHERE key = $1`
+
+	var (
+		record entity.IdempotencyRecord
+		header []byte
+	)
+	row := i.db.QueryRowxContext(ctx, selectQuery, key)
+	if err := row.Scan(&record.Key, &record.Fingerprint, &record.StatusCode, &header, &record.Body, &record.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			// key is released right after the reservation failed, treat it as still in progress
+			return &entity.IdempotencyRecord{Key: key, Fingerprint: fingerprint}, nil
+		}
+		return nil, err
+	}
+
+	if len(header) > 0 {
+		if err := json.Unmarshal(header, &record.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response of reserved key
+func (i *IdempotencyKey) Complete(ctx context.Context, record *entity.IdempotencyRecord) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE public.idempotency_keys SET status_code = $2, header = $3, body = $4 WHERE key = $1`
+	_, err = i.db.ExecContext(ctx, query, record.Key, record.StatusCode, header, record.Body)
+	return err
+}
+
+// Release deletes reserved key
+func (i *IdempotencyKey) Release(ctx context.Context, key string) error {
+	query := `DELETE FROM public.idempotency_keys WHERE key = $1`
+	_, err := i.db.ExecContext(ctx, query, key)
+	return err
+}
+
+// DeleteExpired deletes keys whose responses have expired, returning number of deleted keys
+func (i *IdempotencyKey) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM public.idempotency_keys WHERE expires_at <= NOW()`
+	res, err := i.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
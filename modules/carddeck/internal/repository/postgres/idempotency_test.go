@@ -0,0 +1,161 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyKeyTestSuite struct {
+	suite.Suite
+	dbmock sqlmock.Sqlmock
+	dbx    *sqlx.DB
+}
+
+func (s *IdempotencyKeyTestSuite) SetupSuite() {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(s.T(), err)
+	s.dbmock = dbmock
+	s.dbx = sqlx.NewDb(db, "sqlmock")
+}
+
+func TestIdempotencyKeyTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyKeyTestSuite))
+}
+
+func (s *IdempotencyKeyTestSuite) TestReserve() {
+	repo := postgres.NewIdempotencyKey(s.dbx)
+	reserveQuery := `INSERT INTO public.idempotency_keys (key, fingerprint, expires_at) VALUES ($1, $2, $3) ON CONFLICT (key) DO UPDATE`
+	selectQuery := `SELECT key, fingerprint, status_code, header, body, expires_at FROM public.idempotency_keys WHERE key = $1`
+	selectCols := []string{"key", "fingerprint", "status_code", "header", "body", "expires_at"}
+
+	s.Run("success - key reserved", func() {
+		rows := sqlmock.NewRows([]string{"key"}).AddRow("key-1")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(reserveQuery)).WithArgs("key-1", "fp", timeTemp, float64(60)).WillReturnRows(rows)
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+
+	s.Run("success - key reserved by request that died is taken over", func() {
+		takeOver := `WHERE idempotency_keys.expires_at <= NOW() OR (idempotency_keys.status_code = 0 AND idempotency_keys.created_at <= NOW() - $4 * INTERVAL '1 second') RETURNING key`
+		rows := sqlmock.NewRows([]string{"key"}).AddRow("key-1")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(takeOver)).WithArgs("key-1", "fp", timeTemp, float64(60)).WillReturnRows(rows)
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+
+	s.Run("success - key already used", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(reserveQuery)).WillReturnError(sql.ErrNoRows)
+		rows := sqlmock.NewRows(selectCols).AddRow("key-1", "fp", 201, []byte(`{"Etag":["\"1\""]}`), []byte("body"), timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectQuery)).WillReturnRows(rows)
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.IdempotencyRecord{
+			Key:         "key-1",
+			Fingerprint: "fp",
+			StatusCode:  201,
+			Header:      map[string][]string{"Etag": {`"1"`}},
+			Body:        []byte("body"),
+			ExpiresAt:   timeTemp,
+		}, record)
+	})
+
+	s.Run("success - key released in between", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(reserveQuery)).WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectQuery)).WillReturnError(sql.ErrNoRows)
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.IdempotencyRecord{Key: "key-1", Fingerprint: "fp"}, record)
+	})
+
+	s.Run("failed - reserve error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(reserveQuery)).WillReturnError(errors.New("some error"))
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+
+	s.Run("failed - select error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(reserveQuery)).WillReturnError(sql.ErrNoRows)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(selectQuery)).WillReturnError(errors.New("some error"))
+
+		record, err := repo.Reserve(context.Background(), "key-1", "fp", timeTemp, time.Minute)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), record)
+	})
+}
+
+func (s *IdempotencyKeyTestSuite) TestComplete() {
+	repo := postgres.NewIdempotencyKey(s.dbx)
+	query := `UPDATE public.idempotency_keys SET status_code = $2, header = $3, body = $4 WHERE key = $1`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs("key-1", 201, []byte(`{"Etag":["\"1\""]}`), []byte("body")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Complete(context.Background(), &entity.IdempotencyRecord{
+			Key:        "key-1",
+			StatusCode: 201,
+			Header:     map[string][]string{"Etag": {`"1"`}},
+			Body:       []byte("body"),
+		})
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - unknown error from repository", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		err := repo.Complete(context.Background(), &entity.IdempotencyRecord{Key: "key-1"})
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *IdempotencyKeyTestSuite) TestRelease() {
+	repo := postgres.NewIdempotencyKey(s.dbx)
+	query := `DELETE FROM public.idempotency_keys WHERE key = $1`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("key-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Release(context.Background(), "key-1")
+		assert.NoError(s.T(), err)
+	})
+}
+
+func (s *IdempotencyKeyTestSuite) TestDeleteExpired() {
+	repo := postgres.NewIdempotencyKey(s.dbx)
+	query := `DELETE FROM public.idempotency_keys WHERE expires_at <= NOW()`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 3))
+
+		deleted, err := repo.DeleteExpired(context.Background())
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3), deleted)
+	})
+
+	s.Run("failed - unknown error from repository", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		_, err := repo.DeleteExpired(context.Background())
+		assert.Error(s.T(), err)
+	})
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+	// listenerCatchUpLimit is maximum number of events published after reconnecting
+	listenerCatchUpLimit = 1000
+	// listenerLookBack is number of IDs before the last published event read again when catching up.
+	// IDs are assigned when events are inserted, not committed, so event committed late may have smaller ID
+	// than events already published.
+	listenerLookBack = 100
+)
+
+// NotificationConn defines connection receiving LISTEN/NOTIFY notifications, implemented by *pgx.Conn
+type NotificationConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// Dialer opens new connection used by Listener
+type Dialer func(ctx context.Context) (NotificationConn, error)
+
+// PgxDialer returns dialer connecting to the database using pgx
+func PgxDialer(connString string) Dialer {
+	return func(ctx context.Context) (NotificationConn, error) {
+		return pgx.Connect(ctx, connString)
+	}
+}
+
+// Listener receives ID of deck events inserted by any server instance (see WithNotify)
+// and publishes the events to in-process subscribers.
+type Listener struct {
+	dial    Dialer
+	events  *DeckEvent
+	publish func(event *entity.DeckEvent)
+	// lastID is the greatest ID published so far, events after it (and listenerLookBack before it) are published after reconnecting
+	lastID int64
+	// published contains recently published IDs, so events read again when catching up
+	// or notified after catching up are not published twice
+	published map[int64]struct{}
+}
+
+// NewListener returns new listener, publish is called for every received event in order of arrival
+func NewListener(dial Dialer, events *DeckEvent, publish func(event *entity.DeckEvent)) *Listener {
+	return &Listener{
+		dial:      dial,
+		events:    events,
+		publish:   publish,
+		published: make(map[int64]struct{}),
+	}
+}
+
+// Run listens until ctx is done, reconnecting with backoff whenever the connection is lost.
+// After reconnecting, events persisted while disconnected are published before new notifications.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := listenerMinBackoff
+	for {
+		connected, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = listenerMinBackoff
+		}
+		log.Error().Err(err).Dur("retry_in", backoff).Msg("[postgres listener] connection lost")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > listenerMaxBackoff {
+			backoff = listenerMaxBackoff
+		}
+	}
+}
+
+// listen subscribes to the channel and publishes events until the connection fails.
+// connected reports whether the subscription succeeded.
+func (l *Listener) listen(ctx context.Context) (connected bool, err error) {
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		// use background context, ctx might be done already
+		if err := conn.Close(context.Background()); err != nil {
+			log.Error().Err(err).Msg("[postgres listener] error closing connection")
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{DeckEventsChannel}.Sanitize()); err != nil {
+		return false, err
+	}
+	log.Info().Msg("[postgres listener] listening to deck events")
+
+	if l.lastID > 0 {
+		if err := l.catchUp(ctx); err != nil {
+			return true, err
+		}
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			log.Error().Err(err).Str("payload", notification.Payload).Msg("[postgres listener] invalid notification payload")
+			continue
+		}
+		if _, ok := l.published[id]; ok {
+			// already published while catching up
+			continue
+		}
+
+		event, err := l.events.GetByID(ctx, id)
+		if err != nil {
+			log.Error().Err(err).Int64("id", id).Msg("[postgres listener] error getting deck event")
+			continue
+		}
+		l.emit(event)
+	}
+}
+
+// catchUp publishes events persisted after the last published event,
+// including events of the look-back window not published yet because they were committed late.
+func (l *Listener) catchUp(ctx context.Context) error {
+	since := max(l.lastID-listenerLookBack, 0)
+	for id := range l.published {
+		if id <= since {
+			delete(l.published, id)
+		}
+	}
+
+	events, err := l.events.ListSince(ctx, since, listenerCatchUpLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if _, ok := l.published[event.ID]; ok {
+			continue
+		}
+		l.emit(event)
+	}
+	return nil
+}
+
+func (l *Listener) emit(event *entity.DeckEvent) {
+	if event.ID > l.lastID {
+		l.lastID = event.ID
+	}
+	l.published[event.ID] = struct{}{}
+	if len(l.published) > 2*listenerCatchUpLimit {
+		for id := range l.published {
+			if id <= l.lastID-listenerCatchUpLimit {
+				delete(l.published, id)
+			}
+		}
+	}
+	l.publish(event)
+}
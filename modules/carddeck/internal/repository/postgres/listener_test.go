@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn delivers queued notification payloads, then fails with err (or blocks until ctx is done when err is nil)
+type fakeConn struct {
+	listened      chan string
+	notifications []string
+	err           error
+}
+
+func (c *fakeConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	c.listened <- sql
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *fakeConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if len(c.notifications) > 0 {
+		payload := c.notifications[0]
+		c.notifications = c.notifications[1:]
+		return &pgconn.Notification{Channel: postgres.DeckEventsChannel, Payload: payload}, nil
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *fakeConn) Close(context.Context) error { return nil }
+
+func Test_Listener(t *testing.T) {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(t, err)
+	repo := postgres.NewDeckEvent(sqlx.NewDb(db, "sqlmock"))
+
+	getQuery := `SELECT id, payload FROM public.deck_events WHERE id = $1`
+	listSinceQuery := `SELECT id, payload FROM public.deck_events WHERE id > $1 ORDER BY id LIMIT $2`
+	payload := func(eventType string) []byte {
+		return []byte(`{"type":"` + eventType + `","deck_id":"deck-1"}`)
+	}
+	cols := []string{"id", "payload"}
+
+	listened := make(chan string, 2)
+	conns := []*fakeConn{
+		// first connection is lost after one notification
+		{listened: listened, notifications: []string{"5"}, err: errors.New("connection reset")},
+		// 6 is persisted while disconnected, its notification arrives after catching up.
+		// 4 is committed after 5 was published, it is only found by looking back.
+		{listened: listened, notifications: []string{"invalid", "6", "7"}},
+	}
+	dial := func(context.Context) (postgres.NotificationConn, error) {
+		conn := conns[0]
+		conns = conns[1:]
+		return conn, nil
+	}
+
+	dbmock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(5).WillReturnRows(sqlmock.NewRows(cols).AddRow(5, payload(entity.DeckEventDrawn)))
+	dbmock.ExpectQuery(regexp.QuoteMeta(listSinceQuery)).WithArgs(0, 1000).WillReturnRows(sqlmock.NewRows(cols).
+		AddRow(4, payload(entity.DeckEventReturned)).
+		AddRow(5, payload(entity.DeckEventDrawn)).
+		AddRow(6, payload(entity.DeckEventShuffled)))
+	dbmock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(7).WillReturnRows(sqlmock.NewRows(cols).AddRow(7, payload(entity.DeckEventReturned)))
+
+	published := make(chan *entity.DeckEvent, 5)
+	listener := postgres.NewListener(dial, repo, func(ev *entity.DeckEvent) { published <- ev })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		listener.Run(ctx)
+		close(done)
+	}()
+
+	for _, expected := range []struct {
+		id        int64
+		eventType string
+	}{{5, entity.DeckEventDrawn}, {4, entity.DeckEventReturned}, {6, entity.DeckEventShuffled}, {7, entity.DeckEventReturned}} {
+		select {
+		case ev := <-published:
+			assert.Equal(t, expected.id, ev.ID)
+			assert.Equal(t, expected.eventType, ev.Type)
+			assert.Equal(t, "deck-1", ev.DeckID)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d is not published", expected.id)
+		}
+	}
+
+	// every connection subscribes to the channel again
+	assert.Equal(t, `LISTEN "deck_events"`, <-listened)
+	assert.Equal(t, `LISTEN "deck_events"`, <-listened)
+
+	cancel()
+	<-done
+	// 5 is not published again
+	assert.Empty(t, published)
+	assert.NoError(t, dbmock.ExpectationsWereMet())
+}
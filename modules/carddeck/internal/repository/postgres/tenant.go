@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// Tenant defines repository for tenants
+type Tenant struct {
+	db *sqlx.DB
+}
+
+// NewTenant returns new tenant repository
+func NewTenant(db *sqlx.DB) *Tenant {
+	return &Tenant{db: db}
+}
+
+// Insert persists the tenant and assigns its ID
+func (t *Tenant) Insert(ctx context.Context, tenant *entity.Tenant) (*entity.Tenant, error) {
+	query := `INSERT INTO public.tenants (name, max_active_decks, max_requests_per_minute) VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	row := conn(ctx, t.db).QueryRowxContext(ctx, query, tenant.Name, tenant.MaxActiveDecks, tenant.MaxRequestsPerMinute)
+	if err := row.Scan(&tenant.ID, &tenant.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// GetByID returns tenant by its ID
+func (t *Tenant) GetByID(ctx context.Context, id string) (*entity.Tenant, error) {
+	query := `SELECT id, name, max_active_decks, max_requests_per_minute, created_at FROM public.tenants WHERE id = $1`
+
+	var tenant entity.Tenant
+	if err := conn(ctx, t.db).QueryRowxContext(ctx, query, id).StructScan(&tenant); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.NewError(entity.ErrTenantNotFound, entity.ErrMsgTenantNotFound)
+		}
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+// UpdateQuota updates quota overrides of the tenant, nil restores default quota of the server
+func (t *Tenant) UpdateQuota(ctx context.Context, tenant *entity.Tenant) error {
+	query := `UPDATE public.tenants SET max_active_decks = $2, max_requests_per_minute = $3 WHERE id = $1`
+
+	res, err := conn(ctx, t.db).ExecContext(ctx, query, tenant.ID, tenant.MaxActiveDecks, tenant.MaxRequestsPerMinute)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.NewError(entity.ErrTenantNotFound, entity.ErrMsgTenantNotFound)
+	}
+
+	return nil
+}
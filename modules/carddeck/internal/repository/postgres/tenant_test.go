@@ -0,0 +1,103 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TenantTestSuite struct {
+	suite.Suite
+	dbmock sqlmock.Sqlmock
+	dbx    *sqlx.DB
+}
+
+func (s *TenantTestSuite) SetupSuite() {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(s.T(), err)
+	s.dbmock = dbmock
+	s.dbx = sqlx.NewDb(db, "sqlmock")
+}
+
+func TestTenantTestSuite(t *testing.T) {
+	suite.Run(t, new(TenantTestSuite))
+}
+
+func (s *TenantTestSuite) TestInsert() {
+	repo := postgres.NewTenant(s.dbx)
+	query := `INSERT INTO public.tenants (name, max_active_decks, max_requests_per_minute) VALUES ($1, $2, $3) RETURNING id, created_at`
+	maxActiveDecks := 10
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow("tenant-1", timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("studio", &maxActiveDecks, nil).WillReturnRows(rows)
+
+		tenant, err := repo.Insert(context.Background(), &entity.Tenant{Name: "studio", MaxActiveDecks: &maxActiveDecks})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.Tenant{ID: "tenant-1", Name: "studio", MaxActiveDecks: &maxActiveDecks, CreatedAt: timeTemp}, tenant)
+	})
+
+	s.Run("failed - insert error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		tenant, err := repo.Insert(context.Background(), &entity.Tenant{Name: "studio"})
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), tenant)
+	})
+}
+
+func (s *TenantTestSuite) TestGetByID() {
+	repo := postgres.NewTenant(s.dbx)
+	query := `SELECT id, name, max_active_decks, max_requests_per_minute, created_at FROM public.tenants WHERE id = $1`
+	cols := []string{"id", "name", "max_active_decks", "max_requests_per_minute", "created_at"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow("tenant-1", "studio", nil, 600, timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("tenant-1").WillReturnRows(rows)
+
+		tenant, err := repo.GetByID(context.Background(), "tenant-1")
+		assert.NoError(s.T(), err)
+		assert.Nil(s.T(), tenant.MaxActiveDecks)
+		assert.Equal(s.T(), 600, *tenant.MaxRequestsPerMinute)
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrNoRows)
+
+		tenant, err := repo.GetByID(context.Background(), "tenant-1")
+		assert.Nil(s.T(), tenant)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrTenantNotFound, perr.Code)
+	})
+}
+
+func (s *TenantTestSuite) TestUpdateQuota() {
+	repo := postgres.NewTenant(s.dbx)
+	query := `UPDATE public.tenants SET max_active_decks = $2, max_requests_per_minute = $3 WHERE id = $1`
+	maxRequestsPerMinute := 60
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("tenant-1", nil, &maxRequestsPerMinute).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(s.T(), repo.UpdateQuota(context.Background(), &entity.Tenant{ID: "tenant-1", MaxRequestsPerMinute: &maxRequestsPerMinute}))
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		perr, ok := repo.UpdateQuota(context.Background(), &entity.Tenant{ID: "tenant-1"}).(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrTenantNotFound, perr.Code)
+	})
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// Webhook defines repository for webhooks and their outbox of deliveries
+type Webhook struct {
+	db *sqlx.DB
+}
+
+// NewWebhook returns new webhook repository
+func NewWebhook(db *sqlx.DB) *Webhook {
+	return &Webhook{db: db}
+}
+
+// Insert persists the webhook and assigns its ID, the webhook belongs to the tenant carried by ctx
+func (w *Webhook) Insert(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	query := `INSERT INTO public.webhooks (url, events, secret, tenant_id) VALUES ($1, $2, $3, $4) RETURNING id, url, events, secret, created_at`
+
+	row := conn(ctx, w.db).QueryRowxContext(ctx, query, webhook.URL, webhook.Events, webhook.Secret, tenantArg(ctx))
+	if err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Events, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetByID returns webhook of the tenant carried by ctx by its ID
+func (w *Webhook) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	query := `SELECT id, url, events, secret, created_at FROM public.webhooks WHERE id = $1 AND tenant_id IS NOT DISTINCT FROM $2`
+
+	var webhook entity.Webhook
+	row := conn(ctx, w.db).QueryRowxContext(ctx, query, id, tenantArg(ctx))
+	if err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Events, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound)
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// Delete deletes the webhook of the tenant carried by ctx together with its deliveries
+func (w *Webhook) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM public.webhooks WHERE id = $1 AND tenant_id IS NOT DISTINCT FROM $2`
+
+	res, err := conn(ctx, w.db).ExecContext(ctx, query, id, tenantArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound)
+	}
+
+	return nil
+}
+
+// Enqueue inserts a pending delivery of the payload for every webhook of the tenant carried by ctx subscribed to the event.
+// Called inside the transaction of the deck change, so deliveries exist only if the change is committed.
+func (w *Webhook) Enqueue(ctx context.Context, eventType string, payload []byte) error {
+	query := `INSERT INTO public.webhook_deliveries (webhook_id, event_type, payload) ` +
+		`SELECT id, $1::text, $2::jsonb FROM public.webhooks WHERE events ? $1::text AND tenant_id IS NOT DISTINCT FROM $3`
+
+	_, err := conn(ctx, w.db).ExecContext(ctx, query, eventType, payload, tenantArg(ctx))
+	return err
+}
+
+// ListDeadLetters returns deliveries of the webhook that failed every attempt, ordered by ID
+func (w *Webhook) ListDeadLetters(ctx context.Context, webhookID string) ([]*entity.WebhookDelivery, error) {
+	query := `SELECT id, webhook_id, event_type, payload, attempts, last_status_code, last_error, created_at, updated_at ` +
+		`FROM public.webhook_dead_letters WHERE webhook_id = $1 ORDER BY id`
+
+	rows, err := conn(ctx, w.db).QueryxContext(ctx, query, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*entity.WebhookDelivery{}
+	for rows.Next() {
+		delivery := &entity.WebhookDelivery{Status: entity.WebhookDeliveryDead}
+		if err := rows.StructScan(delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDue returns at most limit pending deliveries whose next attempt is due, together with URL and secret of their webhook.
+// Claimed deliveries are postponed by lease, so other dispatchers skip them,
+// and they are retried after the lease if the dispatcher stops before marking them.
+func (w *Webhook) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error) {
+	query := `UPDATE public.webhook_deliveries d SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond', updated_at = NOW() ` +
+		`FROM public.webhooks w WHERE d.webhook_id = w.id AND d.id IN (` +
+		`SELECT id FROM public.webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW() ` +
+		`ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED) ` +
+		`RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.last_status_code, d.last_error, d.created_at, d.updated_at, w.url, w.secret`
+
+	rows, err := conn(ctx, w.db).QueryxContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*entity.WebhookDelivery{}
+	for rows.Next() {
+		var delivery entity.WebhookDelivery
+		if err := rows.StructScan(&delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// MarkDelivered marks the delivery as delivered
+func (w *Webhook) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	query := `UPDATE public.webhook_deliveries SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = '', updated_at = NOW() WHERE id = $1`
+
+	_, err := conn(ctx, w.db).ExecContext(ctx, query, id, entity.WebhookDeliveryDelivered, statusCode)
+	return err
+}
+
+// MarkFailed records the failed attempt, the delivery is retried after retryIn unless status is WebhookDeliveryDead
+func (w *Webhook) MarkFailed(ctx context.Context, id int64, status string, statusCode int, lastError string, retryIn time.Duration) error {
+	query := `UPDATE public.webhook_deliveries SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4, ` +
+		`next_attempt_at = NOW() + $5 * INTERVAL '1 millisecond', updated_at = NOW() WHERE id = $1`
+
+	_, err := conn(ctx, w.db).ExecContext(ctx, query, id, status, statusCode, lastError, retryIn.Milliseconds())
+	return err
+}
@@ -0,0 +1,227 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookTestSuite struct {
+	suite.Suite
+	dbmock sqlmock.Sqlmock
+	dbx    *sqlx.DB
+}
+
+func (s *WebhookTestSuite) SetupSuite() {
+	db, dbmock, err := sqlmock.New()
+	assert.NoError(s.T(), err)
+	s.dbmock = dbmock
+	s.dbx = sqlx.NewDb(db, "sqlmock")
+}
+
+func TestWebhookTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookTestSuite))
+}
+
+func (s *WebhookTestSuite) TestInsert() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `INSERT INTO public.webhooks (url, events, secret, tenant_id) VALUES ($1, $2, $3, $4) RETURNING id, url, events, secret, created_at`
+	cols := []string{"id", "url", "events", "secret", "created_at"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow("webhook-1", "https://example.com/hook", []byte(`["deck.created"]`), "0123456789abcdef", timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("https://example.com/hook", sqlmock.AnyArg(), "0123456789abcdef", "tenant-1").WillReturnRows(rows)
+
+		webhook, err := repo.Insert(entity.WithTenant(context.Background(), "tenant-1"), &entity.Webhook{
+			URL:    "https://example.com/hook",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated},
+			Secret: "0123456789abcdef",
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &entity.Webhook{
+			ID:        "webhook-1",
+			URL:       "https://example.com/hook",
+			Events:    entity.WebhookEventList{entity.WebhookEventDeckCreated},
+			Secret:    "0123456789abcdef",
+			CreatedAt: timeTemp,
+		}, webhook)
+	})
+
+	s.Run("failed - insert error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		webhook, err := repo.Insert(context.Background(), &entity.Webhook{})
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), webhook)
+	})
+}
+
+func (s *WebhookTestSuite) TestGetByID() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `SELECT id, url, events, secret, created_at FROM public.webhooks WHERE id = $1 AND tenant_id IS NOT DISTINCT FROM $2`
+	cols := []string{"id", "url", "events", "secret", "created_at"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow("webhook-1", "https://example.com/hook", []byte(`["deck.deleted"]`), "0123456789abcdef", timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("webhook-1", nil).WillReturnRows(rows)
+
+		webhook, err := repo.GetByID(context.Background(), "webhook-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), entity.WebhookEventList{entity.WebhookEventDeckDeleted}, webhook.Events)
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows(cols))
+
+		webhook, err := repo.GetByID(context.Background(), "webhook-1")
+		assert.Nil(s.T(), webhook)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrWebhookNotFound, perr.Code)
+	})
+}
+
+func (s *WebhookTestSuite) TestDelete() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `DELETE FROM public.webhooks WHERE id = $1 AND tenant_id IS NOT DISTINCT FROM $2`
+
+	s.Run("success", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("webhook-1", "tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(s.T(), repo.Delete(entity.WithTenant(context.Background(), "tenant-1"), "webhook-1"))
+	})
+
+	s.Run("failed - not found", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		perr, ok := repo.Delete(context.Background(), "webhook-1").(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrWebhookNotFound, perr.Code)
+	})
+}
+
+func (s *WebhookTestSuite) TestEnqueue() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `INSERT INTO public.webhook_deliveries (webhook_id, event_type, payload) SELECT id, $1::text, $2::jsonb FROM public.webhooks WHERE events ? $1::text AND tenant_id IS NOT DISTINCT FROM $3`
+	payload := []byte(`{"type":"deck.created"}`)
+
+	s.Run("success - joins transaction in context", func() {
+		s.dbmock.ExpectBegin()
+		s.dbmock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('carddeck.tenant_id', $1, true)`)).WithArgs("tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(entity.WebhookEventDeckCreated, payload, "tenant-1").WillReturnResult(sqlmock.NewResult(0, 2))
+		s.dbmock.ExpectCommit()
+
+		err := postgres.NewDeck(s.dbx).Transaction(entity.WithTenant(context.Background(), "tenant-1"), func(ctx context.Context) error {
+			return repo.Enqueue(ctx, entity.WebhookEventDeckCreated, payload)
+		})
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), s.dbmock.ExpectationsWereMet())
+	})
+
+	s.Run("failed - exec error", func() {
+		s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		assert.Error(s.T(), repo.Enqueue(context.Background(), entity.WebhookEventDeckCreated, payload))
+	})
+}
+
+func (s *WebhookTestSuite) TestListDeadLetters() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `SELECT id, webhook_id, event_type, payload, attempts, last_status_code, last_error, created_at, updated_at FROM public.webhook_dead_letters WHERE webhook_id = $1 ORDER BY id`
+	cols := []string{"id", "webhook_id", "event_type", "payload", "attempts", "last_status_code", "last_error", "created_at", "updated_at"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow(3, "webhook-1", entity.WebhookEventDeckDeleted, []byte(`{"type":"deck.deleted"}`), 8, 500, "unexpected status code 500", timeTemp, timeTemp)
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("webhook-1").WillReturnRows(rows)
+
+		deliveries, err := repo.ListDeadLetters(context.Background(), "webhook-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []*entity.WebhookDelivery{{
+			ID:             3,
+			WebhookID:      "webhook-1",
+			EventType:      entity.WebhookEventDeckDeleted,
+			Payload:        json.RawMessage(`{"type":"deck.deleted"}`),
+			Status:         entity.WebhookDeliveryDead,
+			Attempts:       8,
+			LastStatusCode: 500,
+			LastError:      "unexpected status code 500",
+			CreatedAt:      timeTemp,
+			UpdatedAt:      timeTemp,
+		}}, deliveries)
+	})
+
+	s.Run("success - no dead letters", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(sqlmock.NewRows(cols))
+
+		deliveries, err := repo.ListDeadLetters(context.Background(), "webhook-1")
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), deliveries)
+	})
+
+	s.Run("failed - query error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		deliveries, err := repo.ListDeadLetters(context.Background(), "webhook-1")
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), deliveries)
+	})
+}
+
+func (s *WebhookTestSuite) TestClaimDue() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `UPDATE public.webhook_deliveries d SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond', updated_at = NOW() ` +
+		`FROM public.webhooks w WHERE d.webhook_id = w.id AND d.id IN (` +
+		`SELECT id FROM public.webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW() ` +
+		`ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED) ` +
+		`RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.last_status_code, d.last_error, d.created_at, d.updated_at, w.url, w.secret`
+	cols := []string{"id", "webhook_id", "event_type", "payload", "status", "attempts", "last_status_code", "last_error", "created_at", "updated_at", "url", "secret"}
+
+	s.Run("success", func() {
+		rows := sqlmock.NewRows(cols).AddRow(3, "webhook-1", entity.WebhookEventDeckCreated, []byte(`{}`), entity.WebhookDeliveryPending, 1, 503, "", timeTemp, timeTemp, "https://example.com/hook", "0123456789abcdef")
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(10, int64(60000)).WillReturnRows(rows)
+
+		deliveries, err := repo.ClaimDue(context.Background(), 10, time.Minute)
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), deliveries, 1)
+		assert.Equal(s.T(), int64(3), deliveries[0].ID)
+		assert.Equal(s.T(), 1, deliveries[0].Attempts)
+		assert.Equal(s.T(), "https://example.com/hook", deliveries[0].URL)
+		assert.Equal(s.T(), "0123456789abcdef", deliveries[0].Secret)
+	})
+
+	s.Run("failed - query error", func() {
+		s.dbmock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("some error"))
+
+		deliveries, err := repo.ClaimDue(context.Background(), 10, time.Minute)
+		assert.Error(s.T(), err)
+		assert.Nil(s.T(), deliveries)
+	})
+}
+
+func (s *WebhookTestSuite) TestMarkDelivered() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `UPDATE public.webhook_deliveries SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = '', updated_at = NOW() WHERE id = $1`
+
+	s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(3, entity.WebhookDeliveryDelivered, 204).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(s.T(), repo.MarkDelivered(context.Background(), 3, 204))
+}
+
+func (s *WebhookTestSuite) TestMarkFailed() {
+	repo := postgres.NewWebhook(s.dbx)
+	query := `UPDATE public.webhook_deliveries SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4, ` +
+		`next_attempt_at = NOW() + $5 * INTERVAL '1 millisecond', updated_at = NOW() WHERE id = $1`
+
+	s.dbmock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(3, entity.WebhookDeliveryPending, 500, "unexpected status code 500", int64(30000)).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(s.T(), repo.MarkFailed(context.Background(), 3, entity.WebhookDeliveryPending, 500, "unexpected status code 500", 30*time.Second))
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/i18n"
+)
+
+const (
+	renderUnicode = "unicode"
+	renderShort   = "short"
+	renderASCII   = "ascii"
+)
+
+// cardRenderers renders card according to render parameter
+var cardRenderers = map[string]func(entity.Card) string{
+	renderUnicode: entity.Card.Glyph,
+	renderShort:   entity.Card.Short,
+	renderASCII:   entity.Card.ASCIIArt,
+}
+
+// cardPresenter adds display fields to cards of the response
+type cardPresenter struct {
+	localizer *i18n.Localizer
+	render    func(entity.Card) string
+}
+
+// newCardPresenter creates card presenter from Accept-Language header and render parameter
+func newCardPresenter(r *http.Request) (*cardPresenter, error) {
+	p := &cardPresenter{localizer: i18n.NewLocalizer(r.Header.Get("Accept-Language"))}
+
+	if render := r.URL.Query().Get("render"); render != "" {
+		var ok bool
+		if p.render, ok = cardRenderers[render]; !ok {
+			err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+			err.AddDetail(entity.NewErrorDetail("render", "render parameter must be unicode, short or ascii"))
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// setHeaders sets headers telling caches the response depends on Accept-Language
+func (p *cardPresenter) setHeaders(w http.ResponseWriter) {
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", p.localizer.Language().String())
+}
+
+func (p *cardPresenter) cards(cards *entity.Cards) []*CardResponse {
+	if cards == nil {
+		return nil
+	}
+
+	resp := make([]*CardResponse, len(*cards))
+	for i, card := range *cards {
+		resp[i] = &CardResponse{
+			Card:  card,
+			Name:  p.localizer.CardName(*card),
+			Image: card.ImagePath(),
+		}
+		if p.render != nil {
+			resp[i].Rendered = p.render(*card)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,269 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	contentTypeMsgpack  = "application/msgpack"
+	contentTypeCBOR     = "application/cbor"
+	contentTypeProtobuf = "application/protobuf"
+)
+
+// codec encodes and decodes bodies of a media type
+type codec struct {
+	// mediaTypes lists accepted media types, the first one is sent as Content-Type
+	mediaTypes []string
+	// extension is file name extension of downloaded bodies
+	extension string
+	marshal   func(v any) ([]byte, error)
+	// unmarshal is nil when request bodies of the media type are not supported
+	unmarshal func(data []byte, v any) error
+	// supports reports whether v can be encoded, nil when every value can
+	supports func(v any) bool
+}
+
+var (
+	jsonCodec = &codec{
+		mediaTypes: []string{contentTypeJSON},
+		extension:  "json",
+		marshal:    json.Marshal,
+		unmarshal:  json.Unmarshal,
+	}
+
+	// codecs lists supported codecs in order of preference when client accepts several of them equally
+	codecs = []*codec{
+		jsonCodec,
+		{
+			mediaTypes: []string{contentTypeMsgpack, "application/x-msgpack", "application/vnd.msgpack"},
+			extension:  "msgpack",
+			marshal:    marshalMsgpack,
+			unmarshal:  unmarshalMsgpack,
+		},
+		{
+			mediaTypes: []string{contentTypeCBOR},
+			extension:  "cbor",
+			marshal:    cborEncMode.Marshal,
+			unmarshal:  cbor.Unmarshal,
+		},
+		{
+			mediaTypes: []string{contentTypeProtobuf, "application/x-protobuf", "application/vnd.google.protobuf"},
+			extension:  "binpb",
+			marshal:    marshalProtobuf,
+			supports: func(v any) bool {
+				_, ok := toProto(v)
+				return ok
+			},
+		},
+	}
+
+	// cborEncMode encodes time as RFC 3339 string, the same as JSON
+	cborEncMode = func() cbor.EncMode {
+		mode, err := cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+		if err != nil {
+			panic(err)
+		}
+		return mode
+	}()
+
+	errProtobufUnsupported = errors.New("response has no protobuf message")
+)
+
+// writeResponse writes v with the status, encoded into the most preferred media type of Accept header.
+// Falls back to JSON when no supported media type is accepted or v cannot be encoded into the accepted one.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v any) {
+	c, _ := negotiateCodec(r, v)
+	body, err := c.marshal(v)
+	if err != nil {
+		log.Error().Err(err).Str("path", r.URL.Path).Str("content_type", c.mediaTypes[0]).Msg("[rest] error encoding response")
+		handleError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", c.mediaTypes[0])
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Str("path", r.URL.Path).Msg("[rest] error writing response")
+	}
+}
+
+// decodeRequest decodes request body into v according to Content-Type header, JSON when it is not supplied.
+// Bodies larger than maxSize bytes are not read, *http.MaxBytesError is returned instead.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any, maxSize int64) error {
+	c := jsonCodec
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return fmt.Errorf("parsing content type: %w", err)
+		}
+		if c = findCodec(mediaType); c == nil || c.unmarshal == nil {
+			return fmt.Errorf("unsupported content type %s", mediaType)
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+	return c.unmarshal(body, v)
+}
+
+// bodyError returns error response of request body that could not be decoded by decodeRequest,
+// message describes the expected body
+func bodyError(err error, message string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return entity.NewError(entity.ErrRequestTooLarge, entity.ErrMsgRequestTooLarge)
+	}
+
+	paramErr := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+	paramErr.AddDetail(entity.NewErrorDetail("body", message))
+	return paramErr
+}
+
+// negotiateCodec returns codec able to encode v whose media type has the highest quality in Accept header, together with the quality.
+// Wildcard media ranges are ignored, hence JSON is returned with quality 0 when no supported media type is listed.
+func negotiateCodec(r *http.Request, v any) (*codec, float64) {
+	accepted := acceptedMediaTypes(r)
+
+	best, bestQ := jsonCodec, 0.0
+	for _, c := range codecs {
+		if c.supports != nil && !c.supports(v) {
+			continue
+		}
+		for _, mediaType := range c.mediaTypes {
+			if q := accepted[mediaType]; q > bestQ {
+				best, bestQ = c, q
+			}
+		}
+	}
+	return best, bestQ
+}
+
+func findCodec(mediaType string) *codec {
+	for _, c := range codecs {
+		for _, t := range c.mediaTypes {
+			if t == mediaType {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// acceptedMediaTypes returns the highest quality of every media type listed by Accept header
+func acceptedMediaTypes(r *http.Request) map[string]float64 {
+	accepted := map[string]float64{}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			accepted[mediaType] = max(accepted[mediaType], q)
+		}
+	}
+	return accepted
+}
+
+// marshalMsgpack encodes v using json tags, so every media type has the same field names
+func marshalMsgpack(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalMsgpack(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func marshalProtobuf(v any) ([]byte, error) {
+	m, ok := toProto(v)
+	if !ok {
+		return nil, errProtobufUnsupported
+	}
+	return proto.Marshal(m)
+}
+
+// toProto converts response into carddeck.v1 message, see modules/carddeck/carddeckpb
+func toProto(v any) (proto.Message, bool) {
+	switch resp := v.(type) {
+	case *CreateDeckResponse:
+		return &carddeckpb.CreateDeckResponse{
+			Id:        resp.ID,
+			Shuffled:  resp.Shuffled,
+			Remaining: resp.Remaining,
+		}, true
+	case *CompactDeckResponse:
+		return &carddeckpb.CompactDeck{
+			Id:        resp.ID,
+			Shuffled:  resp.Shuffled,
+			Remaining: resp.Remaining,
+			Compact:   resp.Compact,
+		}, true
+	case *DeckResponse:
+		return &carddeckpb.Deck{
+			Id:        resp.ID,
+			Shuffled:  resp.Shuffled,
+			Remaining: resp.Remaining,
+			Cards:     toProtoCards(resp.Cards),
+			CreatedAt: timestamppb.New(resp.CreatedAt),
+			UpdatedAt: timestamppb.New(resp.UpdatedAt),
+		}, true
+	case *DrawCardResponse:
+		return &carddeckpb.DrawCardsResponse{Cards: toProtoCards(resp.Cards)}, true
+	case *entity.Error:
+		details := make([]*carddeckpb.ErrorDetail, len(resp.Details))
+		for i, detail := range resp.Details {
+			details[i] = &carddeckpb.ErrorDetail{Field: detail.Field, Message: detail.Message}
+		}
+		return &carddeckpb.Error{Code: resp.Code, Message: resp.Message, ErrorDetails: details}, true
+	default:
+		return nil, false
+	}
+}
+
+func toProtoCards(cards []*CardResponse) []*carddeckpb.Card {
+	res := make([]*carddeckpb.Card, len(cards))
+	for i, card := range cards {
+		res[i] = &carddeckpb.Card{
+			Value:    card.Val,
+			Suit:     card.Suit,
+			Code:     card.Code,
+			Name:     card.Name,
+			Image:    card.Image,
+			Rendered: card.Rendered,
+		}
+	}
+	return res
+}
@@ -0,0 +1,247 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/carddeckpb"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// decodeMsgpack decodes MessagePack body using json tags, the same as the server
+func decodeMsgpack(body io.Reader, v any) error {
+	dec := msgpack.NewDecoder(body)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (s *HandlerTestSuite) TestContentNegotiation() {
+	getDeck := func(accept string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(defaultDeck, nil)
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+		return w.Result()
+	}
+
+	drawCards := func(accept string) *http.Response {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/some-uuid-abc-def/cards?count=2", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DrawCards(r.Context(), defaultDeck.ID, int64(2), int64(0)).Return(&defaultCards, defaultDeck, nil)
+
+		rest.NewHandler(s.svc).DrawCards(w, r)
+		return w.Result()
+	}
+
+	s.Run("success - json by default", func() {
+		for _, accept := range []string{"", "*/*", "application/*", "text/html"} {
+			response := getDeck(accept)
+
+			assert.Equal(s.T(), http.StatusOK, response.StatusCode, accept)
+			assert.Equal(s.T(), "application/json", response.Header.Get("Content-Type"), accept)
+			assert.Contains(s.T(), response.Header.Values("Vary"), "Accept", accept)
+		}
+	})
+
+	s.Run("success - msgpack", func() {
+		response := getDeck("application/msgpack")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "application/msgpack", response.Header.Get("Content-Type"))
+
+		var resp map[string]any
+		require.NoError(s.T(), decodeMsgpack(response.Body, &resp))
+		assert.Equal(s.T(), defaultDeck.ID, resp["id"])
+		assert.EqualValues(s.T(), 3, resp["remaining"])
+		assert.True(s.T(), defaultTime.Equal(resp["created_at"].(time.Time)))
+		cards := resp["cards"].([]any)
+		assert.Len(s.T(), cards, 3)
+		assert.Equal(s.T(), "Ace of Spades", cards[0].(map[string]any)["name"])
+	})
+
+	s.Run("success - cbor preferred by quality", func() {
+		response := drawCards("application/json;q=0.5, application/cbor")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "application/cbor", response.Header.Get("Content-Type"))
+
+		var resp rest.DrawCardResponse
+		require.NoError(s.T(), cbor.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), defaultDrawCardResponse, resp)
+	})
+
+	s.Run("success - protobuf", func() {
+		response := drawCards("application/x-protobuf")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "application/protobuf", response.Header.Get("Content-Type"))
+
+		raw, err := io.ReadAll(response.Body)
+		require.NoError(s.T(), err)
+		var resp carddeckpb.DrawCardsResponse
+		require.NoError(s.T(), proto.Unmarshal(raw, &resp))
+		require.Len(s.T(), resp.GetCards(), 2)
+		assert.Equal(s.T(), "AS", resp.GetCards()[0].GetCode())
+		assert.Equal(s.T(), "Ace of Spades", resp.GetCards()[0].GetName())
+		assert.Equal(s.T(), "/cards/AS.svg", resp.GetCards()[0].GetImage())
+	})
+
+	s.Run("success - protobuf deck", func() {
+		response := getDeck("application/protobuf")
+
+		raw, err := io.ReadAll(response.Body)
+		require.NoError(s.T(), err)
+		var resp carddeckpb.Deck
+		require.NoError(s.T(), proto.Unmarshal(raw, &resp))
+		assert.Equal(s.T(), defaultDeck.ID, resp.GetId())
+		assert.Equal(s.T(), int64(3), resp.GetRemaining())
+		assert.Len(s.T(), resp.GetCards(), 3)
+		assert.Equal(s.T(), defaultTime, resp.GetCreatedAt().AsTime())
+	})
+
+	s.Run("success - error in accepted media type", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", "application/problem+json;q=0.5, application/protobuf")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+		assert.Equal(s.T(), "application/protobuf", response.Header.Get("Content-Type"))
+
+		var resp carddeckpb.Error
+		require.NoError(s.T(), proto.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(s.T(), entity.ErrDeckNotFound, resp.GetCode())
+		assert.Equal(s.T(), entity.ErrMsgDeckNotFound, resp.GetMessage())
+	})
+
+	s.Run("success - response without protobuf message falls back to json", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def/export", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", "application/protobuf")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ExportDeck(r.Context(), defaultDeck.ID).Return(&entity.DeckExport{Version: 1, CardSet: entity.StandardCardSetID}, nil)
+
+		rest.NewHandler(s.svc).ExportDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "application/json", response.Header.Get("Content-Type"))
+		assert.Equal(s.T(), `attachment; filename="deck-some-uuid-abc-def.json"`, response.Header.Get("Content-Disposition"))
+	})
+
+	s.Run("success - exported document file name follows media type", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def/export", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", "application/msgpack")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ExportDeck(r.Context(), defaultDeck.ID).Return(&entity.DeckExport{Version: 1, CardSet: entity.StandardCardSetID}, nil)
+
+		rest.NewHandler(s.svc).ExportDeck(w, r)
+
+		assert.Equal(s.T(), "application/msgpack", w.Result().Header.Get("Content-Type"))
+		assert.Equal(s.T(), `attachment; filename="deck-some-uuid-abc-def.msgpack"`, w.Result().Header.Get("Content-Disposition"))
+	})
+
+	s.Run("success - request body and response in msgpack", func() {
+		body, err := msgpack.Marshal(map[string]any{
+			"operations": []map[string]any{{"op": "create"}, {"op": "draw", "deck_id": "$0", "count": 2}},
+		})
+		require.NoError(s.T(), err)
+
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/msgpack")
+		r.Header.Set("Accept", "application/msgpack")
+		w := httptest.NewRecorder()
+
+		results := []*entity.BatchResult{
+			{Op: entity.BatchOperationCreate, Status: entity.BatchStatusOK, Deck: defaultDeck},
+			{Op: entity.BatchOperationDraw, Status: entity.BatchStatusOK, Cards: &defaultCards},
+		}
+		s.svc.EXPECT().Batch(r.Context(), []*entity.BatchOperation{
+			{Op: entity.BatchOperationCreate},
+			{Op: entity.BatchOperationDraw, DeckID: "$0", Count: 2},
+		}).Return(results, nil)
+
+		rest.NewHandler(s.svc).Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "application/msgpack", response.Header.Get("Content-Type"))
+
+		var resp map[string]any
+		require.NoError(s.T(), decodeMsgpack(response.Body, &resp))
+		first := resp["results"].([]any)[0].(map[string]any)
+		assert.EqualValues(s.T(), 3, first["deck"].(map[string]any)["remaining"])
+	})
+
+	s.Run("failed - request body media type unsupported", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(`{"operations":[]}`))
+		r.Header.Set("Content-Type", "application/protobuf")
+		w := httptest.NewRecorder()
+
+		rest.NewHandler(s.svc).Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+
+		var resp entity.Error
+		require.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), "body", resp.Details[0].Field)
+	})
+
+	s.Run("success - webhook in cbor", func() {
+		body, err := cbor.Marshal(map[string]any{"url": "https://example.com/hook", "events": []string{"deck.created"}, "secret": "0123456789abcdef"})
+		require.NoError(s.T(), err)
+
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/webhooks", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/cbor")
+		r.Header.Set("Accept", "application/cbor")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().CreateWebhook(gomock.Any(), &entity.Webhook{
+			URL:    "https://example.com/hook",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated},
+			Secret: "0123456789abcdef",
+		}).DoAndReturn(func(_ any, webhook *entity.Webhook) (*entity.Webhook, error) {
+			webhook.ID = "webhook-1"
+			return webhook, nil
+		})
+
+		rest.NewHandler(s.svc).CreateWebhook(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+		assert.Equal(s.T(), "application/cbor", response.Header.Get("Content-Type"))
+
+		var resp map[string]any
+		require.NoError(s.T(), cbor.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), "webhook-1", resp["id"])
+		assert.NotContains(s.T(), resp, "secret")
+	})
+}
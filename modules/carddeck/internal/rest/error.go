@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/i18n"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeProblem = "application/problem+json"
+
+	// problemTypePrefix prefixes error code into problem type, a URI reference relative to the API
+	problemTypePrefix = "/problems/"
+)
+
+// errorMapping defines how entity.Error code is served over HTTP
+type errorMapping struct {
+	status int
+	title  string
+}
+
+// errorMappings maps entity.Error codes into HTTP status and problem title, unknown codes are internal errors
+var errorMappings = map[string]errorMapping{
+	entity.ErrParamInvalid:             {http.StatusBadRequest, entity.ErrMsgParamInvalid},
+	entity.ErrInternal:                 {http.StatusInternalServerError, entity.ErrMsgInternal},
+	entity.ErrIdempotencyKeyConflict:   {http.StatusUnprocessableEntity, entity.ErrMsgIdempotencyKeyConflict},
+	entity.ErrIdempotencyKeyInProgress: {http.StatusConflict, entity.ErrMsgIdempotencyKeyInProgress},
+	entity.ErrRequestTooLarge:          {http.StatusRequestEntityTooLarge, entity.ErrMsgRequestTooLarge},
+	entity.ErrAPIKeyRequired:           {http.StatusUnauthorized, entity.ErrMsgAPIKeyRequired},
+	entity.ErrAPIKeyInvalid:            {http.StatusUnauthorized, entity.ErrMsgAPIKeyInvalid},
+	entity.ErrTokenInvalid:             {http.StatusUnauthorized, entity.ErrMsgTokenInvalid},
+	entity.ErrTokenRequired:            {http.StatusUnauthorized, entity.ErrMsgTokenRequired},
+	entity.ErrScopeInsufficient:        {http.StatusForbidden, entity.ErrMsgScopeInsufficient},
+	entity.ErrRequestQuotaExceeded:     {http.StatusTooManyRequests, entity.ErrMsgRequestQuotaExceeded},
+	entity.ErrRateLimited:              {http.StatusTooManyRequests, entity.ErrMsgRateLimited},
+	entity.ErrCardCodeInvalid:          {http.StatusUnprocessableEntity, entity.ErrMsgCardCodeInvalid},
+	entity.ErrCardNotFound:             {http.StatusNotFound, entity.ErrMsgCardNotFound},
+	entity.ErrDeckNotFound:             {http.StatusNotFound, entity.ErrMsgDeckNotFound},
+	entity.ErrDeckCardInsufficient:     {http.StatusUnprocessableEntity, entity.ErrMsgDeckCardInsufficient},
+	entity.ErrDeckVersionMismatch:      {http.StatusPreconditionFailed, entity.ErrMsgDeckVersionMismatch},
+	entity.ErrDeckCompactInvalid:       {http.StatusUnprocessableEntity, entity.ErrMsgDeckCompactInvalid},
+	entity.ErrDeckImportInvalid:        {http.StatusUnprocessableEntity, entity.ErrMsgDeckImportInvalid},
+	entity.ErrDeckEventNotFound:        {http.StatusNotFound, entity.ErrMsgDeckEventNotFound},
+	entity.ErrWebhookNotFound:          {http.StatusNotFound, entity.ErrMsgWebhookNotFound},
+	entity.ErrAPIKeyNotFound:           {http.StatusNotFound, entity.ErrMsgAPIKeyNotFound},
+	entity.ErrTenantNotFound:           {http.StatusNotFound, entity.ErrMsgTenantNotFound},
+	entity.ErrTenantDeckQuotaExceeded:  {http.StatusForbidden, entity.ErrMsgTenantDeckQuotaExceeded},
+}
+
+// errorStatus returns HTTP status of error code
+func errorStatus(code string) int {
+	if m, ok := errorMappings[code]; ok {
+		return m.status
+	}
+	return http.StatusInternalServerError
+}
+
+// toEntityError returns err as entity.Error, hiding unknown error as internal error
+func toEntityError(err error) *entity.Error {
+	if perr, ok := err.(*entity.Error); ok {
+		return perr
+	}
+	// error is not in custom error, assume unknown error
+	return entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+}
+
+// handleError writes err with the status mapped from its code, translated into language of Accept-Language header.
+// Clients preferring application/problem+json get RFC 7807 problem details, others get entity.Error in the negotiated media type.
+func handleError(w http.ResponseWriter, r *http.Request, err error) {
+	localizer := i18n.NewLocalizer(r.Header.Get("Accept-Language"))
+	perr := localizer.Error(toEntityError(err))
+	status := errorStatus(perr.Code)
+
+	c, q := negotiateCodec(r, perr)
+	var body any = perr
+	contentType := c.mediaTypes[0]
+	if problemQ := acceptedMediaTypes(r)[contentTypeProblem]; problemQ > 0 && problemQ >= q {
+		c, body = jsonCodec, newProblemResponse(r, localizer, perr, status)
+		contentType = contentTypeProblem
+	}
+
+	raw, err := c.marshal(body)
+	if err != nil {
+		// if encoding still failed, fallback to default internal error
+		log.Error().Err(err).Msg("[rest] error encoding error response")
+		http.Error(w, entity.ErrMsgInternal, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", localizer.Language().String())
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if _, err := w.Write(raw); err != nil {
+		log.Error().Err(err).Msg("[rest] error writing error response")
+	}
+}
+
+// WriteError writes err the same way as errors of the handlers, so middleware in front of them
+// share status mapping, content negotiation and translations of the handlers.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	handleError(w, r, err)
+}
+
+func newProblemResponse(r *http.Request, localizer *i18n.Localizer, perr *entity.Error, status int) *ProblemResponse {
+	title := http.StatusText(status)
+	if m, ok := errorMappings[perr.Code]; ok {
+		title = localizer.Message(perr.Code, m.title)
+	}
+
+	return &ProblemResponse{
+		Type:     problemTypePrefix + perr.Code,
+		Title:    title,
+		Status:   status,
+		Detail:   perr.Message,
+		Instance: r.URL.RequestURI(),
+		Details:  perr.Details,
+	}
+}
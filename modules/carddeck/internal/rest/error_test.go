@@ -0,0 +1,128 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *HandlerTestSuite) TestErrorResponse() {
+	getDeck := func(accept string, err error) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def?trace=1", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(nil, err)
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+		return w.Result()
+	}
+
+	s.Run("success - problem details when client accepts application/problem+json", func() {
+		response := getDeck("application/problem+json", entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+		assert.Equal(s.T(), "application/problem+json", response.Header.Get("Content-Type"))
+		assert.Equal(s.T(), "Accept", response.Header.Get("Vary"))
+
+		var problem rest.ProblemResponse
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&problem))
+		assert.Equal(s.T(), rest.ProblemResponse{
+			Type:     "/problems/carddeck.deck.not_found",
+			Title:    entity.ErrMsgDeckNotFound,
+			Status:   http.StatusNotFound,
+			Detail:   entity.ErrMsgDeckNotFound,
+			Instance: "/decks/some-uuid-abc-def?trace=1",
+		}, problem)
+	})
+
+	s.Run("success - problem details contain error_details extension", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks?shuffled=maybe", nil)
+		r.Header.Set("Accept", "application/json;q=0.5, application/problem+json")
+		w := httptest.NewRecorder()
+
+		rest.NewHandler(s.svc).CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+
+		var problem rest.ProblemResponse
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&problem))
+		assert.Equal(s.T(), "/problems/common.parameter_invalid", problem.Type)
+		assert.Equal(s.T(), "/decks?shuffled=maybe", problem.Instance)
+		assert.Equal(s.T(), []*entity.ErrorDetail{entity.NewErrorDetail("shuffled", "shuffled parameter is invalid")}, problem.Details)
+	})
+
+	s.Run("success - unknown error is hidden as internal problem", func() {
+		response := getDeck("application/problem+json", errors.New("connection refused"))
+
+		assert.Equal(s.T(), http.StatusInternalServerError, response.StatusCode)
+
+		var problem rest.ProblemResponse
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&problem))
+		assert.Equal(s.T(), "/problems/common.internal", problem.Type)
+		assert.Equal(s.T(), entity.ErrMsgInternal, problem.Detail)
+	})
+
+	for _, accept := range []string{"", "*/*", "application/json", "application/problem+json;q=0.5, application/json", "application/problem+json;q=0"} {
+		s.Run("success - legacy error for Accept "+accept, func() {
+			response := getDeck(accept, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+			assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+			assert.Equal(s.T(), "application/json", response.Header.Get("Content-Type"))
+
+			var perr entity.Error
+			assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&perr))
+			assert.Equal(s.T(), *entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound), perr)
+		})
+	}
+
+	s.Run("success - error is translated into accepted language", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks?shuffled=maybe", nil)
+		r.Header.Set("Accept-Language", "ja-JP, en;q=0.5")
+		w := httptest.NewRecorder()
+
+		rest.NewHandler(s.svc).CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+		assert.Equal(s.T(), "ja", response.Header.Get("Content-Language"))
+		assert.Equal(s.T(), []string{"Accept", "Accept-Language"}, response.Header.Values("Vary"))
+
+		var perr entity.Error
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&perr))
+		assert.Equal(s.T(), entity.Error{
+			Code:    entity.ErrParamInvalid,
+			Message: "パラメータが不正です",
+			Details: []*entity.ErrorDetail{entity.NewErrorDetail("shuffled", "shuffledパラメータが不正です")},
+		}, perr)
+	})
+
+	s.Run("success - problem details are translated into accepted language", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("Accept", "application/problem+json")
+		r.Header.Set("Accept-Language", "es-MX")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), "es", response.Header.Get("Content-Language"))
+
+		var problem rest.ProblemResponse
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&problem))
+		assert.Equal(s.T(), "mazo no encontrado", problem.Title)
+		assert.Equal(s.T(), "mazo no encontrado", problem.Detail)
+	})
+}
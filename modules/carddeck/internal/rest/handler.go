@@ -3,53 +3,125 @@ package rest
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/gorilla/websocket"
 	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	formatCompact = "compact"
+)
+
 // Service defines interfaces for carddeck usecases
 type Service interface {
 	CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error)
+	RestoreDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error)
 	GetDeck(ctx context.Context, id string) (*entity.Deck, error)
-	DrawCards(ctx context.Context, id string, n int64) (*entity.Cards, error)
+	DrawCards(ctx context.Context, id string, n int64, version int64) (*entity.Cards, *entity.Deck, error)
+	ExportDeck(ctx context.Context, id string) (*entity.DeckExport, error)
+	ImportDeck(ctx context.Context, doc *entity.DeckExport) (*entity.Deck, error)
+	ShuffleDeck(ctx context.Context, id string, version int64) (*entity.Deck, error)
+	ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*entity.Deck, error)
+	Batch(ctx context.Context, operations []*entity.BatchOperation) ([]*entity.BatchResult, error)
+	SubscribeDeck(ctx context.Context, id string) (<-chan *entity.DeckEvent, func(), error)
+	ListDeckEvents(ctx context.Context, id string, afterID int64) ([]*entity.DeckEvent, error)
+	DeleteDeck(ctx context.Context, id string, version int64) error
+	CreateWebhook(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhookDeadLetters(ctx context.Context, id string) ([]*entity.WebhookDelivery, error)
 }
 
 // Handler defines REST API Handler for card deck
 type Handler struct {
-	svc Service
+	svc         Service
+	images      *imageCache
+	maxBodySize int64
+	upgrader    *websocket.Upgrader
+}
+
+// DefaultMaxBodySize is maximum size in bytes of request bodies decoded by the handler, unless WithMaxBodySize is used
+const DefaultMaxBodySize = 1 << 20
+
+// HandlerOption configures REST API handler
+type HandlerOption func(*Handler)
+
+// WithMaxBodySize limits size in bytes of request bodies, larger requests are rejected with ErrRequestTooLarge
+func WithMaxBodySize(size int64) HandlerOption {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithCheckOrigin accepts WebSocket handshakes whose Origin header passes check, e.g. middleware.CORSPolicy.CheckOrigin.
+// Without it only handshakes from the same host are accepted.
+func WithCheckOrigin(check func(r *http.Request) bool) HandlerOption {
+	return func(h *Handler) {
+		h.upgrader.CheckOrigin = check
+	}
 }
 
 // NewHandler creates new REST API handler.
-func NewHandler(svc Service) *Handler {
-	return &Handler{
-		svc: svc,
+func NewHandler(svc Service, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		svc:         svc,
+		images:      newImageCache(),
+		maxBodySize: DefaultMaxBodySize,
+		upgrader:    newUpgrader(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // @summary	Create new deck
 // @tags		carddeck
-// @produce	json
+// @produce	json,application/msgpack,application/cbor,application/protobuf
 // @param		shuffled	query	boolean	false	"Specify whether newly created deck is shuffled or not"
 // @param		cards		query	string	false	"Specify cards used in this newly created deck"
-// @router		/decks [get]
+// @param		compact		query	string	false	"Restore deck position and shuffled flag from compact encoding returned by GET /decks/{id}?format=compact"
+// @param		Idempotency-Key	header	string	false	"Retrying request with the same key replays the first response instead of creating another deck"
+// @router		/decks [post]
 func (h *Handler) CreateDeck(w http.ResponseWriter, r *http.Request) {
 	shuffledParam := r.URL.Query().Get("shuffled")
 	cardsParam := r.URL.Query().Get("cards")
+	compactParam := r.URL.Query().Get("compact")
 
 	var (
 		shuffled  = false
 		cardCodes []string
 	)
 
+	if cardsParam != "" && compactParam != "" {
+		log.Error().Msg("[POST /decks] cards and compact parameter are both supplied")
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("compact", "compact parameter cannot be used together with cards parameter"))
+		handleError(w, r, err)
+		return
+	}
+
+	if shuffledParam != "" && compactParam != "" {
+		log.Error().Msg("[POST /decks] shuffled and compact parameter are both supplied")
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("compact", "compact parameter cannot be used together with shuffled parameter"))
+		handleError(w, r, err)
+		return
+	}
+
 	if cardsParam != "" {
 		cardCodes = strings.Split(cardsParam, ",")
 	}
 
+	if compactParam != "" {
+		h.restoreDeck(w, r, compactParam)
+		return
+	}
+
 	if shuffledParam != "" {
 		var parseErr error
 		shuffled, parseErr = strconv.ParseBool(shuffledParam)
@@ -57,7 +129,7 @@ func (h *Handler) CreateDeck(w http.ResponseWriter, r *http.Request) {
 			log.Error().Err(parseErr).Msg("[POST /decks] error parsing shuffled parameter")
 			err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
 			err.AddDetail(entity.NewErrorDetail("shuffled", "shuffled parameter is invalid"))
-			handleError(w, err, http.StatusBadRequest)
+			handleError(w, r, err)
 			return
 		}
 	}
@@ -66,130 +138,381 @@ func (h *Handler) CreateDeck(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error().Err(err).Msg("[POST /decks] error creating deck")
 
-		if perr, ok := err.(*entity.Error); ok {
-			switch perr.Code {
-			case entity.ErrCardCodeInvalid:
-				handleError(w, perr, http.StatusUnprocessableEntity)
-			default:
-				handleError(w, perr, http.StatusInternalServerError)
-			}
-		} else {
-			// error is not in custom error, assume unknown error
-			handleError(
-				w,
-				entity.NewError(entity.ErrInternal, entity.ErrMsgInternal),
-				http.StatusInternalServerError)
-		}
+		handleError(w, r, err)
+		return
+	}
+
+	writeCreatedDeck(w, r, deck)
+}
+
+// restoreDeck creates deck of POST /decks from compact encoding of another deck, keeping its position and shuffled flag
+func (h *Handler) restoreDeck(w http.ResponseWriter, r *http.Request, compact string) {
+	set, cards, shuffled, err := entity.DecodeCompactDeck(compact)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks] error decoding compact parameter")
+		handleError(w, r, err)
+		return
+	}
+	// decks are built from the standard card set, indexes of other sets point to other cards
+	if set.ID != entity.StandardCardSetID {
+		log.Error().Str("card_set", set.ID).Msg("[POST /decks] compact parameter is encoded from other card set")
+		handleError(w, r, entity.NewError(entity.ErrDeckCompactInvalid, entity.ErrMsgDeckCompactInvalid))
+		return
+	}
+
+	cardCodes := make([]string, len(cards))
+	for i, card := range cards {
+		cardCodes[i] = card.Code
+	}
+
+	deck, err := h.svc.RestoreDeck(r.Context(), shuffled, cardCodes)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks] error restoring deck")
+
+		handleError(w, r, err)
 		return
 	}
 
+	writeCreatedDeck(w, r, deck)
+}
+
+// writeCreatedDeck writes response of POST /decks
+func writeCreatedDeck(w http.ResponseWriter, r *http.Request, deck *entity.Deck) {
 	resp := CreateDeckResponse{
 		ID:        deck.ID,
 		Shuffled:  deck.Shuffled,
 		Remaining: int64(deck.Remaining()),
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(&resp); err != nil {
-		log.Error().Err(err).Msg("[POST /decks] error encoding response")
-		http.Error(w, entity.ErrMsgInternal, http.StatusInternalServerError)
-	}
+	w.Header().Set("ETag", etag(deck.Version))
+	writeResponse(w, r, http.StatusCreated, &resp)
 }
 
 // @summary	"Open" a new deck, or get deck by specific ID
 // @tags		carddeck
-// @produce	json
-// @param		id	path	string	true	"ID of the deck"
+// @produce	json,application/msgpack,application/cbor,application/protobuf
+// @param		id		path	string	true	"ID of the deck"
+// @param		format	query	string	false	"Response format, use compact to get compact encoding of the deck instead of cards"	Enums(compact)
+// @param		render	query	string	false	"Also render every card as Unicode playing card, short notation (e.g. Q♥) or multi-line ASCII art"	Enums(unicode, short, ascii)
+// @param		Accept-Language	header	string	false	"Language of card names and error messages: en, id, ja or es"
+// @param		If-None-Match	header	string	false	"ETag of cached deck, 304 is returned when the deck is unchanged"
+// @param		If-Modified-Since	header	string	false	"Last-Modified of cached deck, ignored when If-None-Match is supplied"
 // @router		/decks/{id} [get]
 func (h *Handler) GetDeck(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	format := r.URL.Query().Get("format")
+	if format != "" && format != formatCompact {
+		log.Error().Str("format", format).Msg("[GET /decks/{id}] unknown format parameter")
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("format", "format parameter is invalid"))
+		handleError(w, r, err)
+		return
+	}
+
+	presenter, err := newCardPresenter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}] error parsing render parameter")
+		handleError(w, r, err)
+		return
+	}
 
 	deck, err := h.svc.GetDeck(r.Context(), id)
 	if err != nil {
 		log.Error().Err(err).Msg("[GET /decks/{id}] error getting deck")
 
-		if perr, ok := err.(*entity.Error); ok {
-			switch perr.Code {
-			case entity.ErrDeckNotFound:
-				handleError(w, perr, http.StatusNotFound)
-			default:
-				handleError(w, perr, http.StatusInternalServerError)
-			}
-		} else {
-			// error is not in custom error, assume unknown error
-			handleError(
-				w,
-				entity.NewError(entity.ErrInternal, entity.ErrMsgInternal),
-				http.StatusInternalServerError)
-		}
+		handleError(w, r, err)
+		return
+	}
+
+	setValidators(w, deck)
+	if notModified(r, deck) {
+		w.Header().Add("Vary", "Accept")
+		presenter.setHeaders(w)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if format == formatCompact {
+		h.writeCompactDeck(w, r, deck)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(&deck); err != nil {
-		log.Error().Err(err).Msg("[GET /decks/{id}] error encoding response")
-		http.Error(w, entity.ErrMsgInternal, http.StatusInternalServerError)
+	resp := DeckResponse{
+		ID:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+		CreatedAt: deck.CreatedAt,
+		UpdatedAt: deck.UpdatedAt,
+		Cards:     presenter.cards(deck.Cards),
 	}
+
+	presenter.setHeaders(w)
+	writeResponse(w, r, http.StatusOK, &resp)
 }
 
 // @summary	Draw cards from specific deck
 // @tags		carddeck
-// @produce	json
+// @produce	json,application/msgpack,application/cbor,application/protobuf
 // @param		id		path	string	true	"ID of the deck"
-// @param		count	query	integer	true	"Number of cards to withdraw"
+// @param		count		query	integer	true	"Number of cards to withdraw"
+// @param		render		query	string	false	"Also render every card as Unicode playing card, short notation (e.g. Q♥) or multi-line ASCII art"	Enums(unicode, short, ascii)
+// @param		Accept-Language	header	string	false	"Language of card names and error messages: en, id, ja or es"
+// @param		If-Match		header	string	false	"Only draw if deck ETag (returned by GET /decks/{id}) still matches"
+// @param		Idempotency-Key	header	string	false	"Retrying request with the same key replays the first response instead of drawing again"
+// @router		/decks/{id}/cards [post]
 // @router		/decks/{id}/cards [get]
 func (h *Handler) DrawCards(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
+	if r.Method == http.MethodGet {
+		// GET is kept for backward compatibility, drawing cards is not safe nor idempotent
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf(`</decks/%s/cards>; rel="successor-version"`, id))
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/cards] error parsing If-Match header")
+		handleError(w, r, err)
+		return
+	}
+
 	countParam := r.URL.Query().Get("count")
 	count, err := strconv.ParseInt(countParam, 10, 64)
 	if err != nil {
-		log.Error().Err(err).Msg("[GET /decks/{id}/cards] error parsing count parameter")
+		log.Error().Err(err).Msg("[POST /decks/{id}/cards] error parsing count parameter")
 		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
 		err.AddDetail(entity.NewErrorDetail("count", "count parameter is invalid"))
-		handleError(w, err, http.StatusBadRequest)
+		handleError(w, r, err)
+		return
+	}
+
+	presenter, err := newCardPresenter(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/cards] error parsing render parameter")
+		handleError(w, r, err)
 		return
 	}
 
-	cards, err := h.svc.DrawCards(r.Context(), id, count)
+	cards, deck, err := h.svc.DrawCards(r.Context(), id, count, version)
 	if err != nil {
-		log.Error().Err(err).Msg("[GET /decks/{id}/cards] error drawing cards")
+		log.Error().Err(err).Msg("[POST /decks/{id}/cards] error drawing cards")
 
-		if perr, ok := err.(*entity.Error); ok {
-			switch perr.Code {
-			case entity.ErrDeckNotFound:
-				handleError(w, perr, http.StatusNotFound)
-			case entity.ErrDeckCardInsufficient:
-				handleError(w, perr, http.StatusUnprocessableEntity)
-			default:
-				handleError(w, perr, http.StatusInternalServerError)
-			}
-		} else {
-			// error is not in custom error, assume unknown error
-			handleError(
-				w,
-				entity.NewError(entity.ErrInternal, entity.ErrMsgInternal),
-				http.StatusInternalServerError)
-		}
+		handleError(w, r, err)
 		return
 	}
 
 	resp := DrawCardResponse{
-		Cards: cards,
+		Cards: presenter.cards(cards),
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(&resp); err != nil {
-		log.Error().Err(err).Msg("[GET /decks/{id}/cards] error encoding response")
-		http.Error(w, entity.ErrMsgInternal, http.StatusInternalServerError)
+	presenter.setHeaders(w)
+	w.Header().Set("ETag", etag(deck.Version))
+	writeResponse(w, r, http.StatusOK, &resp)
+}
+
+// @summary	Export deck state into portable versioned document
+// @tags		carddeck
+// @produce	json,application/msgpack,application/cbor
+// @param		id	path	string	true	"ID of the deck"
+// @router		/decks/{id}/export [get]
+func (h *Handler) ExportDeck(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	doc, err := h.svc.ExportDeck(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/export] error exporting deck")
+
+		handleError(w, r, err)
+		return
 	}
+
+	c, _ := negotiateCodec(r, doc)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="deck-%s.%s"`, id, c.extension))
+	writeResponse(w, r, http.StatusOK, doc)
 }
 
-func handleError(w http.ResponseWriter, err any, code int) {
-	w.WriteHeader(code)
+// @summary	Import deck state from document produced by export, creating a new deck
+// @tags		carddeck
+// @accept		json,application/msgpack,application/cbor
+// @produce	json,application/msgpack,application/cbor,application/protobuf
+// @param		document	body	entity.DeckExport	true	"Deck export document"
+// @router		/decks/import [post]
+func (h *Handler) ImportDeck(w http.ResponseWriter, r *http.Request) {
+	var doc entity.DeckExport
+	if err := decodeRequest(w, r, &doc, h.maxBodySize); err != nil {
+		log.Error().Err(err).Msg("[POST /decks/import] error decoding request body")
+		handleError(w, r, bodyError(err, "request body is not a valid deck export document"))
+		return
+	}
 
-	// if encoding still failed, fallback to default internal error
-	if err := json.NewEncoder(w).Encode(&err); err != nil {
-		log.Error().Err(err).Msg("[GET /decks] error encoding response")
-		http.Error(w, entity.ErrMsgInternal, http.StatusInternalServerError)
+	deck, err := h.svc.ImportDeck(r.Context(), &doc)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/import] error importing deck")
+
+		handleError(w, r, err)
+		return
 	}
+
+	resp := CreateDeckResponse{
+		ID:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+	}
+
+	w.Header().Set("ETag", etag(deck.Version))
+	writeResponse(w, r, http.StatusCreated, &resp)
+}
+
+// @summary	Shuffle remaining cards of specific deck
+// @tags		carddeck
+// @produce	json,application/msgpack,application/cbor,application/protobuf
+// @param		id				path	string	true	"ID of the deck"
+// @param		If-Match		header	string	false	"Only shuffle if deck ETag (returned by GET /decks/{id}) still matches"
+// @param		Idempotency-Key	header	string	false	"Retrying request with the same key replays the first response instead of shuffling again"
+// @router		/decks/{id}/shuffle [post]
+func (h *Handler) ShuffleDeck(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, err := parseIfMatch(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/shuffle] error parsing If-Match header")
+		handleError(w, r, err)
+		return
+	}
+
+	deck, err := h.svc.ShuffleDeck(r.Context(), id, version)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/shuffle] error shuffling deck")
+
+		handleError(w, r, err)
+		return
+	}
+
+	resp := CreateDeckResponse{
+		ID:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+	}
+
+	w.Header().Set("ETag", etag(deck.Version))
+	writeResponse(w, r, http.StatusOK, &resp)
+}
+
+// @summary	Return cards to the bottom of specific deck
+// @tags		carddeck
+// @produce	json,application/msgpack,application/cbor,application/protobuf
+// @param		id				path	string	true	"ID of the deck"
+// @param		cards			query	string	true	"Comma separated codes of cards returned to the deck"
+// @param		If-Match		header	string	false	"Only return cards if deck ETag (returned by GET /decks/{id}) still matches"
+// @param		Idempotency-Key	header	string	false	"Retrying request with the same key replays the first response instead of returning cards again"
+// @router		/decks/{id}/return [post]
+func (h *Handler) ReturnCards(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, err := parseIfMatch(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/return] error parsing If-Match header")
+		handleError(w, r, err)
+		return
+	}
+
+	var cardCodes []string
+	if cardsParam := r.URL.Query().Get("cards"); cardsParam != "" {
+		cardCodes = strings.Split(cardsParam, ",")
+	}
+
+	deck, err := h.svc.ReturnCards(r.Context(), id, cardCodes, version)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /decks/{id}/return] error returning cards")
+
+		handleError(w, r, err)
+		return
+	}
+
+	resp := CreateDeckResponse{
+		ID:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+	}
+
+	w.Header().Set("ETag", etag(deck.Version))
+	writeResponse(w, r, http.StatusOK, &resp)
+}
+
+// @summary	Delete specific deck
+// @tags		carddeck
+// @param		id			path	string	true	"ID of the deck"
+// @param		If-Match	header	string	false	"Only delete if deck ETag (returned by GET /decks/{id}) still matches"
+// @success	204
+// @router		/decks/{id} [delete]
+func (h *Handler) DeleteDeck(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	version, err := parseIfMatch(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[DELETE /decks/{id}] error parsing If-Match header")
+		handleError(w, r, err)
+		return
+	}
+
+	if err := h.svc.DeleteDeck(r.Context(), id, version); err != nil {
+		log.Error().Err(err).Msg("[DELETE /decks/{id}] error deleting deck")
+
+		handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @summary	Execute ordered deck operations (create, draw, shuffle, return) atomically
+// @description	All operations are executed in a single transaction, if one fails every operation is rolled back.
+// @description	deck_id may reference the deck of a previous operation using "$<index>", e.g. "$0".
+// @tags		carddeck
+// @accept		json,application/msgpack,application/cbor
+// @produce	json,application/msgpack,application/cbor
+// @param		request	body	BatchRequest	true	"Ordered list of operations"
+// @router		/batch [post]
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := decodeRequest(w, r, &req, h.maxBodySize); err != nil {
+		log.Error().Err(err).Msg("[POST /batch] error decoding request body")
+		handleError(w, r, bodyError(err, "request body is not a valid batch request"))
+		return
+	}
+
+	results, err := h.svc.Batch(r.Context(), req.Operations)
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /batch] error executing batch")
+
+		if results == nil {
+			handleError(w, r, err)
+			return
+		}
+
+		writeResponse(w, r, errorStatus(toEntityError(err).Code), &BatchResponse{Results: results})
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, &BatchResponse{Results: results})
+}
+
+func (h *Handler) writeCompactDeck(w http.ResponseWriter, r *http.Request, deck *entity.Deck) {
+	var cards entity.Cards
+	if deck.Cards != nil {
+		cards = *deck.Cards
+	}
+
+	compact, err := entity.EncodeCompactDeck(entity.StandardCardSet, cards, deck.Shuffled)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}] error encoding compact deck")
+		handleError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+		return
+	}
+
+	resp := CompactDeckResponse{
+		ID:        deck.ID,
+		Shuffled:  deck.Shuffled,
+		Remaining: int64(deck.Remaining()),
+		Compact:   compact,
+	}
+
+	writeResponse(w, r, http.StatusOK, &resp)
 }
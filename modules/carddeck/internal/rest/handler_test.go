@@ -29,6 +29,7 @@ var (
 			{Val: "3", Suit: "SPADE", Code: "3S"},
 		})
 		deck.ID = "some-uuid-abc-def"
+		deck.Version = 1
 		deck.CreatedAt = defaultTime
 		deck.UpdatedAt = defaultTime
 
@@ -39,7 +40,10 @@ var (
 		{Val: "2", Suit: "SPADE", Code: "2S"},
 	}
 	defaultDrawCardResponse = rest.DrawCardResponse{
-		Cards: &defaultCards,
+		Cards: []*rest.CardResponse{
+			{Card: defaultCards[0], Name: "Ace of Spades", Image: "/cards/AS.svg"},
+			{Card: defaultCards[1], Name: "2 of Spades", Image: "/cards/2S.svg"},
+		},
 	}
 )
 
@@ -133,6 +137,106 @@ func (s *HandlerTestSuite) TestCreateDeck() {
 		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
 	})
 
+	s.Run("success - with compact parameter", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=standard.AAE", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().RestoreDeck(r.Context(), false, []string{"AS", "2S"}).Return(defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+	})
+
+	s.Run("success - with compact parameter of shuffled deck", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=standard.AAE.s", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().RestoreDeck(r.Context(), true, []string{"AS", "2S"}).Return(defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+	})
+
+	s.Run("failed - compact and shuffled are both supplied", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=standard.AAE&shuffled=true", nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+	})
+
+	s.Run("failed - compact is invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=NOT_COMPACT", nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusUnprocessableEntity, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expectedError := entity.NewError(entity.ErrDeckCompactInvalid, entity.ErrMsgDeckCompactInvalid)
+		expected, err := json.Marshal(&expectedError)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - compact is encoded from other card set", func() {
+		entity.RegisterCardSet(entity.NewCardSet("test-other", []entity.Card{
+			{Val: "JOKER", Suit: "RED", Code: "JR"},
+			{Val: "JOKER", Suit: "BLACK", Code: "JB"},
+		}))
+		s.T().Cleanup(func() { entity.UnregisterCardSet("test-other") })
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=test-other.AAE", nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusUnprocessableEntity, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expectedError := entity.NewError(entity.ErrDeckCompactInvalid, entity.ErrMsgDeckCompactInvalid)
+		expected, err := json.Marshal(&expectedError)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - compact and cards are both supplied", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost?compact=standard.AAE&cards=AS", nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.CreateDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expectedError := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		expectedError.AddDetail(entity.NewErrorDetail("compact", "compact parameter cannot be used together with cards parameter"))
+		expected, err := json.Marshal(&expectedError)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
 	s.Run("failed - shuffled is invalid", func() {
 		r := httptest.NewRequest(http.MethodPost, "http://localhost?shuffled=NOT_BOOL_PARSABLE", nil)
 		w := httptest.NewRecorder()
@@ -216,10 +320,146 @@ func (s *HandlerTestSuite) TestGetDeck() {
 		response := w.Result()
 
 		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), `W/"1"`, response.Header.Get("ETag"))
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(&rest.DeckResponse{
+			ID:        defaultDeck.ID,
+			Shuffled:  defaultDeck.Shuffled,
+			Remaining: 3,
+			CreatedAt: defaultDeck.CreatedAt,
+			UpdatedAt: defaultDeck.UpdatedAt,
+			Cards: []*rest.CardResponse{
+				{Card: (*defaultDeck.Cards)[0], Name: "Ace of Spades", Image: "/cards/AS.svg"},
+				{Card: (*defaultDeck.Cards)[1], Name: "2 of Spades", Image: "/cards/2S.svg"},
+				{Card: (*defaultDeck.Cards)[2], Name: "3 of Spades", Image: "/cards/3S.svg"},
+			},
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("success - card names in accepted language with short rendering", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s?render=short", tempID), nil)
+		r.Header.Set("Accept-Language", "es-ES")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), tempID).Return(defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}", h.GetDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "es", response.Header.Get("Content-Language"))
+		assert.Equal(s.T(), "Accept-Language", response.Header.Get("Vary"))
+
+		var resp struct {
+			Cards []*rest.CardResponse `json:"cards"`
+		}
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), []*rest.CardResponse{
+			{Card: (*defaultDeck.Cards)[0], Name: "As de picas", Image: "/cards/AS.svg", Rendered: "A♠"},
+			{Card: (*defaultDeck.Cards)[1], Name: "2 de picas", Image: "/cards/2S.svg", Rendered: "2♠"},
+			{Card: (*defaultDeck.Cards)[2], Name: "3 de picas", Image: "/cards/3S.svg", Rendered: "3♠"},
+		}, resp.Cards)
+	})
+
+	s.Run("failed - render parameter invalid", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s?render=svg", tempID), nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}", h.GetDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expectedError := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		expectedError.AddDetail(entity.NewErrorDetail("render", "render parameter must be unicode, short or ascii"))
+		expected, err := json.Marshal(&expectedError)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("success - compact format", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s?format=compact", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), tempID).Return(defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}", h.GetDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(&rest.CompactDeckResponse{
+			ID:        defaultDeck.ID,
+			Shuffled:  defaultDeck.Shuffled,
+			Remaining: int64(defaultDeck.Remaining()),
+			Compact:   "standard.AAEC",
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("success - compact format of shuffled deck", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s?format=compact", tempID), nil)
+		w := httptest.NewRecorder()
+
+		shuffled := *defaultDeck
+		shuffled.Shuffled = true
+		s.svc.EXPECT().GetDeck(r.Context(), tempID).Return(&shuffled, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}", h.GetDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+
+		var resp rest.CompactDeckResponse
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), "standard.AAEC.s", resp.Compact)
+	})
+
+	s.Run("failed - format parameter invalid", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s?format=xml", tempID), nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}", h.GetDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
 
 		rawResponseBody, err := io.ReadAll(response.Body)
 		assert.NoError(s.T(), err)
-		expected, err := json.Marshal(&defaultDeck)
+
+		expectedError := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		expectedError.AddDetail(entity.NewErrorDetail("format", "format parameter is invalid"))
+		expected, err := json.Marshal(&expectedError)
 		assert.NoError(s.T(), err)
 		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
 	})
@@ -287,7 +527,7 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
 		w := httptest.NewRecorder()
 
-		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount).Return(&defaultCards, nil)
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(&defaultCards, defaultDeck, nil)
 
 		h := rest.NewHandler(s.svc)
 
@@ -300,6 +540,7 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		response := w.Result()
 
 		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), `W/"1"`, response.Header.Get("ETag"))
 
 		rawResponseBody, err := io.ReadAll(response.Body)
 		assert.NoError(s.T(), err)
@@ -309,11 +550,42 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
 	})
 
+	s.Run("success - unicode and ascii rendering", func() {
+		for render, expected := range map[string][]string{
+			"unicode": {"🂡", "🂢"},
+			"ascii": {
+				"+-----+\n|A    |\n|  S  |\n|    A|\n+-----+",
+				"+-----+\n|2    |\n|  S  |\n|    2|\n+-----+",
+			},
+		} {
+			r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d&render=%s", tempID, tempCount, render), nil)
+			w := httptest.NewRecorder()
+
+			s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(&defaultCards, defaultDeck, nil)
+
+			h := rest.NewHandler(s.svc)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /decks/{id}/cards", h.DrawCards)
+			mux.ServeHTTP(w, r)
+			response := w.Result()
+
+			assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+
+			var resp rest.DrawCardResponse
+			assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+			assert.Len(s.T(), resp.Cards, 2)
+			for i, card := range resp.Cards {
+				assert.Equal(s.T(), expected[i], card.Rendered, render)
+			}
+		}
+	})
+
 	s.Run("failed - service layer returns unexpected error", func() {
 		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
 		w := httptest.NewRecorder()
 
-		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount).Return(nil, errors.New("unknown error"))
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(nil, nil, errors.New("unknown error"))
 
 		h := rest.NewHandler(s.svc)
 
@@ -340,7 +612,7 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
 		w := httptest.NewRecorder()
 
-		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(nil, nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
 
 		h := rest.NewHandler(s.svc)
 
@@ -367,7 +639,7 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
 		w := httptest.NewRecorder()
 
-		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount).Return(nil, entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient))
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(nil, nil, entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient))
 
 		h := rest.NewHandler(s.svc)
 
@@ -390,17 +662,91 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
 	})
 
-	s.Run("failed - count parameter invalid", func() {
-		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%s", tempID, "not_a_number"), nil)
+	s.Run("success - POST method", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(&defaultCards, defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/cards", h.DrawCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Empty(s.T(), response.Header.Get("Deprecation"))
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expected, err := json.Marshal(&defaultDrawCardResponse)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("success - deprecated GET method", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
 		w := httptest.NewRecorder()
 
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(0)).Return(&defaultCards, defaultDeck, nil)
+
 		h := rest.NewHandler(s.svc)
 
 		mux := http.NewServeMux()
 		mux.HandleFunc("GET /decks/{id}/cards", h.DrawCards)
-		srv := httptest.NewServer(mux)
-		defer srv.Close()
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "true", response.Header.Get("Deprecation"))
+		assert.Equal(s.T(), fmt.Sprintf(`</decks/%s/cards>; rel="successor-version"`, tempID), response.Header.Get("Link"))
+	})
+
+	s.Run("success - with If-Match header", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
+		r.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(3)).Return(&defaultCards, defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/cards", h.DrawCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	})
+
+	s.Run("success - with weak If-Match header returned by ETag", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
+		r.Header.Set("If-Match", `W/"3"`)
+		w := httptest.NewRecorder()
 
+		s.svc.EXPECT().DrawCards(r.Context(), tempID, tempCount, int64(3)).Return(&defaultCards, defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/cards", h.DrawCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	})
+
+	s.Run("failed - If-Match header invalid", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%d", tempID, tempCount), nil)
+		r.Header.Set("If-Match", `"1", "2"`)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/cards", h.DrawCards)
 		mux.ServeHTTP(w, r)
 		response := w.Result()
 
@@ -410,9 +756,421 @@ func (s *HandlerTestSuite) TestDrawCards() {
 		assert.NoError(s.T(), err)
 
 		expectedError := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
-		expectedError.AddDetail(entity.NewErrorDetail("count", "count parameter is invalid"))
+		expectedError.AddDetail(entity.NewErrorDetail("If-Match", "If-Match header must be a single entity tag returned by ETag header"))
 		expected, err := json.Marshal(&expectedError)
 		assert.NoError(s.T(), err)
 		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
 	})
+
+	s.Run("failed - count parameter invalid", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/cards?count=%s", tempID, "not_a_number"), nil)
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/cards", h.DrawCards)
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expectedError := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		expectedError.AddDetail(entity.NewErrorDetail("count", "count parameter is invalid"))
+		expected, err := json.Marshal(&expectedError)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+}
+
+func (s *HandlerTestSuite) TestExportDeck() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/export", tempID), nil)
+		w := httptest.NewRecorder()
+
+		doc, err := entity.NewDeckExport(defaultDeck, defaultTime)
+		assert.NoError(s.T(), err)
+		s.svc.EXPECT().ExportDeck(r.Context(), tempID).Return(doc, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/export", h.ExportDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), fmt.Sprintf(`attachment; filename="deck-%s.json"`, tempID), response.Header.Get("Content-Disposition"))
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(doc)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - deck not found", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/export", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ExportDeck(r.Context(), tempID).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/export", h.ExportDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestImportDeck() {
+	doc, err := entity.NewDeckExport(defaultDeck, defaultTime)
+	assert.NoError(s.T(), err)
+	body, err := json.Marshal(doc)
+	assert.NoError(s.T(), err)
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/import", strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ImportDeck(r.Context(), gomock.Any()).DoAndReturn(
+			func(_ any, got *entity.DeckExport) (*entity.Deck, error) {
+				assert.Equal(s.T(), doc.Cards, got.Cards)
+				assert.Equal(s.T(), doc.Checksum, got.Checksum)
+
+				return defaultDeck, nil
+			})
+
+		h := rest.NewHandler(s.svc)
+		h.ImportDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+
+		expected, err := json.Marshal(&rest.CreateDeckResponse{
+			ID:        defaultDeck.ID,
+			Shuffled:  defaultDeck.Shuffled,
+			Remaining: int64(defaultDeck.Remaining()),
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - body is not json", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/import", strings.NewReader("not json"))
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.ImportDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+	})
+
+	s.Run("failed - body too large", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/import", strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc, rest.WithMaxBodySize(int64(len(body)-1)))
+		h.ImportDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusRequestEntityTooLarge, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Contains(s.T(), string(rawResponseBody), entity.ErrRequestTooLarge)
+	})
+
+	s.Run("failed - document invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/import", strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+
+		importErr := entity.NewError(entity.ErrDeckImportInvalid, entity.ErrMsgDeckImportInvalid)
+		importErr.AddDetail(entity.NewErrorDetail("checksum", "checksum does not match document content"))
+		s.svc.EXPECT().ImportDeck(r.Context(), gomock.Any()).Return(nil, importErr)
+
+		h := rest.NewHandler(s.svc)
+		h.ImportDeck(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusUnprocessableEntity, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(importErr)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+}
+
+func (s *HandlerTestSuite) TestShuffleDeck() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/shuffle", tempID), nil)
+		r.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+
+		shuffled := *defaultDeck
+		shuffled.Shuffled = true
+		shuffled.Version = 2
+		s.svc.EXPECT().ShuffleDeck(gomock.Any(), tempID, int64(1)).Return(&shuffled, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/shuffle", h.ShuffleDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), `W/"2"`, response.Header.Get("ETag"))
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), `{"id":"some-uuid-abc-def","shuffled":true,"remaining":3}`, strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - version mismatch", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/shuffle", tempID), nil)
+		r.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ShuffleDeck(gomock.Any(), tempID, int64(1)).Return(nil, entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/shuffle", h.ShuffleDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusPreconditionFailed, response.StatusCode)
+	})
+
+	s.Run("failed - deck not found", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/shuffle", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ShuffleDeck(gomock.Any(), tempID, int64(0)).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/shuffle", h.ShuffleDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestReturnCards() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/return?cards=KH,QH", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ReturnCards(gomock.Any(), tempID, []string{"KH", "QH"}, int64(0)).Return(defaultDeck, nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/return", h.ReturnCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), `W/"1"`, response.Header.Get("ETag"))
+	})
+
+	s.Run("failed - card code invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/return?cards=XX", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ReturnCards(gomock.Any(), tempID, []string{"XX"}, int64(0)).Return(nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/return", h.ReturnCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusUnprocessableEntity, response.StatusCode)
+	})
+
+	s.Run("failed - If-Match header invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost/decks/%s/return?cards=KH", tempID), nil)
+		r.Header.Set("If-Match", "1")
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /decks/{id}/return", h.ReturnCards)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestDeleteDeck() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost/decks/%s", tempID), nil)
+		r.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DeleteDeck(gomock.Any(), tempID, int64(1)).Return(nil)
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("DELETE /decks/{id}", h.DeleteDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusNoContent, response.StatusCode)
+	})
+
+	s.Run("failed - version mismatch", func() {
+		r := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost/decks/%s", tempID), nil)
+		r.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DeleteDeck(gomock.Any(), tempID, int64(1)).Return(entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("DELETE /decks/{id}", h.DeleteDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusPreconditionFailed, response.StatusCode)
+	})
+
+	s.Run("failed - deck not found", func() {
+		r := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost/decks/%s", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DeleteDeck(gomock.Any(), tempID, int64(0)).Return(entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		h := rest.NewHandler(s.svc)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("DELETE /decks/{id}", h.DeleteDeck)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestBatch() {
+	body := `{"operations":[{"op":"create"},{"op":"draw","deck_id":"$0","count":2}]}`
+
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		results := []*entity.BatchResult{
+			{Op: entity.BatchOperationCreate, Status: entity.BatchStatusOK, Deck: defaultDeck},
+			{Op: entity.BatchOperationDraw, Status: entity.BatchStatusOK, Cards: &defaultCards},
+		}
+		s.svc.EXPECT().Batch(r.Context(), []*entity.BatchOperation{
+			{Op: entity.BatchOperationCreate},
+			{Op: entity.BatchOperationDraw, DeckID: "$0", Count: 2},
+		}).Return(results, nil)
+
+		h := rest.NewHandler(s.svc)
+		h.Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(&rest.BatchResponse{Results: results})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - body too large", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc, rest.WithMaxBodySize(16))
+		h.Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusRequestEntityTooLarge, response.StatusCode)
+	})
+
+	s.Run("failed - operation fails", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		insufficientErr := entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient)
+		results := []*entity.BatchResult{
+			{Op: entity.BatchOperationCreate, Status: entity.BatchStatusRolledBack},
+			{Op: entity.BatchOperationDraw, Status: entity.BatchStatusFailed, Error: insufficientErr},
+		}
+		s.svc.EXPECT().Batch(r.Context(), gomock.Any()).Return(results, insufficientErr)
+
+		h := rest.NewHandler(s.svc)
+		h.Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusUnprocessableEntity, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		expected, err := json.Marshal(&rest.BatchResponse{Results: results})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), string(expected), strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - operations empty", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(`{"operations":[]}`))
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().Batch(r.Context(), gomock.Any()).Return(nil, entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid))
+
+		h := rest.NewHandler(s.svc)
+		h.Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+	})
+
+	s.Run("failed - body is not json", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader("not json"))
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.Batch(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode)
+	})
 }
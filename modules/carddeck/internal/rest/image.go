@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/cardimage"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	imageSVG = ".svg"
+	imagePNG = ".png"
+
+	// cardBack is the file name of the card back image
+	cardBack = "back"
+
+	// imageCacheControl lets clients cache images, which never change for the same URL
+	imageCacheControl = "public, max-age=86400"
+
+	// maxCachedBackColors caps number of card back colors kept in memory, other colors are rendered on every request
+	maxCachedBackColors = 64
+)
+
+var (
+	imageContentTypes = map[string]string{
+		imageSVG: "image/svg+xml",
+		imagePNG: "image/png",
+	}
+
+	colorPattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+// @summary		Get image of card face or card back
+// @description	File is the card code or "back" followed by .svg or .png extension, e.g. QH.svg or back.png.
+// @tags			carddeck
+// @produce		image/svg+xml,image/png
+// @param			file	path	string	true	"Card code or back, with .svg or .png extension"
+// @param			set		query	string	false	"Card set of the card, searched in every card set when omitted"
+// @param			color	query	string	false	"Hex color of card back, e.g. 1f4e9c"
+// @success		200
+// @router			/cards/{file} [get]
+func (h *Handler) GetCardImage(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	ext := path.Ext(file)
+	name := strings.TrimSuffix(file, ext)
+
+	contentType, ok := imageContentTypes[ext]
+	if !ok || name == "" {
+		log.Error().Str("file", file).Msg("[GET /cards/{file}] unsupported image file")
+		handleError(w, r, entity.NewError(entity.ErrCardNotFound, entity.ErrMsgCardNotFound))
+		return
+	}
+
+	var (
+		key    string
+		render func() []byte
+	)
+	if name == cardBack {
+		color, err := backColor(r.URL.Query().Get("color"))
+		if err != nil {
+			log.Error().Err(err).Msg("[GET /cards/{file}] invalid color parameter")
+			handleError(w, r, err)
+			return
+		}
+		key = cardBack + color
+		render = func() []byte { return cardimage.Back(color) }
+	} else {
+		setID := r.URL.Query().Get("set")
+		card, err := findCard(setID, name)
+		if err != nil {
+			log.Error().Err(err).Msg("[GET /cards/{file}] error finding card")
+			handleError(w, r, err)
+			return
+		}
+		key = setID + "/" + card.Code
+		render = func() []byte { return cardimage.Face(card) }
+	}
+
+	body, err := h.images.get(key+ext, name == cardBack, func() ([]byte, error) {
+		svg := render()
+		if ext == imagePNG {
+			return cardimage.PNG(svg)
+		}
+		return svg, nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /cards/{file}] error rasterizing image")
+		handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("[GET /cards/{file}] error writing response")
+	}
+}
+
+// backColor validates color parameter, returning the default color when it is empty
+func backColor(param string) (string, error) {
+	if param == "" {
+		return cardimage.DefaultBackColor, nil
+	}
+	if !colorPattern.MatchString(param) {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("color", "color parameter must be a hex color like #1f4e9c"))
+		return "", err
+	}
+	return "#" + strings.ToLower(strings.TrimPrefix(param, "#")), nil
+}
+
+// findCard returns card of the code inside the set, or inside any card set when set is empty
+func findCard(setID, code string) (entity.Card, error) {
+	setIDs := entity.CardSetIDs()
+	if setID != "" {
+		if _, ok := entity.GetCardSet(setID); !ok {
+			err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+			err.AddDetail(entity.NewErrorDetail("set", "unknown card set"))
+			return entity.Card{}, err
+		}
+		setIDs = []string{setID}
+	}
+
+	for _, id := range setIDs {
+		set, _ := entity.GetCardSet(id)
+		if card, ok := set.Card(code); ok {
+			return card, nil
+		}
+	}
+	return entity.Card{}, entity.NewError(entity.ErrCardNotFound, entity.ErrMsgCardNotFound)
+}
+
+// imageCache memoizes rendered images, as there are only a few distinct card faces and backs
+type imageCache struct {
+	mu     sync.RWMutex
+	images map[string][]byte
+	backs  int
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{images: make(map[string][]byte)}
+}
+
+// get returns cached image of the key, rendering and caching it when missing.
+// Card backs are not cached once maxCachedBackColors images of backs are cached.
+func (c *imageCache) get(key string, back bool, render func() ([]byte, error)) ([]byte, error) {
+	c.mu.RLock()
+	image, ok := c.images[key]
+	c.mu.RUnlock()
+	if ok {
+		return image, nil
+	}
+
+	image, err := render()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.images[key]; !ok {
+		if back {
+			if c.backs >= maxCachedBackColors {
+				return image, nil
+			}
+			c.backs++
+		}
+		c.images[key] = image
+	}
+	return image, nil
+}
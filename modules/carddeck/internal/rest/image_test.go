@@ -0,0 +1,126 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/cardimage"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *HandlerTestSuite) TestGetCardImage() {
+	serve := func(target string) *http.Response {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /cards/{file}", rest.NewHandler(s.svc).GetCardImage)
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Result()
+	}
+
+	s.Run("success - card face svg", func() {
+		response := serve("http://localhost/cards/QH.svg?set=standard")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "image/svg+xml", response.Header.Get("Content-Type"))
+		assert.Equal(s.T(), "public, max-age=86400", response.Header.Get("Cache-Control"))
+
+		body := new(bytes.Buffer)
+		_, err := body.ReadFrom(response.Body)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), cardimage.Face(entity.Card{Val: "QUEEN", Suit: "HEART", Code: "QH"}), body.Bytes())
+	})
+
+	s.Run("success - card face png", func() {
+		response := serve("http://localhost/cards/10C.png")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "image/png", response.Header.Get("Content-Type"))
+
+		img, err := png.Decode(response.Body)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), cardimage.Width, img.Bounds().Dx())
+	})
+
+	s.Run("success - card back with color", func() {
+		for target, color := range map[string]string{
+			"http://localhost/cards/back.svg":              cardimage.DefaultBackColor,
+			"http://localhost/cards/back.svg?color=00ff00": "#00ff00",
+			"http://localhost/cards/back.svg?color=%23abc": "#abc",
+		} {
+			response := serve(target)
+			assert.Equal(s.T(), http.StatusOK, response.StatusCode, target)
+
+			body := new(bytes.Buffer)
+			_, err := body.ReadFrom(response.Body)
+			require.NoError(s.T(), err)
+			assert.Equal(s.T(), cardimage.Back(color), body.Bytes(), target)
+		}
+	})
+
+	s.Run("success - card back png", func() {
+		response := serve("http://localhost/cards/back.png?color=00ff00")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "image/png", response.Header.Get("Content-Type"))
+	})
+
+	s.Run("success - images are served from memory", func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /cards/{file}", rest.NewHandler(s.svc).GetCardImage)
+		get := func(target string) []byte {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			require.Equal(s.T(), http.StatusOK, w.Code, target)
+			return w.Body.Bytes()
+		}
+
+		assert.Equal(s.T(), get("http://localhost/cards/QH.png"), get("http://localhost/cards/QH.png"))
+		assert.Equal(s.T(), get("http://localhost/cards/back.svg?color=ABCDEF"), get("http://localhost/cards/back.svg?color=abcdef"))
+
+		// colors over the cache capacity are still rendered
+		for i := 0; i < 100; i++ {
+			color := fmt.Sprintf("%06x", i)
+			assert.Equal(s.T(), cardimage.Back("#"+color), get("http://localhost/cards/back.svg?color="+color))
+		}
+	})
+
+	s.Run("failed - not found", func() {
+		for _, target := range []string{
+			"http://localhost/cards/XX.svg",
+			"http://localhost/cards/QH.gif",
+			"http://localhost/cards/QH",
+			"http://localhost/cards/.svg",
+		} {
+			response := serve(target)
+			assert.Equal(s.T(), http.StatusNotFound, response.StatusCode, target)
+
+			var resp entity.Error
+			require.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+			assert.Equal(s.T(), entity.ErrCardNotFound, resp.Code, target)
+		}
+	})
+
+	s.Run("failed - parameter invalid", func() {
+		for target, field := range map[string]string{
+			"http://localhost/cards/QH.svg?set=tarot":     "set",
+			"http://localhost/cards/back.svg?color=blue":  "color",
+			"http://localhost/cards/back.svg?color=12345": "color",
+		} {
+			response := serve(target)
+			assert.Equal(s.T(), http.StatusBadRequest, response.StatusCode, target)
+
+			var resp entity.Error
+			require.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+			assert.Equal(s.T(), entity.ErrParamInvalid, resp.Code, target)
+			assert.Equal(s.T(), field, resp.Details[0].Field, target)
+		}
+	})
+}
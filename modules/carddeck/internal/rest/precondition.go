@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// etag returns weak entity tag of deck version.
+// The same version is sent in many representations, e.g. JSON, MessagePack, compact or compressed,
+// which are equivalent but not byte for byte the same, hence the tag is weak.
+func etag(version int64) string {
+	return "W/" + strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch parses If-Match header into the deck version expected by the client.
+// Returns 0 when header is absent or "*", meaning any version is accepted.
+// Entity tags are compared weakly, so both W/"3" returned by ETag header and "3" expect version 3.
+func parseIfMatch(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(header, "W/"))
+	if err != nil {
+		return 0, ifMatchInvalidError()
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ifMatchInvalidError()
+	}
+
+	return version, nil
+}
+
+func ifMatchInvalidError() *entity.Error {
+	err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+	err.AddDetail(entity.NewErrorDetail("If-Match", "If-Match header must be a single entity tag returned by ETag header"))
+	return err
+}
+
+// setValidators sets headers letting clients revalidate their copy of the deck with If-None-Match or If-Modified-Since.
+// Cache-Control no-cache makes caches revalidate every time, decks change on every draw.
+func setValidators(w http.ResponseWriter, deck *entity.Deck) {
+	w.Header().Set("ETag", etag(deck.Version))
+	if !deck.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", deck.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+}
+
+// notModified reports whether copy of the deck held by the client is still current.
+// If-None-Match uses weak comparison and takes precedence over If-Modified-Since (RFC 9110 section 13.2.2).
+func notModified(r *http.Request, deck *entity.Deck) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		current := strings.TrimPrefix(etag(deck.Version), "W/")
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == current {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !deck.UpdatedAt.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+		// Last-Modified has second precision
+		return !deck.UpdatedAt.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
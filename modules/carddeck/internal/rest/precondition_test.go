@@ -0,0 +1,94 @@
+package rest_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *HandlerTestSuite) TestConditionalGetDeck() {
+	getDeck := func(header, value, query string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def"+query, nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(defaultDeck, nil)
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+		return w.Result()
+	}
+
+	s.Run("success - validators", func() {
+		response := getDeck("", "", "")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), `W/"1"`, response.Header.Get("ETag"))
+		assert.Equal(s.T(), "Sat, 01 Jan 2022 01:00:00 GMT", response.Header.Get("Last-Modified"))
+		assert.Equal(s.T(), "no-cache", response.Header.Get("Cache-Control"))
+	})
+
+	s.Run("success - not modified by If-None-Match", func() {
+		for _, value := range []string{`"1"`, `W/"1"`, `"0", "1"`, "*"} {
+			response := getDeck("If-None-Match", value, "")
+
+			assert.Equal(s.T(), http.StatusNotModified, response.StatusCode, value)
+			assert.Equal(s.T(), `W/"1"`, response.Header.Get("ETag"), value)
+			assert.Equal(s.T(), "Sat, 01 Jan 2022 01:00:00 GMT", response.Header.Get("Last-Modified"), value)
+			assert.Contains(s.T(), response.Header.Values("Vary"), "Accept", value)
+			assert.Empty(s.T(), response.Header.Get("Content-Type"), value)
+			body, err := io.ReadAll(response.Body)
+			assert.NoError(s.T(), err)
+			assert.Empty(s.T(), body, value)
+		}
+	})
+
+	s.Run("success - compact format not modified", func() {
+		response := getDeck("If-None-Match", `"1"`, "?format=compact")
+
+		assert.Equal(s.T(), http.StatusNotModified, response.StatusCode)
+	})
+
+	s.Run("success - modified by If-None-Match", func() {
+		for _, value := range []string{`"0"`, `"0", W/"2"`} {
+			response := getDeck("If-None-Match", value, "")
+
+			assert.Equal(s.T(), http.StatusOK, response.StatusCode, value)
+		}
+	})
+
+	s.Run("success - not modified by If-Modified-Since", func() {
+		for _, value := range []string{"Sat, 01 Jan 2022 01:00:00 GMT", "Sat, 01 Jan 2022 02:00:00 GMT"} {
+			response := getDeck("If-Modified-Since", value, "")
+
+			assert.Equal(s.T(), http.StatusNotModified, response.StatusCode, value)
+		}
+	})
+
+	s.Run("success - modified by If-Modified-Since", func() {
+		for _, value := range []string{"Sat, 01 Jan 2022 00:59:59 GMT", "yesterday"} {
+			response := getDeck("If-Modified-Since", value, "")
+
+			assert.Equal(s.T(), http.StatusOK, response.StatusCode, value)
+		}
+	})
+
+	s.Run("success - If-Modified-Since ignored with If-None-Match", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid-abc-def", nil)
+		r.SetPathValue("id", defaultDeck.ID)
+		r.Header.Set("If-None-Match", `"0"`)
+		r.Header.Set("If-Modified-Since", "Sat, 01 Jan 2022 02:00:00 GMT")
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().GetDeck(r.Context(), defaultDeck.ID).Return(defaultDeck, nil)
+
+		rest.NewHandler(s.svc).GetDeck(w, r)
+
+		assert.Equal(s.T(), http.StatusOK, w.Result().StatusCode)
+	})
+}
@@ -1,6 +1,10 @@
 package rest
 
-import "github.com/raymondwongso/carddeck/modules/carddeck/entity"
+import (
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
 
 // CreateDeckResponse contains simplified deck information, only showing the ID, shuffled and remaining fields.
 type CreateDeckResponse struct {
@@ -9,7 +13,56 @@ type CreateDeckResponse struct {
 	Remaining int64  `json:"remaining"`
 }
 
+// CompactDeckResponse defines response for GET /decks/{id}?format=compact.
+// Compact can be passed back to POST /decks to restore the deck position.
+type CompactDeckResponse struct {
+	ID        string `json:"id"`
+	Shuffled  bool   `json:"shuffled"`
+	Remaining int64  `json:"remaining"`
+	Compact   string `json:"compact"`
+}
+
+// DeckResponse defines response for GET /decks/{id}, cards carrying display fields
+type DeckResponse struct {
+	ID        string          `json:"id"`
+	Shuffled  bool            `json:"shuffled"`
+	Remaining int64           `json:"remaining"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Cards     []*CardResponse `json:"cards"`
+}
+
 // DrawCardResponse defines custom response for GET /decks/{id}/cards
 type DrawCardResponse struct {
-	Cards *entity.Cards `json:"cards"`
+	Cards []*CardResponse `json:"cards"`
+}
+
+// CardResponse defines card with its name in language of Accept-Language header and path of its SVG image.
+// Rendered is only returned when render parameter is supplied.
+type CardResponse struct {
+	*entity.Card
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Rendered string `json:"rendered,omitempty"`
+}
+
+// BatchRequest defines request body for POST /batch
+type BatchRequest struct {
+	Operations []*entity.BatchOperation `json:"operations"`
+}
+
+// BatchResponse defines response for POST /batch, containing result of every operation in request order
+type BatchResponse struct {
+	Results []*entity.BatchResult `json:"results"`
+}
+
+// ProblemResponse defines RFC 7807 problem details, returned instead of entity.Error when client accepts application/problem+json.
+// Type is relative URI reference "/problems/{code}" of the entity.Error code.
+type ProblemResponse struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail"`
+	Instance string                `json:"instance"`
+	Details  []*entity.ErrorDetail `json:"error_details,omitempty"`
 }
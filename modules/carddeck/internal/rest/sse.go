@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// sseHeartbeatPeriod keeps idle streams from being closed by proxies
+const sseHeartbeatPeriod = 15 * time.Second
+
+// @summary		Stream changes of specific deck as Server-Sent Events
+// @description	Sends an event every time the deck is drawn from, shuffled or returned to.
+// @description	Reconnecting clients sending Last-Event-ID header receive the events they missed first.
+// @tags			carddeck
+// @produce		text/event-stream
+// @param			id				path	string	true	"ID of the deck"
+// @param			Last-Event-ID	header	string	false	"ID of the last event received, events after it are replayed"
+// @success		200	{object}	entity.DeckEvent
+// @router			/decks/{id}/events [get]
+func (h *Handler) StreamDeckEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	lastEventID, resume, err := parseLastEventID(r)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/events] error parsing Last-Event-ID header")
+		handleError(w, r, err)
+		return
+	}
+
+	// subscribe before reading missed events, so nothing happening in between is lost
+	events, unsubscribe, err := h.svc.SubscribeDeck(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/events] error subscribing deck")
+		handleError(w, r, err)
+		return
+	}
+	defer unsubscribe()
+
+	var missed []*entity.DeckEvent
+	if resume {
+		missed, err = h.svc.ListDeckEvents(r.Context(), id, lastEventID)
+		if err != nil {
+			log.Error().Err(err).Msg("[GET /decks/{id}/events] error listing missed events")
+			handleError(w, r, err)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// disable response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range missed {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+		lastEventID = ev.ID
+	}
+	if err := rc.Flush(); err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/events] error flushing response")
+		return
+	}
+
+	ticker := time.NewTicker(sseHeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// subscription dropped (slow client) or server is shutting down, client reconnects with Last-Event-ID
+				return
+			}
+			if ev.ID != 0 && ev.ID <= lastEventID {
+				// already sent while replaying missed events
+				continue
+			}
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			lastEventID = ev.ID
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			log.Error().Err(err).Msg("[GET /decks/{id}/events] error flushing response")
+			return
+		}
+	}
+}
+
+// writeEvent writes event in text/event-stream format, events without ID are sent without id field
+func writeEvent(w http.ResponseWriter, ev *entity.DeckEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/events] error encoding event")
+		return err
+	}
+
+	if ev.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", ev.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
+
+// parseLastEventID returns ID sent in Last-Event-ID header and whether the header is present
+func parseLastEventID(r *http.Request) (int64, bool, error) {
+	header := r.Header.Get("Last-Event-ID")
+	if header == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || id < 0 {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("Last-Event-ID", "Last-Event-ID header must be an event ID"))
+		return 0, false, err
+	}
+
+	return id, true, nil
+}
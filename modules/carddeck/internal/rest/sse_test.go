@@ -0,0 +1,126 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *HandlerTestSuite) TestStreamDeckEvents() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	newServer := func() *httptest.Server {
+		h := rest.NewHandler(s.svc)
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/events", h.StreamDeckEvents)
+		return httptest.NewServer(mux)
+	}
+	newEvent := func(id int64) *entity.DeckEvent {
+		return &entity.DeckEvent{ID: id, Type: entity.DeckEventDrawn, DeckID: tempID, Version: id, OccurredAt: defaultTime}
+	}
+	formatEvent := func(ev *entity.DeckEvent) string {
+		data, err := json.Marshal(ev)
+		assert.NoError(s.T(), err)
+		if ev.ID == 0 {
+			return fmt.Sprintf("event: %s\ndata: %s\n\n", ev.Type, data)
+		}
+		return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	}
+
+	s.Run("success - missed events are replayed before live events", func() {
+		events := make(chan *entity.DeckEvent, 3)
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() {}, nil)
+		s.svc.EXPECT().ListDeckEvents(gomock.Any(), tempID, int64(4)).Return([]*entity.DeckEvent{newEvent(5), newEvent(6)}, nil)
+
+		// event 6 is published while the missed events are read, it must not be sent twice
+		events <- newEvent(6)
+		events <- newEvent(7)
+		close(events)
+
+		server := newServer()
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/decks/"+tempID+"/events", nil)
+		assert.NoError(s.T(), err)
+		req.Header.Set("Last-Event-ID", "4")
+
+		response, err := http.DefaultClient.Do(req)
+		assert.NoError(s.T(), err)
+		defer response.Body.Close()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "text/event-stream", response.Header.Get("Content-Type"))
+		assert.Equal(s.T(), "no-cache", response.Header.Get("Cache-Control"))
+
+		// stream ends when the subscription is closed
+		body, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), formatEvent(newEvent(5))+formatEvent(newEvent(6))+formatEvent(newEvent(7)), string(body))
+	})
+
+	s.Run("success - without Last-Event-ID only live events are sent", func() {
+		events := make(chan *entity.DeckEvent, 1)
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() {}, nil)
+
+		unpersisted := newEvent(0)
+		events <- unpersisted
+		close(events)
+
+		server := newServer()
+		defer server.Close()
+
+		response, err := http.Get(server.URL + "/decks/" + tempID + "/events")
+		assert.NoError(s.T(), err)
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), formatEvent(unpersisted), string(body))
+	})
+
+	s.Run("failed - Last-Event-ID invalid", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/events", tempID), nil)
+		r.Header.Set("Last-Event-ID", "abc")
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.StreamDeckEvents(w, r)
+
+		assert.Equal(s.T(), http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("failed - deck not found", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/events", tempID), nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), gomock.Any()).Return(nil, nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		h := rest.NewHandler(s.svc)
+		h.StreamDeckEvents(w, r)
+
+		assert.Equal(s.T(), http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	s.Run("failed - listing missed events failed", func() {
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost/decks/%s/events", tempID), nil)
+		r.Header.Set("Last-Event-ID", "4")
+		w := httptest.NewRecorder()
+
+		unsubscribed := false
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), gomock.Any()).Return(make(<-chan *entity.DeckEvent), func() { unsubscribed = true }, nil)
+		s.svc.EXPECT().ListDeckEvents(gomock.Any(), gomock.Any(), int64(4)).Return(nil, fmt.Errorf("some error"))
+
+		h := rest.NewHandler(s.svc)
+		h.StreamDeckEvents(w, r)
+
+		assert.Equal(s.T(), http.StatusInternalServerError, w.Result().StatusCode)
+		assert.True(s.T(), unsubscribed)
+	})
+}
@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateWebhookRequest defines request body for POST /webhooks
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret signs every delivery, see X-Carddeck-Signature header. It is never returned.
+	Secret string `json:"secret"`
+}
+
+// WebhookDeadLettersResponse defines response for GET /webhooks/{id}/dead-letters
+type WebhookDeadLettersResponse struct {
+	DeadLetters []*entity.WebhookDelivery `json:"dead_letters"`
+}
+
+// @summary		Subscribe URL to deck lifecycle events
+// @description	Every delivery is a POST of JSON payload signed with HMAC-SHA256 of the secret, see README.
+// @tags			webhook
+// @accept			json,application/msgpack,application/cbor
+// @produce		json,application/msgpack,application/cbor
+// @param			request	body	CreateWebhookRequest	true	"URL, subscribed events (deck.created, deck.exhausted, deck.deleted) and secret"
+// @success		201		{object}	entity.Webhook
+// @router			/webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := decodeRequest(w, r, &req, h.maxBodySize); err != nil {
+		log.Error().Err(err).Msg("[POST /webhooks] error decoding request body")
+		handleError(w, r, bodyError(err, "request body is not a valid webhook"))
+		return
+	}
+
+	webhook, err := h.svc.CreateWebhook(r.Context(), &entity.Webhook{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("[POST /webhooks] error creating webhook")
+
+		handleError(w, r, err)
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, webhook)
+}
+
+// @summary	Delete webhook together with its pending and dead deliveries
+// @tags		webhook
+// @param		id	path	string	true	"ID of the webhook"
+// @success	204
+// @router		/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.svc.DeleteWebhook(r.Context(), id); err != nil {
+		log.Error().Err(err).Msg("[DELETE /webhooks/{id}] error deleting webhook")
+
+		handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @summary	List deliveries of webhook that failed every attempt
+// @tags		webhook
+// @produce	json,application/msgpack,application/cbor
+// @param		id	path	string	true	"ID of the webhook"
+// @success	200	{object}	WebhookDeadLettersResponse
+// @router		/webhooks/{id}/dead-letters [get]
+func (h *Handler) ListWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	deliveries, err := h.svc.ListWebhookDeadLetters(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /webhooks/{id}/dead-letters] error listing dead letters")
+
+		handleError(w, r, err)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, &WebhookDeadLettersResponse{DeadLetters: deliveries})
+}
@@ -0,0 +1,155 @@
+package rest_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *HandlerTestSuite) TestCreateWebhook() {
+	body := `{"url":"https://example.com/hook","events":["deck.created","deck.deleted"],"secret":"0123456789abcdef"}`
+
+	s.Run("success - secret is not returned", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/webhooks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().CreateWebhook(gomock.Any(), &entity.Webhook{
+			URL:    "https://example.com/hook",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated, entity.WebhookEventDeckDeleted},
+			Secret: "0123456789abcdef",
+		}).DoAndReturn(func(_ any, webhook *entity.Webhook) (*entity.Webhook, error) {
+			webhook.ID = "webhook-1"
+			webhook.CreatedAt = defaultTime
+			return webhook, nil
+		})
+
+		h := rest.NewHandler(s.svc)
+		h.CreateWebhook(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(),
+			`{"id":"webhook-1","url":"https://example.com/hook","events":["deck.created","deck.deleted"],"created_at":"2022-01-01T01:00:00Z"}`,
+			strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - body invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/webhooks", strings.NewReader(`not json`))
+		w := httptest.NewRecorder()
+
+		h := rest.NewHandler(s.svc)
+		h.CreateWebhook(w, r)
+
+		assert.Equal(s.T(), http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("failed - webhook invalid", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/webhooks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Return(nil, entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid))
+
+		h := rest.NewHandler(s.svc)
+		h.CreateWebhook(w, r)
+
+		assert.Equal(s.T(), http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("failed - unexpected error", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/webhooks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+		h := rest.NewHandler(s.svc)
+		h.CreateWebhook(w, r)
+
+		assert.Equal(s.T(), http.StatusInternalServerError, w.Result().StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestDeleteWebhook() {
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodDelete, "http://localhost/webhooks/webhook-1", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DeleteWebhook(gomock.Any(), "webhook-1").Return(nil)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("DELETE /webhooks/{id}", rest.NewHandler(s.svc).DeleteWebhook)
+		mux.ServeHTTP(w, r)
+
+		assert.Equal(s.T(), http.StatusNoContent, w.Result().StatusCode)
+	})
+
+	s.Run("failed - webhook not found", func() {
+		r := httptest.NewRequest(http.MethodDelete, "http://localhost/webhooks/webhook-1", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().DeleteWebhook(gomock.Any(), "webhook-1").Return(entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound))
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("DELETE /webhooks/{id}", rest.NewHandler(s.svc).DeleteWebhook)
+		mux.ServeHTTP(w, r)
+
+		assert.Equal(s.T(), http.StatusNotFound, w.Result().StatusCode)
+	})
+}
+
+func (s *HandlerTestSuite) TestListWebhookDeadLetters() {
+	s.Run("success", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/webhooks/webhook-1/dead-letters", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ListWebhookDeadLetters(gomock.Any(), "webhook-1").Return([]*entity.WebhookDelivery{{
+			ID:             3,
+			WebhookID:      "webhook-1",
+			EventType:      entity.WebhookEventDeckDeleted,
+			Payload:        []byte(`{"type":"deck.deleted"}`),
+			Status:         entity.WebhookDeliveryDead,
+			Attempts:       8,
+			LastStatusCode: 500,
+			LastError:      "unexpected status code 500",
+			CreatedAt:      defaultTime,
+			UpdatedAt:      defaultTime,
+			URL:            "https://example.com/hook",
+			Secret:         "0123456789abcdef",
+		}}, nil)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /webhooks/{id}/dead-letters", rest.NewHandler(s.svc).ListWebhookDeadLetters)
+		mux.ServeHTTP(w, r)
+		response := w.Result()
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+
+		rawResponseBody, err := io.ReadAll(response.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(),
+			`{"dead_letters":[{"id":3,"webhook_id":"webhook-1","event_type":"deck.deleted","payload":{"type":"deck.deleted"},"status":"dead","attempts":8,"last_status_code":500,"last_error":"unexpected status code 500","created_at":"2022-01-01T01:00:00Z","updated_at":"2022-01-01T01:00:00Z"}]}`,
+			strings.TrimSuffix(string(rawResponseBody), "\n"))
+	})
+
+	s.Run("failed - webhook not found", func() {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/webhooks/webhook-1/dead-letters", nil)
+		w := httptest.NewRecorder()
+
+		s.svc.EXPECT().ListWebhookDeadLetters(gomock.Any(), "webhook-1").Return(nil, entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound))
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /webhooks/{id}/dead-letters", rest.NewHandler(s.svc).ListWebhookDeadLetters)
+		mux.ServeHTTP(w, r)
+
+		assert.Equal(s.T(), http.StatusNotFound, w.Result().StatusCode)
+	})
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// wsWriteWait is time allowed to write a message to the client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is time allowed to read the next pong message from the client
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be less than wsPongWait, so the client has time to answer
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsReadLimit is maximum size of message read from the client, clients are not expected to send anything
+	wsReadLimit = 512
+)
+
+// newUpgrader returns upgrader of WebSocket connections, only accepting handshakes from the same host until WithCheckOrigin is used
+func newUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+}
+
+// @summary		Watch changes of specific deck over WebSocket
+// @description	Pushes a JSON event every time the deck is drawn from, shuffled or returned to.
+// @description	The connection is closed by the server when the client falls too far behind or the server shuts down.
+// @tags			carddeck
+// @produce		json
+// @param			id	path	string	true	"ID of the deck"
+// @success		101	{object}	entity.DeckEvent
+// @router			/decks/{id}/ws [get]
+func (h *Handler) WatchDeck(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	events, unsubscribe, err := h.svc.SubscribeDeck(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/ws] error subscribing deck")
+		handleError(w, r, err)
+		return
+	}
+	defer unsubscribe()
+
+	// upgrader writes the error response itself
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("[GET /decks/{id}/ws] error upgrading connection")
+		return
+	}
+	defer conn.Close()
+
+	streamEvents(conn, events)
+}
+
+// streamEvents writes events to the connection until the subscription ends or the client goes away.
+func streamEvents(conn *websocket.Conn, events <-chan *entity.DeckEvent) {
+	done := make(chan struct{})
+	go readPump(conn, done)
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// subscription dropped (slow client) or server is shutting down
+				closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "subscription ended")
+				_ = conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(wsWriteWait))
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(ev); err != nil {
+				log.Error().Err(err).Msg("[GET /decks/{id}/ws] error writing event")
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				log.Error().Err(err).Msg("[GET /decks/{id}/ws] error writing ping")
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// readPump handles pong and close messages, done is closed when the client goes away or stops answering pings.
+func readPump(conn *websocket.Conn, done chan struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(wsReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
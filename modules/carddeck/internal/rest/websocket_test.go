@@ -0,0 +1,105 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *HandlerTestSuite) TestWatchDeck() {
+	tempID := "3cdc5e5a-8f56-4f70-91e6-bd564d04ce79"
+
+	newServer := func(opts ...rest.HandlerOption) *httptest.Server {
+		h := rest.NewHandler(s.svc, opts...)
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /decks/{id}/ws", h.WatchDeck)
+		return httptest.NewServer(mux)
+	}
+	wsURL := func(server *httptest.Server) string {
+		return "ws" + strings.TrimPrefix(server.URL, "http") + "/decks/" + tempID + "/ws"
+	}
+
+	s.Run("success - events are pushed until subscription ends", func() {
+		events := make(chan *entity.DeckEvent, 1)
+		unsubscribed := make(chan struct{})
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() { close(unsubscribed) }, nil)
+
+		server := newServer()
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL(server), nil)
+		assert.NoError(s.T(), err)
+		defer conn.Close()
+
+		sent := &entity.DeckEvent{Type: entity.DeckEventDrawn, DeckID: tempID, Version: 2, Remaining: 50, Cards: &defaultCards, OccurredAt: defaultTime}
+		events <- sent
+
+		var received entity.DeckEvent
+		assert.NoError(s.T(), conn.ReadJSON(&received))
+		assert.Equal(s.T(), *sent, received)
+
+		// hub closes the channel on shutdown or when the client is too slow
+		close(events)
+		_, _, err = conn.ReadMessage()
+		assert.True(s.T(), websocket.IsCloseError(err, websocket.CloseGoingAway))
+		<-unsubscribed
+	})
+
+	s.Run("success - client disconnect stops the subscription", func() {
+		events := make(chan *entity.DeckEvent)
+		unsubscribed := make(chan struct{})
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() { close(unsubscribed) }, nil)
+
+		server := newServer()
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL(server), nil)
+		assert.NoError(s.T(), err)
+		conn.Close()
+
+		<-unsubscribed
+	})
+
+	checkOrigin := func(r *http.Request) bool { return r.Header.Get("Origin") == "https://game.example.com" }
+
+	s.Run("success - origin allowed by check", func() {
+		events := make(chan *entity.DeckEvent)
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() {}, nil)
+
+		server := newServer(rest.WithCheckOrigin(checkOrigin))
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL(server), http.Header{"Origin": {"https://game.example.com"}})
+		assert.NoError(s.T(), err)
+		conn.Close()
+	})
+
+	s.Run("failed - origin not allowed", func() {
+		events := make(chan *entity.DeckEvent)
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return((<-chan *entity.DeckEvent)(events), func() {}, nil)
+
+		server := newServer(rest.WithCheckOrigin(checkOrigin))
+		defer server.Close()
+
+		_, response, err := websocket.DefaultDialer.Dial(wsURL(server), http.Header{"Origin": {"https://evil.example.com"}})
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), http.StatusForbidden, response.StatusCode)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.svc.EXPECT().SubscribeDeck(gomock.Any(), tempID).Return(nil, nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		server := newServer()
+		defer server.Close()
+
+		_, response, err := websocket.DefaultDialer.Dial(wsURL(server), nil)
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), http.StatusNotFound, response.StatusCode)
+	})
+}
@@ -0,0 +1,66 @@
+// Package retention implements periodic deletion of rows the server no longer needs
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Task deletes rows no longer needed, returning number of deleted rows
+type Task struct {
+	// Name identifies the task in logs
+	Name  string
+	Purge func(ctx context.Context) (int64, error)
+}
+
+// Purger runs its tasks every interval
+type Purger struct {
+	interval time.Duration
+	tasks    []Task
+}
+
+// NewPurger creates new purger running tasks every interval
+func NewPurger(interval time.Duration, tasks ...Task) *Purger {
+	return &Purger{
+		interval: interval,
+		tasks:    tasks,
+	}
+}
+
+// Run runs every task once and then every interval until ctx is done
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeAll runs every task once, returning number of rows deleted by each task.
+// Failed tasks are logged and retried on the next run, they do not stop the other tasks.
+func (p *Purger) PurgeAll(ctx context.Context) map[string]int64 {
+	deleted := make(map[string]int64, len(p.tasks))
+	for _, task := range p.tasks {
+		n, err := task.Purge(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Str("task", task.Name).Msg("[retention] error purging rows")
+			}
+			continue
+		}
+		deleted[task.Name] = n
+		if n > 0 {
+			log.Info().Str("task", task.Name).Int64("deleted", n).Msg("[retention] purged rows")
+		}
+	}
+	return deleted
+}
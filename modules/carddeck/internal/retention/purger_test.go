@@ -0,0 +1,62 @@
+package retention_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/retention"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeAll(t *testing.T) {
+	t.Run("success - every task is run", func(t *testing.T) {
+		purger := retention.NewPurger(time.Hour,
+			retention.Task{Name: "first", Purge: func(context.Context) (int64, error) { return 2, nil }},
+			retention.Task{Name: "second", Purge: func(context.Context) (int64, error) { return 0, nil }},
+		)
+
+		assert.Equal(t, map[string]int64{"first": 2, "second": 0}, purger.PurgeAll(context.Background()))
+	})
+
+	t.Run("success - failed task does not stop the others", func(t *testing.T) {
+		purger := retention.NewPurger(time.Hour,
+			retention.Task{Name: "first", Purge: func(context.Context) (int64, error) { return 0, errors.New("some error") }},
+			retention.Task{Name: "second", Purge: func(context.Context) (int64, error) { return 3, nil }},
+		)
+
+		assert.Equal(t, map[string]int64{"second": 3}, purger.PurgeAll(context.Background()))
+	})
+}
+
+func TestRun(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	purger := retention.NewPurger(10*time.Millisecond, retention.Task{Name: "task", Purge: func(context.Context) (int64, error) {
+		runs <- struct{}{}
+		return 0, nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(done)
+	}()
+
+	// the first run is immediate, the second one is after the interval
+	for i := 0; i < 2; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("task was not run")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purger did not stop")
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// CreateAPIKey generates new API key of the tenant, Key of the returned API key is the only time the key is revealed.
+// Empty tenantID creates new tenant named after the API key.
+// will return error when:
+//
+//	name is too long
+//	tenant not found
+func (s *Service) CreateAPIKey(ctx context.Context, name string, tenantID string) (*entity.APIKey, error) {
+	if s.apiKeyRepository == nil || s.tenantRepository == nil {
+		return nil, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if len(name) > entity.APIKeyNameMaxLength {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("name", fmt.Sprintf("name must not be longer than %d characters", entity.APIKeyNameMaxLength)))
+		return nil, err
+	}
+
+	apiKey, err := entity.NewAPIKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		tenant, err := s.apiKeyTenant(ctx, name, tenantID)
+		if err != nil {
+			return err
+		}
+
+		apiKey.TenantID = tenant.ID
+		apiKey, err = s.apiKeyRepository.Insert(ctx, apiKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+// apiKeyTenant returns tenant of new API key, creating it when tenantID is empty
+func (s *Service) apiKeyTenant(ctx context.Context, name string, tenantID string) (*entity.Tenant, error) {
+	if tenantID == "" {
+		return s.tenantRepository.Insert(ctx, &entity.Tenant{Name: name})
+	}
+
+	return s.tenantRepository.GetByID(ctx, tenantID)
+}
+
+// RevokeAPIKey revokes the API key, requests with it are rejected while other API keys of its tenant keep working
+// will return error when:
+//
+//	API key not found
+func (s *Service) RevokeAPIKey(ctx context.Context, id string) error {
+	if s.apiKeyRepository == nil {
+		return entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return err
+	}
+
+	return s.apiKeyRepository.Revoke(ctx, id)
+}
+
+// AuthenticateAPIKey returns API key matching the key
+// will return error when:
+//
+//	key is unknown or revoked
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error) {
+	if s.apiKeyRepository == nil {
+		return nil, entity.NewError(entity.ErrAPIKeyInvalid, entity.ErrMsgAPIKeyInvalid)
+	}
+
+	apiKey, err := s.apiKeyRepository.GetByHash(ctx, entity.HashAPIKey(key))
+	if err != nil {
+		if perr, ok := err.(*entity.Error); ok && perr.Code == entity.ErrAPIKeyNotFound {
+			return nil, entity.NewError(entity.ErrAPIKeyInvalid, entity.ErrMsgAPIKeyInvalid)
+		}
+		return nil, err
+	}
+
+	if apiKey.Revoked() {
+		return nil, entity.NewError(entity.ErrAPIKeyInvalid, entity.ErrMsgAPIKeyInvalid)
+	}
+
+	return apiKey, nil
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceTestSuite) TestCreateAPIKey() {
+	ctx := context.Background()
+	opts := []service.Option{service.WithAPIKeyRepository(s.apiKeyRepo), service.WithTenantRepository(s.tenantRepo)}
+
+	s.Run("success - new tenant", func() {
+		s.expectTransaction()
+		s.tenantRepo.EXPECT().Insert(ctx, &entity.Tenant{Name: "studio"}).Return(&entity.Tenant{ID: "tenant-1", Name: "studio"}, nil)
+		s.apiKeyRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, apiKey *entity.APIKey) (*entity.APIKey, error) {
+			apiKey.ID = "api-key-1"
+			return apiKey, nil
+		})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		apiKey, err := svc.CreateAPIKey(ctx, "studio", "")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "api-key-1", apiKey.ID)
+		assert.Equal(s.T(), "tenant-1", apiKey.TenantID)
+		assert.Equal(s.T(), "studio", apiKey.Name)
+		assert.True(s.T(), strings.HasPrefix(apiKey.Key, "cdk_"))
+		assert.Equal(s.T(), entity.HashAPIKey(apiKey.Key), apiKey.Hash)
+	})
+
+	s.Run("success - existing tenant", func() {
+		s.expectTransaction()
+		s.tenantRepo.EXPECT().GetByID(ctx, "tenant-1").Return(&entity.Tenant{ID: "tenant-1"}, nil)
+		s.apiKeyRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, apiKey *entity.APIKey) (*entity.APIKey, error) {
+			return apiKey, nil
+		})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		apiKey, err := svc.CreateAPIKey(ctx, "ci", "tenant-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "tenant-1", apiKey.TenantID)
+	})
+
+	s.Run("failed - tenant not found", func() {
+		s.expectTransaction()
+		s.tenantRepo.EXPECT().GetByID(ctx, "tenant-1").Return(nil, entity.NewError(entity.ErrTenantNotFound, entity.ErrMsgTenantNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		apiKey, err := svc.CreateAPIKey(ctx, "ci", "tenant-1")
+		assert.Nil(s.T(), apiKey)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrTenantNotFound, perr.Code)
+	})
+
+	s.Run("failed - name too long", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		apiKey, err := svc.CreateAPIKey(ctx, strings.Repeat("a", 256), "")
+		assert.Nil(s.T(), apiKey)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+		assert.Equal(s.T(), "name", perr.Details[0].Field)
+	})
+}
+
+func (s *ServiceTestSuite) TestRevokeAPIKey() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.apiKeyRepo.EXPECT().Revoke(ctx, "api-key-1").Return(nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		assert.NoError(s.T(), svc.RevokeAPIKey(ctx, "api-key-1"))
+	})
+
+	s.Run("failed - API key not found", func() {
+		s.apiKeyRepo.EXPECT().Revoke(ctx, "api-key-1").Return(entity.NewError(entity.ErrAPIKeyNotFound, entity.ErrMsgAPIKeyNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		perr, ok := svc.RevokeAPIKey(ctx, "api-key-1").(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrAPIKeyNotFound, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestAuthenticateAPIKey() {
+	ctx := context.Background()
+	hash := entity.HashAPIKey("cdk_key")
+
+	s.Run("success", func() {
+		s.apiKeyRepo.EXPECT().GetByHash(ctx, hash).Return(&entity.APIKey{ID: "api-key-1", Hash: hash}, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		apiKey, err := svc.AuthenticateAPIKey(ctx, "cdk_key")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "api-key-1", apiKey.ID)
+	})
+
+	s.Run("failed - unknown key", func() {
+		s.apiKeyRepo.EXPECT().GetByHash(ctx, hash).Return(nil, entity.NewError(entity.ErrAPIKeyNotFound, entity.ErrMsgAPIKeyNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		apiKey, err := svc.AuthenticateAPIKey(ctx, "cdk_key")
+		assert.Nil(s.T(), apiKey)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrAPIKeyInvalid, perr.Code)
+	})
+
+	s.Run("failed - revoked key", func() {
+		revokedAt := defaultTime
+		s.apiKeyRepo.EXPECT().GetByHash(ctx, hash).Return(&entity.APIKey{ID: "api-key-1", Hash: hash, RevokedAt: &revokedAt}, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		_, err := svc.AuthenticateAPIKey(ctx, "cdk_key")
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrAPIKeyInvalid, perr.Code)
+	})
+
+	s.Run("failed - repository error", func() {
+		s.apiKeyRepo.EXPECT().GetByHash(ctx, hash).Return(nil, errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithAPIKeyRepository(s.apiKeyRepo))
+		_, err := svc.AuthenticateAPIKey(ctx, "cdk_key")
+		assert.EqualError(s.T(), err, "some error")
+	})
+}
@@ -3,9 +3,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/event"
 )
 
 var (
@@ -126,43 +130,149 @@ var (
 	}
 )
 
+// DeckEventReplayLimit is maximum number of missed events returned by ListDeckEvents
+const DeckEventReplayLimit = 1000
+
 // DeckRepository defines repository for accessing deck data
 type DeckRepository interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
 	Insert(ctx context.Context, deck *entity.Deck) (*entity.Deck, error)
 	GetByID(ctx context.Context, id string) (*entity.Deck, error)
-	DrawCards(ctx context.Context, id string, count int64) (*entity.Cards, error)
+	List(ctx context.Context, filter *entity.DeckFilter) ([]*entity.Deck, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*entity.Deck, error)
+	Update(ctx context.Context, deck *entity.Deck) (*entity.Deck, error)
+	DrawCards(ctx context.Context, id string, count int64, version int64) (*entity.Cards, *entity.Deck, error)
+	Delete(ctx context.Context, id string) error
+	CountActiveForUpdate(ctx context.Context) (int, error)
+}
+
+// EventRepository defines repository for persisted deck events
+type EventRepository interface {
+	Insert(ctx context.Context, event *entity.DeckEvent) (*entity.DeckEvent, error)
+	ListAfter(ctx context.Context, deckID string, afterID int64, limit int) ([]*entity.DeckEvent, error)
+}
+
+// WebhookRepository defines repository for webhooks and their outbox of deliveries
+type WebhookRepository interface {
+	Insert(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error)
+	GetByID(ctx context.Context, id string) (*entity.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	Enqueue(ctx context.Context, eventType string, payload []byte) error
+	ListDeadLetters(ctx context.Context, webhookID string) ([]*entity.WebhookDelivery, error)
+}
+
+// APIKeyRepository defines repository for API keys
+type APIKeyRepository interface {
+	Insert(ctx context.Context, apiKey *entity.APIKey) (*entity.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// TenantRepository defines repository for tenants
+type TenantRepository interface {
+	Insert(ctx context.Context, tenant *entity.Tenant) (*entity.Tenant, error)
+	GetByID(ctx context.Context, id string) (*entity.Tenant, error)
+	UpdateQuota(ctx context.Context, tenant *entity.Tenant) error
+}
+
+// EventBroker defines publish/subscribe of deck events
+type EventBroker interface {
+	Publish(event *entity.DeckEvent)
+	Subscribe(deckID string) (<-chan *entity.DeckEvent, func())
 }
 
 type Service struct {
-	deckRepository DeckRepository
-	generateRandom RandomGenerator
-	shuffleCard    CardShuffler
+	deckRepository    DeckRepository
+	generateRandom    RandomGenerator
+	shuffleCard       CardShuffler
+	eventBroker       EventBroker
+	eventRepository   EventRepository
+	webhookRepository WebhookRepository
+	apiKeyRepository  APIKeyRepository
+	tenantRepository  TenantRepository
+	tenantQuota       entity.TenantQuota
 }
 
 type RandomGenerator func() *rand.Rand
 type CardShuffler func(*rand.Rand, []*entity.Card) []*entity.Card
 
+// Option configures optional dependencies of the service
+type Option func(*Service)
+
+// WithEventBroker sets broker receiving deck events.
+// By default events are only published inside the service's own in-process hub.
+func WithEventBroker(broker EventBroker) Option {
+	return func(s *Service) {
+		s.eventBroker = broker
+	}
+}
+
+// WithEventRepository sets repository persisting deck events, so missed events can be replayed.
+// Without it events are only published and have no ID.
+func WithEventRepository(er EventRepository) Option {
+	return func(s *Service) {
+		s.eventRepository = er
+	}
+}
+
+// WithWebhookRepository sets repository of webhooks notified about deck lifecycle events.
+// Without it no webhook delivery is enqueued and webhooks can not be managed.
+func WithWebhookRepository(wr WebhookRepository) Option {
+	return func(s *Service) {
+		s.webhookRepository = wr
+	}
+}
+
+// WithAPIKeyRepository sets repository of API keys.
+// Without it API keys can not be managed and every API key is rejected.
+func WithAPIKeyRepository(ar APIKeyRepository) Option {
+	return func(s *Service) {
+		s.apiKeyRepository = ar
+	}
+}
+
+// WithTenantRepository sets repository of tenants.
+// Without it tenants can not be managed and every tenant has the default quota.
+func WithTenantRepository(tr TenantRepository) Option {
+	return func(s *Service) {
+		s.tenantRepository = tr
+	}
+}
+
+// WithDefaultTenantQuota sets quota of tenants not overriding it. By default tenants are unlimited.
+func WithDefaultTenantQuota(quota entity.TenantQuota) Option {
+	return func(s *Service) {
+		s.tenantQuota = quota
+	}
+}
+
 // New creates new carddeck service layer (usecase)
-func New(dr DeckRepository, randGenerator RandomGenerator, cardShuffler CardShuffler) *Service {
-	return &Service{
+func New(dr DeckRepository, randGenerator RandomGenerator, cardShuffler CardShuffler, opts ...Option) *Service {
+	s := &Service{
 		deckRepository: dr,
 		generateRandom: randGenerator,
 		shuffleCard:    cardShuffler,
+		eventBroker:    event.NewHub(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // CreateDeck create deck
 func (s *Service) CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error) {
-	cards := CardArray
+	// copy the default cards, so shuffling does not change CardArray order
+	cards := make([]*entity.Card, len(CardArray))
+	copy(cards, CardArray)
 
 	if len(cardCodes) > 0 {
-		cards = make([]*entity.Card, len(cardCodes))
-		for i, code := range cardCodes {
-			card, ok := CardMapping[code]
-			if !ok {
-				return nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid)
-			}
-			cards[i] = &card
+		var err error
+		cards, err = cardsFromCodes(cardCodes)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -170,8 +280,21 @@ func (s *Service) CreateDeck(ctx context.Context, shuffled bool, cardCodes []str
 		cards = s.shuffleCard(s.generateRandom(), cards)
 	}
 
-	deck := entity.NewDeck(shuffled, (*entity.Cards)(&cards))
-	return s.deckRepository.Insert(ctx, deck)
+	return s.insertDeck(ctx, entity.NewDeck(shuffled, (*entity.Cards)(&cards)))
+}
+
+// RestoreDeck creates new deck of the cards in the given order, e.g. decoded from compact encoding of another deck.
+// Unlike CreateDeck the cards are never shuffled, shuffled only tells whether the original deck was shuffled.
+// will return error when:
+//
+//	any card code is invalid
+func (s *Service) RestoreDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error) {
+	cards, err := cardsFromCodes(cardCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.insertDeck(ctx, entity.NewDeck(shuffled, (*entity.Cards)(&cards)))
 }
 
 // GetDeck get deck by ID
@@ -188,23 +311,460 @@ func (s *Service) GetDeck(ctx context.Context, id string) (*entity.Deck, error)
 	return s.deckRepository.GetByID(ctx, id)
 }
 
-// DrawCards draw cards according to n parameter
+// ListDecks returns decks matching the filter, ordered from the newest
+// will return error when:
+//
+//	filter is invalid
+func (s *Service) ListDecks(ctx context.Context, filter *entity.DeckFilter) ([]*entity.Deck, error) {
+	if filter == nil {
+		filter = &entity.DeckFilter{}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.deckRepository.List(ctx, filter)
+}
+
+// DrawCards draw cards according to n parameter, returning drawn cards and the deck after drawing.
+// version is the deck version the caller expects, 0 means any version.
 // Will return error when:
 //
 //	deck not found
+//	deck version is not the expected version
 //	n is larger than remaining card in deck
-func (s *Service) DrawCards(ctx context.Context, id string, n int64) (*entity.Cards, error) {
+func (s *Service) DrawCards(ctx context.Context, id string, n int64, version int64) (*entity.Cards, *entity.Deck, error) {
 	if id == "" {
 		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
 		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
-		return nil, err
+		return nil, nil, err
 	}
 
 	if n <= 0 {
 		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
 		err.AddDetail(entity.NewErrorDetail("count", "count must be bigger than 0"))
+		return nil, nil, err
+	}
+
+	var (
+		cards *entity.Cards
+		deck  *entity.Deck
+		ev    *entity.DeckEvent
+	)
+	err := s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		cards, deck, err = s.deckRepository.DrawCards(ctx, id, n, version)
+		if err != nil {
+			return err
+		}
+
+		if deck.Cards == nil || deck.Cards.Len() == 0 {
+			if err := s.enqueueWebhook(ctx, entity.WebhookEventDeckExhausted, deck); err != nil {
+				return err
+			}
+		}
+
+		ev, err = s.recordEvent(ctx, entity.NewDeckEvent(entity.DeckEventDrawn, deck, cards))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.publish(ctx, ev)
+	return cards, deck, nil
+}
+
+// ListDeckEvents returns persisted events of the deck having ID greater than afterID,
+// at most DeckEventReplayLimit events, ordered by ID.
+// Events are deleted together with the deck and once they are older than the retention of the server,
+// so events missed for longer than that are not returned.
+func (s *Service) ListDeckEvents(ctx context.Context, id string, afterID int64) ([]*entity.DeckEvent, error) {
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return nil, err
+	}
+
+	if s.eventRepository == nil {
+		return []*entity.DeckEvent{}, nil
+	}
+
+	return s.eventRepository.ListAfter(ctx, id, afterID, DeckEventReplayLimit)
+}
+
+// SubscribeDeck returns channel receiving events of the deck and function to stop the subscription.
+// will return error when:
+//
+//	deck not found
+func (s *Service) SubscribeDeck(ctx context.Context, id string) (<-chan *entity.DeckEvent, func(), error) {
+	if _, err := s.GetDeck(ctx, id); err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe := s.eventBroker.Subscribe(id)
+	return events, unsubscribe, nil
+}
+
+// ExportDeck exports deck into portable document
+// will return error when:
+//
+//	deck not found
+func (s *Service) ExportDeck(ctx context.Context, id string) (*entity.DeckExport, error) {
+	deck, err := s.GetDeck(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.NewDeckExport(deck, time.Now().UTC())
+}
+
+// ImportDeck creates new deck from export document, keeping the cards order as is
+// will return error when:
+//
+//	document is invalid (unsupported version, checksum mismatch, unknown card set or card code)
+func (s *Service) ImportDeck(ctx context.Context, doc *entity.DeckExport) (*entity.Deck, error) {
+	if doc == nil {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("document", "document is empty"))
+		return nil, err
+	}
+
+	cards, err := doc.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.insertDeck(ctx, entity.NewDeck(doc.Shuffled, &cards))
+}
+
+// DeleteDeck deletes the deck.
+// version is the deck version the caller expects, 0 means any version.
+// Will return error when:
+//
+//	deck not found
+//	deck version is not the expected version
+func (s *Service) DeleteDeck(ctx context.Context, id string, version int64) error {
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return err
+	}
+
+	return s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		deck, err := s.lockDeck(ctx, id, version)
+		if err != nil {
+			return err
+		}
+
+		if err := s.deckRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return s.enqueueWebhook(ctx, entity.WebhookEventDeckDeleted, deck)
+	})
+}
+
+// ShuffleDeck shuffles the remaining cards of the deck.
+// version is the deck version the caller expects, 0 means any version.
+// Will return error when:
+//
+//	deck not found
+//	deck version is not the expected version
+func (s *Service) ShuffleDeck(ctx context.Context, id string, version int64) (*entity.Deck, error) {
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return nil, err
+	}
+
+	var (
+		deck *entity.Deck
+		ev   *entity.DeckEvent
+	)
+	err := s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		deck, err = s.lockDeck(ctx, id, version)
+		if err != nil {
+			return err
+		}
+
+		var cards []*entity.Card
+		if deck.Cards != nil {
+			cards = s.shuffleCard(s.generateRandom(), *deck.Cards)
+		}
+		deck.Cards = (*entity.Cards)(&cards)
+		deck.Shuffled = true
+
+		deck, err = s.deckRepository.Update(ctx, deck)
+		if err != nil {
+			return err
+		}
+
+		ev, err = s.recordEvent(ctx, entity.NewDeckEvent(entity.DeckEventShuffled, deck, nil))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, ev)
+	return deck, nil
+}
+
+// ReturnCards puts cards back to the bottom of the deck.
+// version is the deck version the caller expects, 0 means any version.
+// Will return error when:
+//
+//	deck not found
+//	deck version is not the expected version
+//	card codes is empty or contains unknown code
+func (s *Service) ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*entity.Deck, error) {
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return nil, err
+	}
+
+	if len(cardCodes) == 0 {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("cards", "cards must not be empty"))
+		return nil, err
+	}
+
+	returned, err := cardsFromCodes(cardCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deck *entity.Deck
+		ev   *entity.DeckEvent
+	)
+	err = s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		deck, err = s.lockDeck(ctx, id, version)
+		if err != nil {
+			return err
+		}
+
+		var cards entity.Cards
+		if deck.Cards != nil {
+			cards = append(cards, *deck.Cards...)
+		}
+		cards = append(cards, returned...)
+		deck.Cards = &cards
+
+		deck, err = s.deckRepository.Update(ctx, deck)
+		if err != nil {
+			return err
+		}
+
+		ev, err = s.recordEvent(ctx, entity.NewDeckEvent(entity.DeckEventReturned, deck, (*entity.Cards)(&returned)))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, ev)
+	return deck, nil
+}
+
+// Batch executes operations in order inside a single transaction.
+// When an operation fails, the whole batch is rolled back and the error of failing operation is returned
+// together with results describing state of every operation.
+func (s *Service) Batch(ctx context.Context, operations []*entity.BatchOperation) ([]*entity.BatchResult, error) {
+	if len(operations) == 0 || len(operations) > entity.BatchMaxOperations {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("operations", fmt.Sprintf("operations must contain 1 to %d operation", entity.BatchMaxOperations)))
+		return nil, err
+	}
+
+	results := make([]*entity.BatchResult, len(operations))
+	for i, op := range operations {
+		results[i] = &entity.BatchResult{Op: op.Op, Status: entity.BatchStatusSkipped}
+	}
+
+	// events are held back until the transaction is committed
+	var events []*entity.DeckEvent
+	batchCtx := context.WithValue(ctx, pendingEventsKey{}, &events)
+
+	failed := -1
+	err := s.deckRepository.Transaction(batchCtx, func(ctx context.Context) error {
+		for i, op := range operations {
+			if err := s.execute(ctx, i, op, results); err != nil {
+				failed = i
+				return err
+			}
+			results[i].Status = entity.BatchStatusOK
+		}
+		return nil
+	})
+	if err != nil {
+		// failed stays -1 when every operation succeeded but the commit failed
+		for i, result := range results {
+			switch {
+			case i == failed:
+				perr, ok := err.(*entity.Error)
+				if !ok {
+					perr = entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+				}
+				results[i] = &entity.BatchResult{Op: result.Op, Status: entity.BatchStatusFailed, Error: perr}
+			case i < failed || failed < 0:
+				results[i] = &entity.BatchResult{Op: result.Op, Status: entity.BatchStatusRolledBack}
+			}
+		}
+		return results, err
+	}
+
+	for _, ev := range events {
+		s.eventBroker.Publish(ev)
+	}
+
+	return results, nil
+}
+
+func (s *Service) execute(ctx context.Context, index int, op *entity.BatchOperation, results []*entity.BatchResult) error {
+	if op.Op == entity.BatchOperationCreate {
+		deck, err := s.CreateDeck(ctx, op.Shuffled, op.Cards)
+		results[index].Deck = deck
+		return err
+	}
+
+	id, err := op.ResolveDeckID(index, results)
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case entity.BatchOperationDraw:
+		cards, _, err := s.DrawCards(ctx, id, op.Count, op.Version)
+		results[index].Cards = cards
+		return err
+	case entity.BatchOperationShuffle:
+		deck, err := s.ShuffleDeck(ctx, id, op.Version)
+		results[index].Deck = deck
+		return err
+	case entity.BatchOperationReturn:
+		deck, err := s.ReturnCards(ctx, id, op.Cards, op.Version)
+		results[index].Deck = deck
+		return err
+	default:
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail(fmt.Sprintf("operations[%d].op", index), "unknown operation"))
+		return err
+	}
+}
+
+// insertDeck checks quota of active decks of the tenant, then inserts the deck and enqueues its webhook deliveries in the same transaction
+func (s *Service) insertDeck(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
+	err := s.deckRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.checkDeckQuota(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		deck, err = s.deckRepository.Insert(ctx, deck)
+		if err != nil {
+			return err
+		}
+
+		return s.enqueueWebhook(ctx, entity.WebhookEventDeckCreated, deck)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deck, nil
+}
+
+// checkDeckQuota returns error when the tenant carried by ctx already has its quota of active decks, must be called inside transaction.
+// The count is locked until the end of transaction, so concurrent inserts of the tenant can not exceed the quota.
+func (s *Service) checkDeckQuota(ctx context.Context) error {
+	tenantID := entity.TenantFromContext(ctx)
+	if tenantID == "" {
+		return nil
+	}
+
+	quota, err := s.TenantQuota(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if quota.MaxActiveDecks == 0 {
+		return nil
+	}
+
+	active, err := s.deckRepository.CountActiveForUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	if active >= quota.MaxActiveDecks {
+		return entity.NewError(entity.ErrTenantDeckQuotaExceeded, entity.ErrMsgTenantDeckQuotaExceeded)
+	}
+
+	return nil
+}
+
+// lockDeck gets deck for update and checks its version, must be called inside transaction
+func (s *Service) lockDeck(ctx context.Context, id string, version int64) (*entity.Deck, error) {
+	deck, err := s.deckRepository.GetByIDForUpdate(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.deckRepository.DrawCards(ctx, id, n)
+	if version != 0 && deck.Version != version {
+		return nil, entity.NewError(entity.ErrDeckVersionMismatch, entity.ErrMsgDeckVersionMismatch)
+	}
+
+	return deck, nil
+}
+
+func cardsFromCodes(cardCodes []string) ([]*entity.Card, error) {
+	cards := make([]*entity.Card, len(cardCodes))
+	for i, code := range cardCodes {
+		card, ok := CardMapping[code]
+		if !ok {
+			return nil, entity.NewError(entity.ErrCardCodeInvalid, entity.ErrMsgCardCodeInvalid)
+		}
+		cards[i] = &card
+	}
+	return cards, nil
+}
+
+// recordEvent persists the event when event repository is set, must be called inside the mutation transaction
+func (s *Service) recordEvent(ctx context.Context, ev *entity.DeckEvent) (*entity.DeckEvent, error) {
+	if s.eventRepository == nil {
+		return ev, nil
+	}
+
+	return s.eventRepository.Insert(ctx, ev)
+}
+
+// pendingEventsKey is context key holding events published inside a batch
+type pendingEventsKey struct{}
+
+// publish sends event to the broker, or holds it back when called inside a batch
+func (s *Service) publish(ctx context.Context, ev *entity.DeckEvent) {
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*[]*entity.DeckEvent); ok {
+		*pending = append(*pending, ev)
+		return
+	}
+
+	s.eventBroker.Publish(ev)
+}
+
+// enqueueWebhook enqueues deliveries of the event to subscribed webhooks, must be called inside the mutation transaction
+func (s *Service) enqueueWebhook(ctx context.Context, eventType string, deck *entity.Deck) error {
+	if s.webhookRepository == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(entity.NewWebhookPayload(eventType, deck))
+	if err != nil {
+		return err
+	}
+
+	return s.webhookRepository.Enqueue(ctx, eventType, payload)
 }
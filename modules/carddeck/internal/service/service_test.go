@@ -52,6 +52,11 @@ var (
 type ServiceTestSuite struct {
 	suite.Suite
 	deckRepo      *mock_service.MockDeckRepository
+	eventRepo     *mock_service.MockEventRepository
+	eventBroker   *mock_service.MockEventBroker
+	webhookRepo   *mock_service.MockWebhookRepository
+	apiKeyRepo    *mock_service.MockAPIKeyRepository
+	tenantRepo    *mock_service.MockTenantRepository
 	randGenerator func() *rand.Rand
 	cardShuffler  func(r *rand.Rand, cards []*entity.Card) []*entity.Card
 }
@@ -59,6 +64,11 @@ type ServiceTestSuite struct {
 func (s *ServiceTestSuite) SetupSuite() {
 	ctrl := gomock.NewController(s.T())
 	s.deckRepo = mock_service.NewMockDeckRepository(ctrl)
+	s.eventRepo = mock_service.NewMockEventRepository(ctrl)
+	s.eventBroker = mock_service.NewMockEventBroker(ctrl)
+	s.webhookRepo = mock_service.NewMockWebhookRepository(ctrl)
+	s.apiKeyRepo = mock_service.NewMockAPIKeyRepository(ctrl)
+	s.tenantRepo = mock_service.NewMockTenantRepository(ctrl)
 	s.randGenerator = func() *rand.Rand {
 		return rand.New(rand.NewSource(defaultTime.Unix()))
 	}
@@ -75,6 +85,7 @@ func (s *ServiceTestSuite) TestCreateDeck() {
 	ctx := context.Background()
 
 	s.Run("success - no shuffle, no cards supplied", func() {
+		s.expectTransaction()
 		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
 			func(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
 				assert.Equal(s.T(), false, deck.Shuffled)
@@ -90,6 +101,7 @@ func (s *ServiceTestSuite) TestCreateDeck() {
 	})
 
 	s.Run("success - with shuffle, with cards supplied", func() {
+		s.expectTransaction()
 		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
 			func(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
 				assert.Equal(s.T(), true, deck.Shuffled)
@@ -121,7 +133,35 @@ func (s *ServiceTestSuite) TestCreateDeck() {
 		assert.Equal(s.T(), entity.ErrMsgCardCodeInvalid, perr.Message)
 	})
 
+	s.Run("success - webhook delivery is enqueued", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).Return(defaultDeck, nil)
+		s.webhookRepo.EXPECT().Enqueue(ctx, entity.WebhookEventDeckCreated, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, payload []byte) error {
+				assert.Contains(s.T(), string(payload), `"type":"deck.created"`)
+				assert.Contains(s.T(), string(payload), `"id":"some-uuid-abc-def"`)
+				return nil
+			})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		deck, err := svc.CreateDeck(ctx, false, nil)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), defaultDeck, deck)
+	})
+
+	s.Run("failed - deck is not created when webhook delivery can not be enqueued", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).Return(defaultDeck, nil)
+		s.webhookRepo.EXPECT().Enqueue(ctx, entity.WebhookEventDeckCreated, gomock.Any()).Return(errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		deck, err := svc.CreateDeck(ctx, false, nil)
+		assert.Nil(s.T(), deck)
+		assert.Error(s.T(), err)
+	})
+
 	s.Run("failed - unexpected error", func() {
+		s.expectTransaction()
 		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).Return(nil, errors.New("some error"))
 
 		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
@@ -157,23 +197,97 @@ func (s *ServiceTestSuite) TestGetDeck() {
 	})
 }
 
+func (s *ServiceTestSuite) TestListDecks() {
+	ctx := context.Background()
+
+	s.Run("success - default filter", func() {
+		s.deckRepo.EXPECT().List(ctx, &entity.DeckFilter{Limit: entity.DefaultDeckListLimit}).Return([]*entity.Deck{defaultDeck}, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		decks, err := svc.ListDecks(ctx, nil)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []*entity.Deck{defaultDeck}, decks)
+	})
+
+	s.Run("failed - filter invalid", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		decks, err := svc.ListDecks(ctx, &entity.DeckFilter{Limit: -1})
+		assert.Nil(s.T(), decks)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+		assert.Equal(s.T(), "limit", perr.Details[0].Field)
+	})
+}
+
 func (s *ServiceTestSuite) TestDrawCards() {
 	ctx := context.Background()
 	id := "some_id"
 	var n int64 = 2
 
 	s.Run("success", func() {
-		s.deckRepo.EXPECT().DrawCards(ctx, id, n).Return(&defaultCards, nil)
+		s.expectTransaction()
+		s.deckRepo.EXPECT().DrawCards(ctx, id, n, int64(0)).Return(&defaultCards, defaultDeck, nil)
+		s.eventRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, ev *entity.DeckEvent) (*entity.DeckEvent, error) {
+				ev.ID = 7
+				return ev, nil
+			})
+		s.eventBroker.EXPECT().Publish(gomock.Any()).Do(func(ev *entity.DeckEvent) {
+			assert.Equal(s.T(), int64(7), ev.ID)
+			assert.Equal(s.T(), entity.DeckEventDrawn, ev.Type)
+			assert.Equal(s.T(), defaultDeck.ID, ev.DeckID)
+			assert.Equal(s.T(), defaultDeck.Remaining(), ev.Remaining)
+			assert.Equal(s.T(), &defaultCards, ev.Cards)
+		})
 
-		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
-		cards, err := svc.DrawCards(ctx, id, n)
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker), service.WithEventRepository(s.eventRepo))
+		cards, deck, err := svc.DrawCards(ctx, id, n, 0)
 		assert.NoError(s.T(), err)
 		assert.Equal(s.T(), &defaultCards, cards)
+		assert.Equal(s.T(), defaultDeck, deck)
+	})
+
+	s.Run("success - webhook delivery is enqueued when deck is exhausted", func() {
+		exhausted := entity.NewDeck(false, &entity.Cards{})
+		exhausted.ID = id
+
+		s.expectTransaction()
+		s.deckRepo.EXPECT().DrawCards(ctx, id, n, int64(0)).Return(&defaultCards, exhausted, nil)
+		s.webhookRepo.EXPECT().Enqueue(ctx, entity.WebhookEventDeckExhausted, gomock.Any()).Return(nil)
+		s.eventBroker.EXPECT().Publish(gomock.Any())
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker), service.WithWebhookRepository(s.webhookRepo))
+		cards, _, err := svc.DrawCards(ctx, id, n, 0)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &defaultCards, cards)
+	})
+
+	s.Run("failed - draw is rolled back when event can not be persisted", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().DrawCards(ctx, id, n, int64(0)).Return(&defaultCards, defaultDeck, nil)
+		s.eventRepo.EXPECT().Insert(ctx, gomock.Any()).Return(nil, errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker), service.WithEventRepository(s.eventRepo))
+		cards, _, err := svc.DrawCards(ctx, id, n, 0)
+		assert.Nil(s.T(), cards)
+		assert.Error(s.T(), err)
+	})
+
+	s.Run("failed - no event published when draw fails", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().DrawCards(ctx, id, n, int64(0)).Return(nil, nil, entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		cards, _, err := svc.DrawCards(ctx, id, n, 0)
+		assert.Nil(s.T(), cards)
+		assert.Error(s.T(), err)
 	})
 
 	s.Run("failed - id empty", func() {
 		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
-		cards, err := svc.DrawCards(ctx, "", n)
+		cards, _, err := svc.DrawCards(ctx, "", n, 0)
 		assert.Nil(s.T(), cards)
 		assert.Error(s.T(), err)
 
@@ -185,7 +299,7 @@ func (s *ServiceTestSuite) TestDrawCards() {
 
 	s.Run("failed - count is zero", func() {
 		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
-		cards, err := svc.DrawCards(ctx, "", 0)
+		cards, _, err := svc.DrawCards(ctx, "", 0, 0)
 		assert.Nil(s.T(), cards)
 		assert.Error(s.T(), err)
 
@@ -195,3 +309,497 @@ func (s *ServiceTestSuite) TestDrawCards() {
 		assert.Equal(s.T(), perr.Message, entity.ErrMsgParamInvalid)
 	})
 }
+
+func (s *ServiceTestSuite) TestExportDeck() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success", func() {
+		s.deckRepo.EXPECT().GetByID(ctx, id).Return(defaultDeck, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		doc, err := svc.ExportDeck(ctx, id)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), entity.DeckExportVersion, doc.Version)
+		assert.Equal(s.T(), entity.StandardCardSetID, doc.CardSet)
+		assert.Equal(s.T(), []string{"AS", "2S", "3S"}, doc.Cards)
+		assert.Equal(s.T(), defaultDeck.ID, doc.Metadata.SourceID)
+
+		_, err = doc.Validate()
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.deckRepo.EXPECT().GetByID(ctx, id).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		doc, err := svc.ExportDeck(ctx, id)
+		assert.Nil(s.T(), doc)
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *ServiceTestSuite) TestRestoreDeck() {
+	ctx := context.Background()
+
+	s.Run("success - keep cards order and shuffled flag", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
+				assert.Equal(s.T(), true, deck.Shuffled)
+				assert.Equal(s.T(), &entity.Cards{
+					{Val: "3", Suit: "SPADE", Code: "3S"},
+					{Val: "ACE", Suit: "SPADE", Code: "AS"},
+				}, deck.Cards)
+
+				return shuffledDeck, nil
+			})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.RestoreDeck(ctx, true, []string{"3S", "AS"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), shuffledDeck, deck)
+	})
+
+	s.Run("failed - card code invalid", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		_, err := svc.RestoreDeck(ctx, false, []string{"XX"})
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *ServiceTestSuite) TestImportDeck() {
+	ctx := context.Background()
+
+	s.Run("success - keep cards order", func() {
+		doc, err := entity.NewDeckExport(shuffledDeck, defaultTime)
+		assert.NoError(s.T(), err)
+
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, deck *entity.Deck) (*entity.Deck, error) {
+				assert.Equal(s.T(), shuffledDeck.Shuffled, deck.Shuffled)
+				assert.Equal(s.T(), shuffledDeck.Cards, deck.Cards)
+
+				return shuffledDeck, nil
+			})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ImportDeck(ctx, doc)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), shuffledDeck, deck)
+	})
+
+	s.Run("failed - document is invalid", func() {
+		doc, err := entity.NewDeckExport(defaultDeck, defaultTime)
+		assert.NoError(s.T(), err)
+		doc.Cards[0] = "XX"
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ImportDeck(ctx, doc)
+		assert.Nil(s.T(), deck)
+		assert.Error(s.T(), err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckImportInvalid, perr.Code)
+	})
+
+	s.Run("failed - document is nil", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ImportDeck(ctx, nil)
+		assert.Nil(s.T(), deck)
+		assert.Error(s.T(), err)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) expectTransaction() {
+	s.deckRepo.EXPECT().Transaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func (s *ServiceTestSuite) lockedDeck() *entity.Deck {
+	deck := entity.NewDeck(false, &entity.Cards{
+		{Val: "ACE", Suit: "SPADE", Code: "AS"},
+		{Val: "2", Suit: "SPADE", Code: "2S"},
+		{Val: "3", Suit: "SPADE", Code: "3S"},
+	})
+	deck.ID = "some_id"
+	deck.Version = 2
+	return deck
+}
+
+func (s *ServiceTestSuite) TestShuffleDeck() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+		s.deckRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, deck *entity.Deck) (*entity.Deck, error) {
+				assert.Equal(s.T(), true, deck.Shuffled)
+				assert.Equal(s.T(), (*entity.Cards)(&shuffledCards), deck.Cards)
+				deck.Version++
+				return deck, nil
+			})
+
+		s.eventBroker.EXPECT().Publish(gomock.Any()).Do(func(ev *entity.DeckEvent) {
+			assert.Equal(s.T(), entity.DeckEventShuffled, ev.Type)
+			assert.Equal(s.T(), int64(3), ev.Version)
+			assert.True(s.T(), ev.Shuffled)
+		})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		deck, err := svc.ShuffleDeck(ctx, id, 2)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(3), deck.Version)
+		assert.Equal(s.T(), 3, deck.Remaining())
+	})
+
+	s.Run("failed - version mismatch", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ShuffleDeck(ctx, id, 1)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckVersionMismatch, perr.Code)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ShuffleDeck(ctx, id, 0)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+
+	s.Run("failed - id empty", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ShuffleDeck(ctx, "", 0)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestReturnCards() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success - cards put at the bottom", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+		s.deckRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, deck *entity.Deck) (*entity.Deck, error) {
+				assert.Equal(s.T(), &entity.Cards{
+					{Val: "ACE", Suit: "SPADE", Code: "AS"},
+					{Val: "2", Suit: "SPADE", Code: "2S"},
+					{Val: "3", Suit: "SPADE", Code: "3S"},
+					{Val: "KING", Suit: "HEART", Code: "KH"},
+				}, deck.Cards)
+				return deck, nil
+			})
+
+		s.eventBroker.EXPECT().Publish(gomock.Any()).Do(func(ev *entity.DeckEvent) {
+			assert.Equal(s.T(), entity.DeckEventReturned, ev.Type)
+			assert.Equal(s.T(), 4, ev.Remaining)
+			assert.Equal(s.T(), &entity.Cards{{Val: "KING", Suit: "HEART", Code: "KH"}}, ev.Cards)
+		})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		deck, err := svc.ReturnCards(ctx, id, []string{"KH"}, 0)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), 4, deck.Remaining())
+	})
+
+	s.Run("failed - card codes invalid", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ReturnCards(ctx, id, []string{"XX"}, 0)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrCardCodeInvalid, perr.Code)
+	})
+
+	s.Run("failed - card codes empty", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ReturnCards(ctx, id, nil, 0)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+
+	s.Run("failed - unexpected error", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+		s.deckRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil, errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		deck, err := svc.ReturnCards(ctx, id, []string{"KH"}, 0)
+		assert.Nil(s.T(), deck)
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *ServiceTestSuite) TestDeleteDeck() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+		s.deckRepo.EXPECT().Delete(ctx, id).Return(nil)
+		s.webhookRepo.EXPECT().Enqueue(ctx, entity.WebhookEventDeckDeleted, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, payload []byte) error {
+				assert.Contains(s.T(), string(payload), `"remaining":3`)
+				return nil
+			})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		err := svc.DeleteDeck(ctx, id, 2)
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("failed - version mismatch", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(s.lockedDeck(), nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		err := svc.DeleteDeck(ctx, id, 1)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckVersionMismatch, perr.Code)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().GetByIDForUpdate(ctx, id).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		err := svc.DeleteDeck(ctx, id, 0)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+
+	s.Run("failed - id empty", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		err := svc.DeleteDeck(ctx, "", 0)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestBatch() {
+	ctx := context.Background()
+
+	s.Run("success - operations reference created deck", func() {
+		// create, draw and shuffle join the batch transaction
+		s.expectTransaction()
+		s.expectTransaction()
+		s.expectTransaction()
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(defaultDeck, nil)
+		s.deckRepo.EXPECT().DrawCards(gomock.Any(), defaultDeck.ID, int64(1), int64(0)).Return(&entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, defaultDeck, nil)
+		s.deckRepo.EXPECT().GetByIDForUpdate(gomock.Any(), defaultDeck.ID).Return(s.lockedDeck(), nil)
+		s.deckRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, deck *entity.Deck) (*entity.Deck, error) {
+				return deck, nil
+			})
+
+		var published []string
+		s.eventBroker.EXPECT().Publish(gomock.Any()).Do(func(ev *entity.DeckEvent) {
+			published = append(published, ev.Type)
+		}).Times(2)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		results, err := svc.Batch(ctx, []*entity.BatchOperation{
+			{Op: entity.BatchOperationCreate},
+			{Op: entity.BatchOperationDraw, DeckID: "$0", Count: 1},
+			{Op: entity.BatchOperationShuffle, DeckID: "$0"},
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), []string{entity.DeckEventDrawn, entity.DeckEventShuffled}, published)
+		assert.Equal(s.T(), 3, len(results))
+		for _, result := range results {
+			assert.Equal(s.T(), entity.BatchStatusOK, result.Status)
+		}
+		assert.Equal(s.T(), defaultDeck, results[0].Deck)
+		assert.Equal(s.T(), &entity.Cards{{Val: "ACE", Suit: "SPADE", Code: "AS"}}, results[1].Cards)
+		assert.Equal(s.T(), true, results[2].Deck.Shuffled)
+	})
+
+	s.Run("failed - operation fails and every operation is rolled back", func() {
+		insufficientErr := entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient)
+
+		s.expectTransaction()
+		s.expectTransaction()
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(defaultDeck, nil)
+		s.deckRepo.EXPECT().DrawCards(gomock.Any(), defaultDeck.ID, int64(99), int64(0)).Return(nil, nil, insufficientErr)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		results, err := svc.Batch(ctx, []*entity.BatchOperation{
+			{Op: entity.BatchOperationCreate},
+			{Op: entity.BatchOperationDraw, DeckID: "$0", Count: 99},
+			{Op: entity.BatchOperationShuffle, DeckID: "$0"},
+		})
+		assert.Equal(s.T(), insufficientErr, err)
+		assert.Equal(s.T(), []*entity.BatchResult{
+			{Op: entity.BatchOperationCreate, Status: entity.BatchStatusRolledBack},
+			{Op: entity.BatchOperationDraw, Status: entity.BatchStatusFailed, Error: insufficientErr},
+			{Op: entity.BatchOperationShuffle, Status: entity.BatchStatusSkipped},
+		}, results)
+	})
+
+	s.Run("failed - unknown operation", func() {
+		s.expectTransaction()
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		results, err := svc.Batch(ctx, []*entity.BatchOperation{{Op: "deal", DeckID: "some_id"}})
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), entity.BatchStatusFailed, results[0].Status)
+		assert.Equal(s.T(), entity.ErrParamInvalid, results[0].Error.Code)
+		assert.Equal(s.T(), "operations[0].op", results[0].Error.Details[0].Field)
+	})
+
+	s.Run("failed - invalid reference", func() {
+		s.expectTransaction()
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		results, err := svc.Batch(ctx, []*entity.BatchOperation{{Op: entity.BatchOperationDraw, DeckID: "$0", Count: 1}})
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), "operations[0].deck_id", results[0].Error.Details[0].Field)
+	})
+
+	s.Run("failed - commit fails", func() {
+		s.deckRepo.EXPECT().Transaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				if err := fn(ctx); err != nil {
+					return err
+				}
+				return errors.New("commit error")
+			})
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(defaultDeck, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		results, err := svc.Batch(ctx, []*entity.BatchOperation{{Op: entity.BatchOperationCreate}})
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), []*entity.BatchResult{
+			{Op: entity.BatchOperationCreate, Status: entity.BatchStatusRolledBack},
+		}, results)
+	})
+
+	s.Run("failed - empty operations", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		results, err := svc.Batch(ctx, nil)
+		assert.Nil(s.T(), results)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestSubscribeDeck() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success", func() {
+		ch := make(chan *entity.DeckEvent)
+		s.deckRepo.EXPECT().GetByID(ctx, id).Return(defaultDeck, nil)
+		s.eventBroker.EXPECT().Subscribe(id).Return(ch, func() {})
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		events, unsubscribe, err := svc.SubscribeDeck(ctx, id)
+		assert.NoError(s.T(), err)
+		assert.NotNil(s.T(), unsubscribe)
+		assert.Equal(s.T(), (<-chan *entity.DeckEvent)(ch), events)
+	})
+
+	s.Run("success - default hub delivers published events", func() {
+		s.deckRepo.EXPECT().GetByID(ctx, defaultDeck.ID).Return(defaultDeck, nil)
+		s.expectTransaction()
+		s.deckRepo.EXPECT().DrawCards(ctx, defaultDeck.ID, int64(1), int64(0)).Return(&defaultCards, defaultDeck, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		events, unsubscribe, err := svc.SubscribeDeck(ctx, defaultDeck.ID)
+		assert.NoError(s.T(), err)
+		defer unsubscribe()
+
+		_, _, err = svc.DrawCards(ctx, defaultDeck.ID, 1, 0)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), entity.DeckEventDrawn, (<-events).Type)
+	})
+
+	s.Run("failed - deck not found", func() {
+		s.deckRepo.EXPECT().GetByID(ctx, id).Return(nil, entity.NewError(entity.ErrDeckNotFound, entity.ErrMsgDeckNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventBroker(s.eventBroker))
+		events, unsubscribe, err := svc.SubscribeDeck(ctx, id)
+		assert.Nil(s.T(), events)
+		assert.Nil(s.T(), unsubscribe)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrDeckNotFound, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestListDeckEvents() {
+	ctx := context.Background()
+	id := "some_id"
+
+	s.Run("success", func() {
+		events := []*entity.DeckEvent{{ID: 5, Type: entity.DeckEventDrawn, DeckID: id}}
+		s.eventRepo.EXPECT().ListAfter(ctx, id, int64(4), service.DeckEventReplayLimit).Return(events, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventRepository(s.eventRepo))
+		got, err := svc.ListDeckEvents(ctx, id, 4)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), events, got)
+	})
+
+	s.Run("success - events are not persisted", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		got, err := svc.ListDeckEvents(ctx, id, 4)
+		assert.NoError(s.T(), err)
+		assert.Empty(s.T(), got)
+	})
+
+	s.Run("failed - id empty", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithEventRepository(s.eventRepo))
+		got, err := svc.ListDeckEvents(ctx, "", 4)
+		assert.Nil(s.T(), got)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+	})
+}
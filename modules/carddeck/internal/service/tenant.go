@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// CreateTenant creates new tenant, nil quota of the tenant uses default quota of the server
+// will return error when:
+//
+//	name is too long or quota is negative
+func (s *Service) CreateTenant(ctx context.Context, tenant *entity.Tenant) (*entity.Tenant, error) {
+	if s.tenantRepository == nil {
+		return nil, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if err := tenant.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.tenantRepository.Insert(ctx, tenant)
+}
+
+// SetTenantQuota overrides quota of the tenant, nil quota restores default quota of the server
+// will return error when:
+//
+//	quota is negative
+//	tenant not found
+func (s *Service) SetTenantQuota(ctx context.Context, tenant *entity.Tenant) error {
+	if s.tenantRepository == nil {
+		return entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if err := tenant.Validate(); err != nil {
+		return err
+	}
+	if tenant.ID == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return err
+	}
+
+	return s.tenantRepository.UpdateQuota(ctx, tenant)
+}
+
+// TenantQuota returns quota of the tenant.
+// Tenants unknown to the server, like tenants of bearer tokens not created on the server, have the default quota.
+func (s *Service) TenantQuota(ctx context.Context, tenantID string) (entity.TenantQuota, error) {
+	if s.tenantRepository == nil || tenantID == "" {
+		return s.tenantQuota, nil
+	}
+
+	tenant, err := s.tenantRepository.GetByID(ctx, tenantID)
+	if err != nil {
+		if perr, ok := err.(*entity.Error); ok && perr.Code == entity.ErrTenantNotFound {
+			return s.tenantQuota, nil
+		}
+		return entity.TenantQuota{}, err
+	}
+
+	return tenant.Quota(s.tenantQuota), nil
+}
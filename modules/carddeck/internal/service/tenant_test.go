@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceTestSuite) TestCreateTenant() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.tenantRepo.EXPECT().Insert(ctx, &entity.Tenant{Name: "studio"}).Return(&entity.Tenant{ID: "tenant-1", Name: "studio"}, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo))
+		tenant, err := svc.CreateTenant(ctx, &entity.Tenant{Name: "studio"})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), "tenant-1", tenant.ID)
+	})
+
+	s.Run("failed - negative quota", func() {
+		maxActiveDecks := -1
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo))
+		tenant, err := svc.CreateTenant(ctx, &entity.Tenant{Name: "studio", MaxActiveDecks: &maxActiveDecks})
+		assert.Nil(s.T(), tenant)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+		assert.Equal(s.T(), "max_active_decks", perr.Details[0].Field)
+	})
+}
+
+func (s *ServiceTestSuite) TestSetTenantQuota() {
+	ctx := context.Background()
+	maxRequestsPerMinute := 60
+
+	s.Run("success", func() {
+		tenant := &entity.Tenant{ID: "tenant-1", MaxRequestsPerMinute: &maxRequestsPerMinute}
+		s.tenantRepo.EXPECT().UpdateQuota(ctx, tenant).Return(nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo))
+		assert.NoError(s.T(), svc.SetTenantQuota(ctx, tenant))
+	})
+
+	s.Run("failed - tenant not found", func() {
+		s.tenantRepo.EXPECT().UpdateQuota(ctx, gomock.Any()).Return(entity.NewError(entity.ErrTenantNotFound, entity.ErrMsgTenantNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo))
+		perr, ok := svc.SetTenantQuota(ctx, &entity.Tenant{ID: "tenant-1"}).(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrTenantNotFound, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestTenantQuota() {
+	ctx := context.Background()
+	defaults := entity.TenantQuota{MaxActiveDecks: 100, MaxRequestsPerMinute: 600}
+	maxActiveDecks := 5
+
+	s.Run("success - tenant overrides default quota", func() {
+		s.tenantRepo.EXPECT().GetByID(ctx, "tenant-1").Return(&entity.Tenant{ID: "tenant-1", MaxActiveDecks: &maxActiveDecks}, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo), service.WithDefaultTenantQuota(defaults))
+		quota, err := svc.TenantQuota(ctx, "tenant-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), entity.TenantQuota{MaxActiveDecks: 5, MaxRequestsPerMinute: 600}, quota)
+	})
+
+	s.Run("success - unknown tenant has default quota", func() {
+		s.tenantRepo.EXPECT().GetByID(ctx, "idp-tenant").Return(nil, entity.NewError(entity.ErrTenantNotFound, entity.ErrMsgTenantNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo), service.WithDefaultTenantQuota(defaults))
+		quota, err := svc.TenantQuota(ctx, "idp-tenant")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), defaults, quota)
+	})
+
+	s.Run("failed - repository error", func() {
+		s.tenantRepo.EXPECT().GetByID(ctx, "tenant-1").Return(nil, errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithTenantRepository(s.tenantRepo))
+		_, err := svc.TenantQuota(ctx, "tenant-1")
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *ServiceTestSuite) TestCreateDeck_TenantQuota() {
+	ctx := entity.WithTenant(context.Background(), "tenant-1")
+	opts := []service.Option{service.WithDefaultTenantQuota(entity.TenantQuota{MaxActiveDecks: 2})}
+
+	s.Run("success - below quota", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().CountActiveForUpdate(ctx).Return(1, nil)
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).Return(defaultDeck, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		deck, err := svc.CreateDeck(ctx, false, nil)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), defaultDeck, deck)
+	})
+
+	s.Run("failed - quota reached", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().CountActiveForUpdate(ctx).Return(2, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, opts...)
+		deck, err := svc.CreateDeck(ctx, false, nil)
+		assert.Nil(s.T(), deck)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrTenantDeckQuotaExceeded, perr.Code)
+	})
+
+	s.Run("success - unlimited tenant is not counted", func() {
+		s.expectTransaction()
+		s.deckRepo.EXPECT().Insert(ctx, gomock.Any()).Return(defaultDeck, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		_, err := svc.CreateDeck(ctx, false, nil)
+		assert.NoError(s.T(), err)
+	})
+}
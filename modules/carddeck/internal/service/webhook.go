@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// CreateWebhook subscribes the webhook URL to deck lifecycle events
+// will return error when:
+//
+//	url, events or secret is invalid
+func (s *Service) CreateWebhook(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	if s.webhookRepository == nil {
+		return nil, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if webhook == nil {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("webhook", "webhook is empty"))
+		return nil, err
+	}
+
+	if err := webhook.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.Insert(ctx, webhook)
+}
+
+// DeleteWebhook deletes the webhook together with its pending and dead deliveries
+// will return error when:
+//
+//	webhook not found
+func (s *Service) DeleteWebhook(ctx context.Context, id string) error {
+	if s.webhookRepository == nil {
+		return entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return err
+	}
+
+	return s.webhookRepository.Delete(ctx, id)
+}
+
+// ListWebhookDeadLetters returns deliveries of the webhook that failed every attempt
+// will return error when:
+//
+//	webhook not found
+func (s *Service) ListWebhookDeadLetters(ctx context.Context, id string) ([]*entity.WebhookDelivery, error) {
+	if s.webhookRepository == nil {
+		return nil, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal)
+	}
+
+	if id == "" {
+		err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+		err.AddDetail(entity.NewErrorDetail("id", "ID is empty"))
+		return nil, err
+	}
+
+	if _, err := s.webhookRepository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.ListDeadLetters(ctx, id)
+}
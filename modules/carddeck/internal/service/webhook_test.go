@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func (s *ServiceTestSuite) TestCreateWebhook() {
+	ctx := context.Background()
+	webhook := func() *entity.Webhook {
+		return &entity.Webhook{
+			URL:    "https://example.com/hook",
+			Events: entity.WebhookEventList{entity.WebhookEventDeckCreated},
+			Secret: "0123456789abcdef",
+		}
+	}
+
+	s.Run("success", func() {
+		created := webhook()
+		created.ID = "webhook-1"
+		s.webhookRepo.EXPECT().Insert(ctx, webhook()).Return(created, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		got, err := svc.CreateWebhook(ctx, webhook())
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), created, got)
+	})
+
+	s.Run("failed - webhook invalid", func() {
+		invalid := webhook()
+		invalid.URL = "ftp://example.com"
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		got, err := svc.CreateWebhook(ctx, invalid)
+		assert.Nil(s.T(), got)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrParamInvalid, perr.Code)
+		assert.Equal(s.T(), "url", perr.Details[0].Field)
+	})
+
+	s.Run("failed - webhooks are not configured", func() {
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler)
+		got, err := svc.CreateWebhook(ctx, webhook())
+		assert.Nil(s.T(), got)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrInternal, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestDeleteWebhook() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		s.webhookRepo.EXPECT().Delete(ctx, "webhook-1").Return(nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		assert.NoError(s.T(), svc.DeleteWebhook(ctx, "webhook-1"))
+	})
+
+	s.Run("failed - webhook not found", func() {
+		s.webhookRepo.EXPECT().Delete(ctx, "webhook-1").Return(entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		perr, ok := svc.DeleteWebhook(ctx, "webhook-1").(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrWebhookNotFound, perr.Code)
+	})
+}
+
+func (s *ServiceTestSuite) TestListWebhookDeadLetters() {
+	ctx := context.Background()
+
+	s.Run("success", func() {
+		deliveries := []*entity.WebhookDelivery{{ID: 3, WebhookID: "webhook-1", Status: entity.WebhookDeliveryDead}}
+		s.webhookRepo.EXPECT().GetByID(ctx, "webhook-1").Return(&entity.Webhook{ID: "webhook-1"}, nil)
+		s.webhookRepo.EXPECT().ListDeadLetters(ctx, "webhook-1").Return(deliveries, nil)
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		got, err := svc.ListWebhookDeadLetters(ctx, "webhook-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), deliveries, got)
+	})
+
+	s.Run("failed - webhook not found", func() {
+		s.webhookRepo.EXPECT().GetByID(ctx, "webhook-1").Return(nil, entity.NewError(entity.ErrWebhookNotFound, entity.ErrMsgWebhookNotFound))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		got, err := svc.ListWebhookDeadLetters(ctx, "webhook-1")
+		assert.Nil(s.T(), got)
+
+		perr, ok := err.(*entity.Error)
+		assert.True(s.T(), ok)
+		assert.Equal(s.T(), entity.ErrWebhookNotFound, perr.Code)
+	})
+
+	s.Run("failed - unexpected error", func() {
+		s.webhookRepo.EXPECT().GetByID(ctx, "webhook-1").Return(&entity.Webhook{ID: "webhook-1"}, nil)
+		s.webhookRepo.EXPECT().ListDeadLetters(ctx, "webhook-1").Return(nil, errors.New("some error"))
+
+		svc := service.New(s.deckRepo, s.randGenerator, s.cardShuffler, service.WithWebhookRepository(s.webhookRepo))
+		got, err := svc.ListWebhookDeadLetters(ctx, "webhook-1")
+		assert.Nil(s.T(), got)
+		assert.Error(s.T(), err)
+	})
+}
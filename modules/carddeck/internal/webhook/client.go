@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errDestinationNotAllowed is returned when the webhook URL resolves to an address of the server network
+var errDestinationNotAllowed = errors.New("destination address is not allowed")
+
+// sharedAddressSpace is carrier-grade NAT range of RFC 6598, not routable on the internet
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient creates HTTP client posting deliveries within timeout.
+// Unless allowPrivate is true, connections to loopback, private, link-local and unspecified addresses are refused,
+// so webhooks can not reach services of the server network. The address is checked after it is resolved,
+// so hosts resolving to such addresses, including by DNS rebinding, are refused too.
+// Redirects are not followed, the redirect response is the result of the attempt.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = allowPublicAddress
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// deliveries are not sent through a proxy, which would connect to the refused addresses on our behalf
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// allowPublicAddress is net.Dialer control refusing connections to addresses of the server network
+func allowPublicAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errDestinationNotAllowed
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || sharedAddressSpace.Contains(addr) {
+		return errDestinationNotAllowed
+	}
+	return nil
+}
+
+// errorClass returns generic description of the failed attempt stored with the delivery.
+// Network errors are not stored as is, so webhook owners can not learn about the network of the server.
+func errorClass(err error) string {
+	var (
+		statusErr *statusError
+		netErr    net.Error
+	)
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.Error()
+	case errors.Is(err, errDestinationNotAllowed):
+		return errDestinationNotAllowed.Error()
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "request failed"
+	}
+}
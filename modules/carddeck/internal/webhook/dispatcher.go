@@ -0,0 +1,181 @@
+// Package webhook implements delivery of webhook payloads stored in the outbox
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BatchSize is maximum number of deliveries claimed on every poll
+	BatchSize = 50
+	// BaseBackoff is delay before the second attempt, doubled after every failed attempt
+	BaseBackoff = 30 * time.Second
+	// MaxBackoff caps delay between attempts
+	MaxBackoff = time.Hour
+
+	// defaultAttemptTimeout bounds every attempt when the client has no timeout
+	defaultAttemptTimeout = 10 * time.Second
+	// leaseMargin is added to the attempt timeout, leaving time to record results before deliveries are claimed again
+	leaseMargin = time.Minute
+)
+
+// statusError is returned when the webhook URL responds with status other than 2xx
+type statusError struct {
+	statusCode int
+}
+
+// Error implements error interface
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.statusCode)
+}
+
+// Repository defines outbox of webhook deliveries
+type Repository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entity.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, statusCode int) error
+	MarkFailed(ctx context.Context, id int64, status string, statusCode int, lastError string, retryIn time.Duration) error
+}
+
+// Dispatcher posts due deliveries to their webhook URL
+type Dispatcher struct {
+	repo         Repository
+	client       *http.Client
+	pollInterval time.Duration
+	maxAttempts  int
+}
+
+// NewDispatcher creates new dispatcher, client timeout bounds every attempt.
+// Use NewClient, so deliveries can not reach the server network.
+func NewDispatcher(repo Repository, client *http.Client, pollInterval time.Duration, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		repo:         repo,
+		client:       client,
+		pollInterval: pollInterval,
+		maxAttempts:  maxAttempts,
+	}
+}
+
+// Run dispatches due deliveries every poll interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchDue(ctx); err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("[webhook dispatcher] error dispatching deliveries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue claims due deliveries and attempts each of them once, returning number of claimed deliveries.
+// Deliveries are attempted concurrently, so the whole batch is done within a single attempt timeout
+// and none of them is claimed again by another poll while being attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	timeout := d.attemptTimeout()
+	// deliveries not marked within the lease, e.g. because the instance stopped, are claimed again
+	lease := timeout + leaseMargin
+
+	deliveries, err := d.repo.ClaimDue(ctx, BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.dispatch(ctx, delivery, timeout)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// attemptTimeout returns timeout of every attempt, the client timeout when it is set
+func (d *Dispatcher) attemptTimeout() time.Duration {
+	if d.client.Timeout > 0 {
+		return d.client.Timeout
+	}
+	return defaultAttemptTimeout
+}
+
+// dispatch posts the delivery within timeout and records the result of the attempt
+func (d *Dispatcher) dispatch(ctx context.Context, delivery *entity.WebhookDelivery, timeout time.Duration) error {
+	postCtx, cancel := context.WithTimeout(ctx, timeout)
+	statusCode, err := d.post(postCtx, delivery)
+	cancel()
+	if err == nil {
+		return d.repo.MarkDelivered(ctx, delivery.ID, statusCode)
+	}
+
+	attempt := delivery.Attempts + 1
+	status := entity.WebhookDeliveryPending
+	if attempt >= d.maxAttempts {
+		status = entity.WebhookDeliveryDead
+	}
+
+	log.Warn().Err(err).Int64("delivery_id", delivery.ID).Int("attempt", attempt).Str("status", status).Msg("[webhook dispatcher] delivery failed")
+
+	return d.repo.MarkFailed(ctx, delivery.ID, status, statusCode, errorClass(err), Backoff(attempt))
+}
+
+// post sends the payload, any response other than 2xx is an error
+func (d *Dispatcher) post(ctx context.Context, delivery *entity.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "carddeck-webhook")
+	req.Header.Set(entity.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(entity.WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(entity.WebhookSignatureHeader, entity.SignWebhook(delivery.Secret, time.Now(), delivery.Payload))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, &statusError{statusCode: res.StatusCode}
+	}
+
+	return res.StatusCode, nil
+}
+
+// Backoff returns delay after the failed attempt (starting from 1) before the next one
+func Backoff(attempt int) time.Duration {
+	backoff := BaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,196 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/internal/webhook"
+	mock_webhook "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "0123456789abcdef"
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts webhook receiver responding with statusCode
+func newReceiver(t *testing.T, statusCode int) (*httptest.Server, chan receivedRequest) {
+	received := make(chan receivedRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received <- receivedRequest{header: r.Header, body: body}
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func newDelivery(url string, attempts int) *entity.WebhookDelivery {
+	return &entity.WebhookDelivery{
+		ID:        3,
+		WebhookID: "webhook-1",
+		EventType: entity.WebhookEventDeckCreated,
+		Payload:   []byte(`{"type":"deck.created"}`),
+		Status:    entity.WebhookDeliveryPending,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    secret,
+	}
+}
+
+func Test_Dispatcher_DispatchDue(t *testing.T) {
+	ctx := context.Background()
+	// receivers listen on loopback
+	client := webhook.NewClient(time.Second, true)
+
+	t.Run("success - delivery is signed and marked delivered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv, received := newReceiver(t, http.StatusNoContent)
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, time.Minute+time.Second).Return([]*entity.WebhookDelivery{newDelivery(srv.URL, 0)}, nil)
+		repo.EXPECT().MarkDelivered(ctx, int64(3), http.StatusNoContent).Return(nil)
+
+		n, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		req := <-received
+		assert.Equal(t, `{"type":"deck.created"}`, string(req.body))
+		assert.Equal(t, entity.WebhookEventDeckCreated, req.header.Get(entity.WebhookEventHeader))
+		assert.Equal(t, "3", req.header.Get(entity.WebhookDeliveryHeader))
+
+		// receiver verifies the signature using the timestamp sent in the header
+		signature := req.header.Get(entity.WebhookSignatureHeader)
+		ts, _, ok := strings.Cut(strings.TrimPrefix(signature, "t="), ",")
+		assert.True(t, ok)
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(unix, 0), 5*time.Second)
+		assert.Equal(t, entity.SignWebhook(secret, time.Unix(unix, 0), req.body), signature)
+	})
+
+	t.Run("success - failed delivery is retried with backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv, _ := newReceiver(t, http.StatusInternalServerError)
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return([]*entity.WebhookDelivery{newDelivery(srv.URL, 1)}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(3), entity.WebhookDeliveryPending, http.StatusInternalServerError, "unexpected status code 500", time.Minute).Return(nil)
+
+		_, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success - delivery is dead after the last attempt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv, _ := newReceiver(t, http.StatusBadRequest)
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return([]*entity.WebhookDelivery{newDelivery(srv.URL, 2)}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(3), entity.WebhookDeliveryDead, http.StatusBadRequest, "unexpected status code 400", gomock.Any()).Return(nil)
+
+		_, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success - unreachable URL is retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv, _ := newReceiver(t, http.StatusOK)
+		srv.Close()
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return([]*entity.WebhookDelivery{newDelivery(srv.URL, 0)}, nil)
+		// connection errors are not stored as is
+		repo.EXPECT().MarkFailed(ctx, int64(3), entity.WebhookDeliveryPending, 0, "request failed", webhook.BaseBackoff).Return(nil)
+
+		_, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success - redirect is not followed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		target, received := newReceiver(t, http.StatusOK)
+		srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		t.Cleanup(srv.Close)
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return([]*entity.WebhookDelivery{newDelivery(srv.URL, 0)}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(3), entity.WebhookDeliveryPending, http.StatusTemporaryRedirect, "unexpected status code 307", webhook.BaseBackoff).Return(nil)
+
+		_, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, received)
+	})
+
+	t.Run("success - private destination is refused", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv, received := newReceiver(t, http.StatusOK)
+		// localhost resolves to loopback, the same as hosts rebinding to it
+		url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return([]*entity.WebhookDelivery{newDelivery(url, 0)}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(3), entity.WebhookDeliveryPending, 0, "destination address is not allowed", webhook.BaseBackoff).Return(nil)
+
+		_, err := webhook.NewDispatcher(repo, webhook.NewClient(time.Second, false), time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, received)
+	})
+
+	t.Run("success - batch taking longer than the lease in sequence is done within one attempt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(srv.Close)
+
+		// sending 20 deliveries one after another takes 4s, so they must be sent concurrently to be marked within the lease
+		deliveries := make([]*entity.WebhookDelivery, 20)
+		for i := range deliveries {
+			deliveries[i] = newDelivery(srv.URL, 0)
+			deliveries[i].ID = int64(i + 1)
+		}
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, time.Minute+time.Second).Return(deliveries, nil)
+		repo.EXPECT().MarkDelivered(ctx, gomock.Any(), http.StatusOK).Return(nil).Times(len(deliveries))
+
+		start := time.Now()
+		n, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, len(deliveries), n)
+		assert.Less(t, time.Since(start), client.Timeout)
+	})
+
+	t.Run("failed - claim error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repo := mock_webhook.NewMockRepository(ctrl)
+		repo.EXPECT().ClaimDue(ctx, webhook.BatchSize, gomock.Any()).Return(nil, errors.New("some error"))
+
+		n, err := webhook.NewDispatcher(repo, client, time.Second, 3).DispatchDue(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 0, n)
+	})
+}
+
+func Test_Backoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.Backoff(1))
+	assert.Equal(t, time.Minute, webhook.Backoff(2))
+	assert.Equal(t, 2*time.Minute, webhook.Backoff(3))
+	assert.Equal(t, time.Hour, webhook.Backoff(8))
+	assert.Equal(t, time.Hour, webhook.Backoff(100))
+}
@@ -0,0 +1,281 @@
+// Package tui implements interactive terminal table playing a deck of a running server
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/raymondwongso/carddeck/modules/carddeck/client"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+const (
+	pileHand = iota
+	pileTable
+)
+
+// eventBufferSize is number of deck events waiting to be rendered
+const eventBufferSize = 16
+
+// Client defines carddeck API used by the table
+type Client interface {
+	GetDeck(ctx context.Context, id string) (*entity.Deck, error)
+	DrawCards(ctx context.Context, id string, count int64, version int64) (*client.DrawnCards, error)
+	ShuffleDeck(ctx context.Context, id string, version int64) (*client.DeckSummary, error)
+	ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*client.DeckSummary, error)
+	WatchDeck(ctx context.Context, id string, handle func(*entity.DeckEvent)) error
+}
+
+// pile defines cards laid out on the table, only known by this terminal
+type pile struct {
+	name   string
+	cards  []*entity.Card
+	cursor int
+}
+
+// remove removes card by code, keeping cursor inside the pile
+func (p *pile) remove(code string) {
+	for i, card := range p.cards {
+		if card.Code == code {
+			p.cards = append(p.cards[:i], p.cards[i+1:]...)
+			break
+		}
+	}
+	if p.cursor >= len(p.cards) && p.cursor > 0 {
+		p.cursor = len(p.cards) - 1
+	}
+}
+
+// selected returns card under the cursor, nil when the pile is empty
+func (p *pile) selected() *entity.Card {
+	if len(p.cards) == 0 {
+		return nil
+	}
+	return p.cards[p.cursor]
+}
+
+type (
+	deckLoadedMsg struct{ deck *entity.Deck }
+	drawnMsg      struct{ drawn *client.DrawnCards }
+	changedMsg    struct {
+		deck   *client.DeckSummary
+		status string
+	}
+	returnedMsg struct {
+		deck *client.DeckSummary
+		pile int
+		card *entity.Card
+	}
+	deckEventMsg  struct{ event *entity.DeckEvent }
+	watchEndedMsg struct{ err error }
+	errMsg        struct{ err error }
+)
+
+// Table defines bubbletea model of the table.
+// Drawn cards go to the hand, and can be moved to the table pile or returned to the bottom of the deck.
+type Table struct {
+	ctx    context.Context
+	client Client
+	deckID string
+
+	loaded    bool
+	remaining int
+	shuffled  bool
+	version   int64
+
+	piles  [2]*pile
+	focus  int
+	status string
+	err    error
+	width  int
+
+	events chan *entity.DeckEvent
+}
+
+// NewTable creates table playing the deck, API calls are made using ctx
+func NewTable(ctx context.Context, c Client, deckID string) *Table {
+	return &Table{
+		ctx:    ctx,
+		client: c,
+		deckID: deckID,
+		piles: [2]*pile{
+			pileHand:  {name: "Hand"},
+			pileTable: {name: "Table"},
+		},
+		width:  80,
+		events: make(chan *entity.DeckEvent, eventBufferSize),
+	}
+}
+
+// Init loads the deck and starts watching its changes
+func (t *Table) Init() tea.Cmd {
+	return tea.Batch(t.loadDeck, t.watchDeck, t.waitForEvent)
+}
+
+// Update handles key presses, results of API calls and deck events
+func (t *Table) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return t, t.handleKey(msg)
+	case tea.WindowSizeMsg:
+		t.width = msg.Width
+	case deckLoadedMsg:
+		t.loaded = true
+		t.remaining = msg.deck.Remaining()
+		t.shuffled = msg.deck.Shuffled
+		t.version = msg.deck.Version
+	case drawnMsg:
+		hand := t.piles[pileHand]
+		if msg.drawn.Cards != nil {
+			hand.cards = append(hand.cards, *msg.drawn.Cards...)
+			hand.cursor = len(hand.cards) - 1
+		}
+		if msg.drawn.Version > t.version {
+			t.version = msg.drawn.Version
+		}
+		// remaining count is updated by the deck event of the draw
+		t.focus = pileHand
+		t.status = "card drawn"
+	case changedMsg:
+		t.apply(msg.deck)
+		t.status = msg.status
+	case returnedMsg:
+		t.apply(msg.deck)
+		t.piles[msg.pile].remove(msg.card.Code)
+		t.status = fmt.Sprintf("%s returned to the bottom of the deck", msg.card.Code)
+	case deckEventMsg:
+		t.applyEvent(msg.event)
+		return t, t.waitForEvent
+	case watchEndedMsg:
+		if msg.err != nil && t.ctx.Err() == nil {
+			t.err = fmt.Errorf("live updates stopped: %w", msg.err)
+		}
+	case errMsg:
+		t.err = msg.err
+	}
+
+	return t, nil
+}
+
+func (t *Table) handleKey(msg tea.KeyMsg) tea.Cmd {
+	t.err = nil
+	focused := t.piles[t.focus]
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "d":
+		return t.drawCard
+	case "s":
+		return t.shuffleDeck
+	case "tab":
+		t.focus = 1 - t.focus
+	case "left", "h":
+		if focused.cursor > 0 {
+			focused.cursor--
+		}
+	case "right", "l":
+		if focused.cursor < len(focused.cards)-1 {
+			focused.cursor++
+		}
+	case "m":
+		card := focused.selected()
+		if card == nil {
+			return nil
+		}
+		focused.remove(card.Code)
+		other := t.piles[1-t.focus]
+		other.cards = append(other.cards, card)
+		other.cursor = len(other.cards) - 1
+		t.status = fmt.Sprintf("%s moved to %s", card.Code, other.name)
+	case "r":
+		card := focused.selected()
+		if card == nil {
+			return nil
+		}
+		return t.returnCard(t.focus, card)
+	}
+
+	return nil
+}
+
+// apply updates deck state from response of the API
+func (t *Table) apply(deck *client.DeckSummary) {
+	if deck.Version < t.version {
+		return
+	}
+	t.remaining = int(deck.Remaining)
+	t.shuffled = deck.Shuffled
+	t.version = deck.Version
+}
+
+// applyEvent updates deck state from change made by this or another client
+func (t *Table) applyEvent(ev *entity.DeckEvent) {
+	if ev.Version < t.version {
+		return
+	}
+	t.remaining = ev.Remaining
+	t.shuffled = ev.Shuffled
+	t.version = ev.Version
+
+	cards := 0
+	if ev.Cards != nil {
+		cards = ev.Cards.Len()
+	}
+	t.status = fmt.Sprintf("%s (%d cards), version %d", ev.Type, cards, ev.Version)
+}
+
+func (t *Table) loadDeck() tea.Msg {
+	deck, err := t.client.GetDeck(t.ctx, t.deckID)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	return deckLoadedMsg{deck: deck}
+}
+
+func (t *Table) drawCard() tea.Msg {
+	drawn, err := t.client.DrawCards(t.ctx, t.deckID, 1, 0)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	return drawnMsg{drawn: drawn}
+}
+
+func (t *Table) shuffleDeck() tea.Msg {
+	deck, err := t.client.ShuffleDeck(t.ctx, t.deckID, 0)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	return changedMsg{deck: deck, status: "deck shuffled"}
+}
+
+func (t *Table) returnCard(pile int, card *entity.Card) tea.Cmd {
+	return func() tea.Msg {
+		deck, err := t.client.ReturnCards(t.ctx, t.deckID, []string{card.Code}, 0)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return returnedMsg{deck: deck, pile: pile, card: card}
+	}
+}
+
+// watchDeck feeds events of the deck until the watch ends, then closes the events channel
+func (t *Table) watchDeck() tea.Msg {
+	err := t.client.WatchDeck(t.ctx, t.deckID, func(ev *entity.DeckEvent) {
+		select {
+		case t.events <- ev:
+		case <-t.ctx.Done():
+		}
+	})
+	close(t.events)
+	return watchEndedMsg{err: err}
+}
+
+func (t *Table) waitForEvent() tea.Msg {
+	ev, ok := <-t.events
+	if !ok {
+		return nil
+	}
+	return deckEventMsg{event: ev}
+}
@@ -0,0 +1,160 @@
+package tui_test
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck/client"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/carddeck/tui"
+	mock_tui "github.com/raymondwongso/carddeck/test/mock/modules/carddeck/tui"
+	"github.com/stretchr/testify/assert"
+)
+
+const deckID = "some-uuid-abc-def"
+
+var (
+	aceOfSpades  = &entity.Card{Val: "ACE", Suit: "SPADE", Code: "AS"}
+	kingOfHearts = &entity.Card{Val: "KING", Suit: "HEART", Code: "KH"}
+)
+
+// run executes cmd and feeds resulting messages back to the table, like bubbletea program does
+func run(t *testing.T, table *tui.Table, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	switch msg := cmd().(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			run(t, table, cmd)
+		}
+	default:
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return
+		}
+		_, next := table.Update(msg)
+		run(t, table, next)
+	}
+}
+
+func press(t *testing.T, table *tui.Table, key string) {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	if key == "tab" {
+		msg = tea.KeyMsg{Type: tea.KeyTab}
+	}
+
+	_, cmd := table.Update(msg)
+	run(t, table, cmd)
+}
+
+// newTable returns loaded table of deck having 2 cards, receiving events from the watch
+func newTable(t *testing.T, c *mock_tui.MockClient, events ...*entity.DeckEvent) *tui.Table {
+	deck := entity.NewDeck(false, &entity.Cards{aceOfSpades, kingOfHearts})
+	deck.ID = deckID
+	deck.Version = 1
+
+	c.EXPECT().GetDeck(gomock.Any(), deckID).Return(deck, nil)
+	c.EXPECT().WatchDeck(gomock.Any(), deckID, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, handle func(*entity.DeckEvent)) error {
+			for _, ev := range events {
+				handle(ev)
+			}
+			return nil
+		})
+
+	table := tui.NewTable(context.Background(), c, deckID)
+	run(t, table, table.Init())
+	return table
+}
+
+func Test_Table(t *testing.T) {
+	t.Run("success - deck is rendered", func(t *testing.T) {
+		table := newTable(t, mock_tui.NewMockClient(gomock.NewController(t)))
+
+		view := table.View()
+		assert.Contains(t, view, "Deck "+deckID)
+		assert.Contains(t, view, "2 cards remaining")
+		assert.Contains(t, view, "not shuffled, version 1")
+		assert.Contains(t, view, "Hand (0)")
+		assert.Contains(t, view, "(empty)")
+	})
+
+	t.Run("success - live update from another client", func(t *testing.T) {
+		table := newTable(t, mock_tui.NewMockClient(gomock.NewController(t)),
+			&entity.DeckEvent{ID: 7, Type: entity.DeckEventShuffled, DeckID: deckID, Version: 2, Shuffled: true, Remaining: 2},
+			// stale event is ignored
+			&entity.DeckEvent{ID: 6, Type: entity.DeckEventDrawn, DeckID: deckID, Version: 1, Remaining: 0},
+		)
+
+		view := table.View()
+		assert.Contains(t, view, "2 cards remaining")
+		assert.Contains(t, view, "shuffled, version 2")
+		assert.Contains(t, view, "deck.shuffled (0 cards), version 2")
+	})
+
+	t.Run("success - draw, move and return card", func(t *testing.T) {
+		c := mock_tui.NewMockClient(gomock.NewController(t))
+		table := newTable(t, c)
+
+		c.EXPECT().DrawCards(gomock.Any(), deckID, int64(1), int64(0)).Return(&client.DrawnCards{Cards: &entity.Cards{aceOfSpades}, Version: 2}, nil)
+		press(t, table, "d")
+		c.EXPECT().DrawCards(gomock.Any(), deckID, int64(1), int64(0)).Return(&client.DrawnCards{Cards: &entity.Cards{kingOfHearts}, Version: 3}, nil)
+		press(t, table, "d")
+		assert.Contains(t, table.View(), "Hand (2)")
+		assert.Contains(t, table.View(), "♥")
+		assert.Contains(t, table.View(), "version 3")
+
+		// move selected king to the table, then return the ace left in the hand
+		press(t, table, "m")
+		assert.Contains(t, table.View(), "Hand (1)")
+		assert.Contains(t, table.View(), "Table (1)")
+		assert.Contains(t, table.View(), "KH moved to Table")
+
+		c.EXPECT().ReturnCards(gomock.Any(), deckID, []string{"AS"}, int64(0)).Return(&client.DeckSummary{ID: deckID, Remaining: 1, Version: 4}, nil)
+		press(t, table, "r")
+		view := table.View()
+		assert.Contains(t, view, "Hand (0)")
+		assert.Contains(t, view, "1 cards remaining")
+		assert.Contains(t, view, "AS returned to the bottom of the deck")
+
+		// switch to the table pile and return the king
+		press(t, table, "tab")
+		c.EXPECT().ReturnCards(gomock.Any(), deckID, []string{"KH"}, int64(0)).Return(&client.DeckSummary{ID: deckID, Remaining: 2, Version: 5}, nil)
+		press(t, table, "r")
+		assert.Contains(t, table.View(), "Table (0)")
+		assert.Contains(t, table.View(), "2 cards remaining")
+	})
+
+	t.Run("success - shuffle", func(t *testing.T) {
+		c := mock_tui.NewMockClient(gomock.NewController(t))
+		table := newTable(t, c)
+
+		c.EXPECT().ShuffleDeck(gomock.Any(), deckID, int64(0)).Return(&client.DeckSummary{ID: deckID, Shuffled: true, Remaining: 2, Version: 2}, nil)
+		press(t, table, "s")
+		assert.Contains(t, table.View(), "shuffled, version 2")
+		assert.Contains(t, table.View(), "deck shuffled")
+	})
+
+	t.Run("failed - error is shown until the next key", func(t *testing.T) {
+		c := mock_tui.NewMockClient(gomock.NewController(t))
+		table := newTable(t, c)
+
+		c.EXPECT().DrawCards(gomock.Any(), deckID, int64(1), int64(0)).Return(nil, entity.NewError(entity.ErrDeckCardInsufficient, entity.ErrMsgDeckCardInsufficient))
+		press(t, table, "d")
+		assert.Contains(t, table.View(), entity.ErrMsgDeckCardInsufficient)
+
+		press(t, table, "tab")
+		assert.NotContains(t, table.View(), entity.ErrMsgDeckCardInsufficient)
+	})
+
+	t.Run("success - quit", func(t *testing.T) {
+		table := newTable(t, mock_tui.NewMockClient(gomock.NewController(t)))
+
+		_, cmd := table.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		assert.Equal(t, tea.QuitMsg{}, cmd())
+	})
+}
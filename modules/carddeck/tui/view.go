@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// cardWidth is width of rendered card including its border and the gap to the next card
+const cardWidth = 8
+
+var (
+	suitSymbols = map[string]string{"SPADE": "♠", "HEART": "♥", "DIAMOND": "♦", "CLUB": "♣"}
+	valueLabels = map[string]string{"ACE": "A", "JACK": "J", "QUEEN": "Q", "KING": "K"}
+
+	cardStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Width(5).MarginRight(1)
+	selectedStyle = cardStyle.BorderForeground(lipgloss.Color("11")).Border(lipgloss.ThickBorder())
+	redStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	focusedStyle  = titleStyle.Foreground(lipgloss.Color("11"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+// View renders the deck, the piles and key bindings
+func (t *Table) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Deck " + t.deckID))
+	b.WriteString("\n")
+	if t.loaded {
+		shuffled := "not shuffled"
+		if t.shuffled {
+			shuffled = "shuffled"
+		}
+		deck := cardStyle.Render("░░░░░\n░░░░░\n░░░░░")
+		info := fmt.Sprintf("\n%d cards remaining\n%s, version %d", t.remaining, shuffled, t.version)
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, deck, info))
+	} else {
+		b.WriteString("loading...")
+	}
+	b.WriteString("\n")
+
+	for i, p := range t.piles {
+		title := fmt.Sprintf("%s (%d)", p.name, len(p.cards))
+		if i == t.focus {
+			b.WriteString(focusedStyle.Render("> " + title))
+		} else {
+			b.WriteString(titleStyle.Render("  " + title))
+		}
+		b.WriteString("\n")
+		b.WriteString(t.renderPile(p, i == t.focus))
+		b.WriteString("\n")
+	}
+
+	if t.err != nil {
+		b.WriteString(errorStyle.Render(t.err.Error()))
+	} else {
+		b.WriteString(t.status)
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("d draw • s shuffle • tab switch pile • ←/→ select • m move to other pile • r return to deck • q quit"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderPile lays out cards of the pile in rows fitting the terminal width
+func (t *Table) renderPile(p *pile, focused bool) string {
+	if len(p.cards) == 0 {
+		return helpStyle.Render("  (empty)") + "\n"
+	}
+
+	perRow := max(1, t.width/cardWidth)
+	var rows []string
+	for start := 0; start < len(p.cards); start += perRow {
+		end := min(start+perRow, len(p.cards))
+
+		rendered := make([]string, 0, end-start)
+		for i := start; i < end; i++ {
+			rendered = append(rendered, renderCard(p.cards[i], focused && i == p.cursor))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, rendered...))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// renderCard renders the card as a box showing its value and suit, hearts and diamonds in red
+func renderCard(card *entity.Card, selected bool) string {
+	label, ok := valueLabels[card.Val]
+	if !ok {
+		label = card.Val
+	}
+	suit, ok := suitSymbols[card.Suit]
+	if !ok {
+		suit = "?"
+	}
+
+	face := fmt.Sprintf("%-5s\n  %s  \n%5s", label, suit, label)
+	if card.Suit == "HEART" || card.Suit == "DIAMOND" {
+		face = redStyle.Render(face)
+	}
+
+	if selected {
+		return selectedStyle.Render(face)
+	}
+	return cardStyle.Render(face)
+}
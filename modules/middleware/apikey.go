@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	// apiKeyChallenge is sent in WWW-Authenticate header of 401 responses
+	apiKeyChallenge = `APIKey header="` + apiKeyHeader + `"`
+)
+
+// APIKeyAuthenticator authenticates API keys sent by clients
+type APIKeyAuthenticator interface {
+	// AuthenticateAPIKey returns API key matching the key, entity.ErrAPIKeyInvalid when it is unknown or revoked.
+	AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error)
+}
+
+// APIKey returns middleware that authenticates requests by X-API-Key header,
+// passing tenant of the key to handlers with entity.WithTenant, so decks of other tenants are not visible.
+// Requests without the header are rejected when required is true,
+// otherwise they are passed as is and only see decks created without API key.
+// Requests already authenticated by Bearer middleware are passed as is.
+func APIKey(auth APIKeyAuthenticator, required bool, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if entity.TenantFromContext(r.Context()) != "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" {
+				if required {
+					w.Header().Set("WWW-Authenticate", apiKeyChallenge)
+					writeError(w, r, entity.NewError(entity.ErrAPIKeyRequired, entity.ErrMsgAPIKeyRequired))
+					return
+				}
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			apiKey, err := auth.AuthenticateAPIKey(r.Context(), key)
+			if err != nil {
+				if perr, ok := err.(*entity.Error); ok && perr.Code == entity.ErrAPIKeyInvalid {
+					w.Header().Set("WWW-Authenticate", apiKeyChallenge)
+					writeError(w, r, perr)
+					return
+				}
+
+				log.Error().Err(err).Msg("[api key] error authenticating API key")
+				writeError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+				return
+			}
+
+			h.ServeHTTP(w, r.WithContext(entity.WithTenant(r.Context(), apiKey.TenantID)))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	mock_middleware "github.com/raymondwongso/carddeck/test/mock/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyTestSuite struct {
+	suite.Suite
+	auth   *mock_middleware.MockAPIKeyAuthenticator
+	tenant string
+	calls  int
+	next   http.Handler
+}
+
+func (s *APIKeyTestSuite) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.auth = mock_middleware.NewMockAPIKeyAuthenticator(ctrl)
+	s.tenant = ""
+	s.calls = 0
+	s.next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		s.tenant = entity.TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestAPIKey(t *testing.T) {
+	suite.Run(t, new(APIKeyTestSuite))
+}
+
+func (s *APIKeyTestSuite) serve(key string, required bool) *http.Response {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+
+	middleware.APIKey(s.auth, required, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+	return w.Result()
+}
+
+func (s *APIKeyTestSuite) TestValidKey() {
+	s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(&entity.APIKey{ID: "api-key-1", TenantID: "tenant-1"}, nil)
+
+	response := s.serve("cdk_valid", true)
+
+	assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	assert.Equal(s.T(), 1, s.calls)
+	assert.Equal(s.T(), "tenant-1", s.tenant)
+}
+
+func (s *APIKeyTestSuite) TestWithoutKey() {
+	s.Run("success - key is optional", func() {
+		response := s.serve("", false)
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Empty(s.T(), s.tenant)
+	})
+
+	s.Run("failed - key is required", func() {
+		s.calls = 0
+		response := s.serve("", true)
+
+		assert.Equal(s.T(), http.StatusUnauthorized, response.StatusCode)
+		assert.Equal(s.T(), `APIKey header="X-API-Key"`, response.Header.Get("WWW-Authenticate"))
+		assert.Equal(s.T(), 0, s.calls)
+
+		var resp entity.Error
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), entity.ErrAPIKeyRequired, resp.Code)
+	})
+}
+
+func (s *APIKeyTestSuite) TestInvalidKey() {
+	// invalid key is rejected even when key is optional, so typos are not silently served anonymously
+	s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_revoked").Return(nil, entity.NewError(entity.ErrAPIKeyInvalid, entity.ErrMsgAPIKeyInvalid))
+
+	response := s.serve("cdk_revoked", false)
+
+	assert.Equal(s.T(), http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(s.T(), `APIKey header="X-API-Key"`, response.Header.Get("WWW-Authenticate"))
+	assert.Equal(s.T(), 0, s.calls)
+
+	var resp entity.Error
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+	assert.Equal(s.T(), entity.ErrAPIKeyInvalid, resp.Code)
+}
+
+func (s *APIKeyTestSuite) TestAuthenticatorError() {
+	s.auth.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(nil, errors.New("connection refused"))
+
+	response := s.serve("cdk_valid", true)
+
+	assert.Equal(s.T(), http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *APIKeyTestSuite) TestAlreadyAuthenticated() {
+	// requests authenticated by Bearer middleware are not required to send API key
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	r = r.WithContext(entity.WithTenant(r.Context(), "studio-1"))
+	w := httptest.NewRecorder()
+
+	middleware.APIKey(s.auth, true, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	assert.Equal(s.T(), http.StatusOK, w.Code)
+	assert.Equal(s.T(), 1, s.calls)
+	assert.Equal(s.T(), "studio-1", s.tenant)
+}
+
+func (s *APIKeyTestSuite) TestErrorNegotiated() {
+	// errors are written the same way as errors of REST API handlers
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	r.Header.Set("Accept", "application/problem+json")
+	r.Header.Set("Accept-Language", "es")
+	w := httptest.NewRecorder()
+
+	middleware.APIKey(s.auth, true, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+	response := w.Result()
+
+	assert.Equal(s.T(), http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(s.T(), "application/problem+json", response.Header.Get("Content-Type"))
+	assert.Equal(s.T(), "es", response.Header.Get("Content-Language"))
+
+	var problem struct {
+		Type   string `json:"type"`
+		Status int    `json:"status"`
+		Detail string `json:"detail"`
+	}
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(s.T(), "/problems/"+entity.ErrAPIKeyRequired, problem.Type)
+	assert.Equal(s.T(), http.StatusUnauthorized, problem.Status)
+	assert.Equal(s.T(), "se requiere una clave de API", problem.Detail)
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	bearerScheme = "Bearer"
+	// tokenLeeway tolerates clock skew between the server and the identity provider
+	tokenLeeway = 30 * time.Second
+)
+
+// TokenClaims are claims of bearer token used by the server
+type TokenClaims struct {
+	// Tenant is value of the tenant claim of the verifier
+	Tenant string
+	// Scope is space separated list of scopes, as defined by RFC 9068
+	Scope string
+}
+
+// Scopes returns scopes granted to the token
+func (c *TokenClaims) Scopes() entity.Scopes {
+	return entity.Scopes(strings.Fields(c.Scope))
+}
+
+// TokenVerifier verifies JWT bearer tokens signed with RS256 or ES256 by keys of the key set
+type TokenVerifier struct {
+	keys        *JWKS
+	parser      *jwt.Parser
+	tenantClaim string
+}
+
+// NewTokenVerifier creates verifier of tokens signed by keys, tenant of the token is string claim named tenantClaim.
+// iss and aud claims are verified when issuer and audience are not empty.
+func NewTokenVerifier(keys *JWKS, issuer, audience, tenantClaim string) *TokenVerifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	return &TokenVerifier{
+		keys:        keys,
+		parser:      jwt.NewParser(opts...),
+		tenantClaim: tenantClaim,
+	}
+}
+
+// Verify returns claims of valid token, entity.ErrTokenInvalid when the token is malformed, expired or not signed by the key set.
+// Other errors mean the key set could not be loaded.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*TokenClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenUnverifiable) && !errors.Is(err, errUnknownKey) {
+			return nil, fmt.Errorf("error loading key set: %w", err)
+		}
+		return nil, entity.NewError(entity.ErrTokenInvalid, entity.ErrMsgTokenInvalid)
+	}
+
+	// tenant owns decks created with the token
+	tenant, _ := claims[v.tenantClaim].(string)
+	if tenant == "" {
+		return nil, entity.NewError(entity.ErrTokenInvalid, entity.ErrMsgTokenInvalid)
+	}
+	scope, _ := claims["scope"].(string)
+
+	return &TokenClaims{Tenant: tenant, Scope: scope}, nil
+}
+
+// Bearer returns middleware that authenticates requests by JWT sent in "Authorization: Bearer" header,
+// passing tenant of the token to handlers with entity.WithTenant and its scopes with entity.WithScopes.
+// Requests without bearer token are passed as is, so it is followed by APIKey middleware for the rest of clients.
+func Bearer(verifier *TokenVerifier, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !found || !strings.EqualFold(scheme, bearerScheme) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := verifier.Verify(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				if perr, ok := err.(*entity.Error); ok {
+					w.Header().Set("WWW-Authenticate", bearerScheme+` error="invalid_token"`)
+					writeError(w, r, perr)
+					return
+				}
+
+				log.Error().Err(err).Msg("[bearer] error verifying token")
+				writeError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+				return
+			}
+
+			ctx := entity.WithTenant(r.Context(), claims.Tenant)
+			ctx = entity.WithScopes(ctx, claims.Scopes())
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireAuthentication returns middleware that rejects requests authenticated neither by bearer token nor API key,
+// with 401 and Bearer challenge. Used when a key set is configured, so requests without credentials do not get more rights
+// than tokens limited by scopes.
+func RequireAuthentication(writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if entity.TenantFromContext(r.Context()) == "" {
+				w.Header().Set("WWW-Authenticate", bearerScheme)
+				writeError(w, r, entity.NewError(entity.ErrTokenRequired, entity.ErrMsgTokenRequired))
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope returns middleware that rejects requests made by bearer token without scope.
+// Requests authenticated by API key are not limited by scopes.
+// Requests without credentials are rejected when authenticated is true, see RequireAuthentication.
+func RequireScope(scope string, authenticated bool, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		h = requireScope(scope, writeError)(h)
+		if authenticated {
+			h = RequireAuthentication(writeError)(h)
+		}
+		return h
+	}
+}
+
+// requireScope rejects requests made by bearer token without scope
+func requireScope(scope string, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, ok := entity.ScopesFromContext(r.Context()); ok && !scopes.Allow(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s error="insufficient_scope", scope="%s"`, bearerScheme, scope))
+				writeError(w, r, entity.NewError(entity.ErrScopeInsufficient, entity.ErrMsgScopeInsufficient))
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package middleware_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	mock_middleware "github.com/raymondwongso/carddeck/test/mock/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/"
+	testAudience = "carddeck"
+)
+
+// signingKey is locally generated key signing test tokens
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    any
+}
+
+func newRSAKey(t *testing.T, kid string) *signingKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodRS256, key: key}
+}
+
+func newECKey(t *testing.T, kid string) *signingKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodES256, key: key}
+}
+
+func (k *signingKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// jwks returns JSON Web Key Set having public keys of keys
+func jwks(t *testing.T, keys ...*signingKey) []byte {
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	var set []map[string]string
+	for _, k := range keys {
+		switch key := k.key.(type) {
+		case *rsa.PrivateKey:
+			set = append(set, map[string]string{
+				"kty": "RSA", "kid": k.kid, "use": "sig", "alg": "RS256",
+				"n": encode(key.N.Bytes()),
+				"e": encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PrivateKey:
+			set = append(set, map[string]string{
+				"kty": "EC", "kid": k.kid, "use": "sig", "alg": "ES256", "crv": "P-256",
+				"x": encode(key.X.FillBytes(make([]byte, 32))),
+				"y": encode(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{"keys": set})
+	require.NoError(t, err)
+	return data
+}
+
+// validClaims returns claims of token accepted by the verifier
+func validClaims(scope string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "studio-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": scope,
+	}
+}
+
+type BearerTestSuite struct {
+	suite.Suite
+	rsaKey   *signingKey
+	ecKey    *signingKey
+	otherKey *signingKey
+	keys     *middleware.JWKS
+	verifier *middleware.TokenVerifier
+	apiKeys  *mock_middleware.MockAPIKeyAuthenticator
+
+	tenant string
+	scopes entity.Scopes
+	calls  int
+	next   http.Handler
+}
+
+func (s *BearerTestSuite) SetupSuite() {
+	s.rsaKey = newRSAKey(s.T(), "rsa-1")
+	s.ecKey = newECKey(s.T(), "ec-1")
+	// otherKey is not in the key set
+	s.otherKey = newECKey(s.T(), "ec-1")
+
+	path := filepath.Join(s.T().TempDir(), "jwks.json")
+	require.NoError(s.T(), os.WriteFile(path, jwks(s.T(), s.rsaKey, s.ecKey), 0o600))
+	s.keys = middleware.NewFileJWKS(path, time.Hour)
+	s.verifier = middleware.NewTokenVerifier(s.keys, testIssuer, testAudience, "sub")
+	s.apiKeys = mock_middleware.NewMockAPIKeyAuthenticator(gomock.NewController(s.T()))
+}
+
+func (s *BearerTestSuite) SetupTest() {
+	s.tenant = ""
+	s.scopes = nil
+	s.calls = 0
+	s.next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		s.tenant = entity.TenantFromContext(r.Context())
+		s.scopes, _ = entity.ScopesFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestBearer(t *testing.T) {
+	suite.Run(t, new(BearerTestSuite))
+}
+
+func (s *BearerTestSuite) serve(verifier *middleware.TokenVerifier, authorization string) *http.Response {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+
+	middleware.Bearer(verifier, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+	return w.Result()
+}
+
+func (s *BearerTestSuite) assertInvalidToken(response *http.Response) {
+	assert.Equal(s.T(), http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(s.T(), `Bearer error="invalid_token"`, response.Header.Get("WWW-Authenticate"))
+	assert.Equal(s.T(), 0, s.calls)
+
+	var resp entity.Error
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+	assert.Equal(s.T(), entity.ErrTokenInvalid, resp.Code)
+}
+
+func (s *BearerTestSuite) TestValidToken() {
+	s.Run("success - RS256", func() {
+		response := s.serve(s.verifier, "Bearer "+s.rsaKey.sign(s.T(), validClaims("deck:read deck:draw")))
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Equal(s.T(), "studio-1", s.tenant)
+		assert.Equal(s.T(), entity.Scopes{entity.ScopeDeckRead, entity.ScopeDeckDraw}, s.scopes)
+	})
+
+	s.Run("success - ES256 with lowercase scheme", func() {
+		s.calls = 0
+		response := s.serve(s.verifier, "bearer "+s.ecKey.sign(s.T(), validClaims("deck:admin")))
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Equal(s.T(), entity.Scopes{entity.ScopeDeckAdmin}, s.scopes)
+	})
+
+	s.Run("success - tenant from custom claim", func() {
+		s.calls = 0
+		verifier := middleware.NewTokenVerifier(s.keys, testIssuer, testAudience, "org_id")
+		claims := validClaims("deck:read")
+		claims["org_id"] = "studio-org"
+		response := s.serve(verifier, "Bearer "+s.rsaKey.sign(s.T(), claims))
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Equal(s.T(), "studio-org", s.tenant)
+	})
+
+	s.Run("failed - custom tenant claim missing", func() {
+		s.calls = 0
+		verifier := middleware.NewTokenVerifier(s.keys, testIssuer, testAudience, "org_id")
+		s.assertInvalidToken(s.serve(verifier, "Bearer "+s.rsaKey.sign(s.T(), validClaims("deck:read"))))
+	})
+}
+
+func (s *BearerTestSuite) TestWithoutToken() {
+	s.Run("success - no Authorization header", func() {
+		response := s.serve(s.verifier, "")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Empty(s.T(), s.tenant)
+	})
+
+	s.Run("success - other scheme is left to other middleware", func() {
+		s.calls = 0
+		response := s.serve(s.verifier, "Basic dXNlcjpwYXNz")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+		assert.Empty(s.T(), s.tenant)
+	})
+}
+
+func (s *BearerTestSuite) TestInvalidToken() {
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims("deck:admin"))
+	hs256.Header["kid"] = "rsa-1"
+	hs256Token, err := hs256.SignedString([]byte("secret"))
+	require.NoError(s.T(), err)
+
+	claims := func(modify func(jwt.MapClaims)) jwt.MapClaims {
+		c := validClaims("deck:read")
+		modify(c)
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"expired", s.rsaKey.sign(s.T(), claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }))},
+		{"without expiration", s.rsaKey.sign(s.T(), claims(func(c jwt.MapClaims) { delete(c, "exp") }))},
+		{"other issuer", s.rsaKey.sign(s.T(), claims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com/" }))},
+		{"other audience", s.rsaKey.sign(s.T(), claims(func(c jwt.MapClaims) { c["aud"] = "other-service" }))},
+		{"without subject", s.rsaKey.sign(s.T(), claims(func(c jwt.MapClaims) { delete(c, "sub") }))},
+		{"signed by key outside key set", s.otherKey.sign(s.T(), validClaims("deck:read"))},
+		{"unknown key ID", newECKey(s.T(), "ec-unknown").sign(s.T(), validClaims("deck:read"))},
+		{"unsupported algorithm", hs256Token},
+	}
+
+	for _, tt := range tests {
+		s.Run("failed - "+tt.name, func() {
+			s.calls = 0
+			s.assertInvalidToken(s.serve(s.verifier, "Bearer "+tt.token))
+		})
+	}
+}
+
+func (s *BearerTestSuite) TestKeySetUnavailable() {
+	missing := filepath.Join(s.T().TempDir(), "missing.json")
+	verifier := middleware.NewTokenVerifier(middleware.NewFileJWKS(missing, time.Hour), testIssuer, testAudience, "sub")
+
+	response := s.serve(verifier, "Bearer "+s.rsaKey.sign(s.T(), validClaims("deck:read")))
+
+	assert.Equal(s.T(), http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *BearerTestSuite) TestRequireScope() {
+	serveWith := func(authenticated bool, authorization, apiKey string) *http.Response {
+		r := httptest.NewRequest(http.MethodDelete, "http://localhost/decks/some-uuid", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		if apiKey != "" {
+			r.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+
+		apiKeyAuth := middleware.APIKey(s.apiKeys, false, carddeck.WriteError)
+		middleware.Bearer(s.verifier, carddeck.WriteError)(apiKeyAuth(middleware.RequireScope(entity.ScopeDeckAdmin, authenticated, carddeck.WriteError)(s.next))).ServeHTTP(w, r)
+		return w.Result()
+	}
+	serve := func(authorization string) *http.Response {
+		return serveWith(true, authorization, "")
+	}
+
+	s.Run("success - scope granted", func() {
+		s.calls = 0
+		response := serve("Bearer " + s.rsaKey.sign(s.T(), validClaims("deck:admin")))
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+	})
+
+	s.Run("success - request by API key is not limited", func() {
+		s.calls = 0
+		s.apiKeys.EXPECT().AuthenticateAPIKey(gomock.Any(), "cdk_valid").Return(&entity.APIKey{ID: "api-key-1", TenantID: "tenant-1"}, nil)
+		response := serveWith(true, "", "cdk_valid")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+	})
+
+	s.Run("success - request without credentials is not limited when tokens are disabled", func() {
+		s.calls = 0
+		response := serveWith(false, "", "")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), 1, s.calls)
+	})
+
+	s.Run("failed - request without credentials on admin route", func() {
+		s.calls = 0
+		response := serve("")
+
+		assert.Equal(s.T(), http.StatusUnauthorized, response.StatusCode)
+		assert.Equal(s.T(), "Bearer", response.Header.Get("WWW-Authenticate"))
+		assert.Equal(s.T(), 0, s.calls)
+
+		var resp entity.Error
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), entity.ErrTokenRequired, resp.Code)
+	})
+
+	s.Run("failed - scope not granted", func() {
+		s.calls = 0
+		response := serve("Bearer " + s.rsaKey.sign(s.T(), validClaims("deck:read deck:draw")))
+
+		assert.Equal(s.T(), http.StatusForbidden, response.StatusCode)
+		assert.Equal(s.T(), `Bearer error="insufficient_scope", scope="deck:admin"`, response.Header.Get("WWW-Authenticate"))
+		assert.Equal(s.T(), 0, s.calls)
+
+		var resp entity.Error
+		assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+		assert.Equal(s.T(), entity.ErrScopeInsufficient, resp.Code)
+	})
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	encodingZstd   = "zstd"
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// encoder compresses response body, pooled per encoding and reset for every response
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var (
+	// encodings lists supported encodings in order of preference when client accepts several of them equally
+	encodings = []string{encodingZstd, encodingBrotli, encodingGzip}
+
+	encoderPools = map[string]*sync.Pool{
+		encodingZstd: {New: func() any {
+			enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return enc
+		}},
+		encodingBrotli: {New: func() any {
+			return brotli.NewWriterLevel(nil, 4)
+		}},
+		encodingGzip: {New: func() any {
+			return gzip.NewWriter(nil)
+		}},
+	}
+
+	// compressibleTypes lists media types worth compressing, others are usually compressed already (e.g. image/png)
+	compressibleTypes = map[string]bool{
+		"application/json":         true,
+		"application/problem+json": true,
+		"application/msgpack":      true,
+		"application/cbor":         true,
+		"application/protobuf":     true,
+		"image/svg+xml":            true,
+	}
+)
+
+// Compress returns middleware that compresses responses with zstd, brotli or gzip, whichever is preferred by Accept-Encoding header.
+// Bodyless responses, streams (e.g. text/event-stream), WebSocket upgrades and incompressible media types are passed as is.
+func Compress(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: negotiateEncoding(r.Header.Values("Accept-Encoding"))}
+		defer cw.close()
+
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding returns supported encoding having the highest quality in Accept-Encoding header, empty when none is accepted
+func negotiateEncoding(acceptEncoding []string) string {
+	accepted := map[string]float64{}
+	for _, header := range acceptEncoding {
+		for _, coding := range strings.Split(header, ",") {
+			name, params, err := mime.ParseMediaType(strings.TrimSpace(coding))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if v, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			accepted[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range encodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter decides whether to compress right before the header is written
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     encoder
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	// 304 has no Content-Type, yet it stands for a representation that varies by Accept-Encoding
+	if !compressibleTypes[mediaType] && code != http.StatusNotModified {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+	if w.encoding != "" && header.Get("Content-Encoding") == "" && bodyAllowed(code) {
+		// compressed body is not byte for byte the same as the identity one, so a strong ETag becomes weak
+		if tag := header.Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+			header.Set("ETag", "W/"+tag)
+		}
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, needed by streaming responses
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder != nil {
+		if err := w.encoder.Flush(); err != nil {
+			log.Error().Err(err).Msg("[compress] error flushing compressed response")
+		}
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original writer, used by http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes compressed body and returns the encoder into its pool
+func (w *compressWriter) close() {
+	if w.encoder == nil {
+		return
+	}
+	if err := w.encoder.Close(); err != nil {
+		log.Error().Err(err).Msg("[compress] error closing compressed response")
+	}
+	w.encoder.Reset(nil)
+	encoderPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+}
+
+// bodyAllowed reports whether response with the status has a body
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
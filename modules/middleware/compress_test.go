@@ -0,0 +1,187 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var compressBody = strings.Repeat(`{"value":"ACE","suit":"SPADES","code":"AS"},`, 50)
+
+type CompressTestSuite struct {
+	suite.Suite
+}
+
+func TestCompress(t *testing.T) {
+	suite.Run(t, new(CompressTestSuite))
+}
+
+func serve(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	middleware.Compress(h).ServeHTTP(w, r)
+	return w
+}
+
+func writeBody(contentType string, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "1")
+		w.Header().Set("ETag", `"3"`)
+		w.WriteHeader(status)
+		if status != http.StatusNoContent && status != http.StatusNotModified {
+			_, _ = w.Write([]byte(compressBody))
+		}
+	})
+}
+
+func decompress(encoding string, body io.Reader) ([]byte, error) {
+	switch encoding {
+	case "gzip":
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	case "br":
+		return io.ReadAll(brotli.NewReader(body))
+	case "zstd":
+		r, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return io.ReadAll(body)
+	}
+}
+
+func (s *CompressTestSuite) TestEncodings() {
+	cases := map[string]string{
+		"gzip":                       "gzip",
+		"br":                         "br",
+		"zstd":                       "zstd",
+		"gzip, deflate, br":          "br",
+		"gzip, br, zstd":             "zstd",
+		"gzip;q=1, br;q=0.5":         "gzip",
+		"*":                          "zstd",
+		"zstd;q=0, *;q=0.1":          "br",
+		"deflate":                    "",
+		"gzip;q=0, br;q=0, zstd;q=0": "",
+	}
+
+	for acceptEncoding, encoding := range cases {
+		for i := 0; i < 2; i++ { // second round uses pooled encoders
+			w := serve(writeBody("application/json", http.StatusOK), acceptEncoding)
+
+			assert.Equal(s.T(), http.StatusOK, w.Code, acceptEncoding)
+			assert.Equal(s.T(), encoding, w.Header().Get("Content-Encoding"), acceptEncoding)
+			assert.Contains(s.T(), w.Header().Values("Vary"), "Accept-Encoding", acceptEncoding)
+			if encoding != "" {
+				assert.Equal(s.T(), `W/"3"`, w.Header().Get("ETag"), acceptEncoding)
+			} else {
+				assert.Equal(s.T(), `"3"`, w.Header().Get("ETag"), acceptEncoding)
+			}
+
+			body, err := decompress(encoding, w.Body)
+			require.NoError(s.T(), err, acceptEncoding)
+			assert.Equal(s.T(), compressBody, string(body), acceptEncoding)
+			if encoding != "" {
+				assert.Empty(s.T(), w.Header().Get("Content-Length"), acceptEncoding)
+				assert.Less(s.T(), w.Body.Len(), len(compressBody), acceptEncoding)
+			}
+		}
+	}
+}
+
+func (s *CompressTestSuite) TestWithoutAcceptEncoding() {
+	w := serve(writeBody("application/json", http.StatusOK), "")
+
+	assert.Empty(s.T(), w.Header().Get("Content-Encoding"))
+	assert.Contains(s.T(), w.Header().Values("Vary"), "Accept-Encoding")
+	assert.Equal(s.T(), compressBody, w.Body.String())
+}
+
+func (s *CompressTestSuite) TestIncompressibleType() {
+	for _, contentType := range []string{"image/png", "text/event-stream"} {
+		w := serve(writeBody(contentType, http.StatusOK), "gzip")
+
+		assert.Empty(s.T(), w.Header().Get("Content-Encoding"), contentType)
+		assert.NotContains(s.T(), w.Header().Values("Vary"), "Accept-Encoding", contentType)
+		assert.Equal(s.T(), compressBody, w.Body.String(), contentType)
+	}
+}
+
+func (s *CompressTestSuite) TestBodylessStatus() {
+	for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+		w := serve(writeBody("application/json", status), "gzip")
+
+		assert.Equal(s.T(), status, w.Code)
+		assert.Empty(s.T(), w.Header().Get("Content-Encoding"), status)
+		assert.Zero(s.T(), w.Body.Len(), status)
+	}
+
+	w := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}), "gzip")
+	assert.Contains(s.T(), w.Header().Values("Vary"), "Accept-Encoding")
+}
+
+func (s *CompressTestSuite) TestContentTypeSniffed() {
+	w := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(compressBody))
+	}), "gzip")
+
+	// sniffed as text/plain, which is not compressed
+	assert.Equal(s.T(), "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(s.T(), w.Header().Get("Content-Encoding"))
+}
+
+func (s *CompressTestSuite) TestFlush() {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	var flushed int
+	middleware.Compress(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(compressBody))
+		require.NoError(s.T(), http.NewResponseController(rw).Flush())
+		flushed = w.Body.Len()
+	})).ServeHTTP(w, r)
+
+	assert.True(s.T(), w.Flushed)
+	assert.NotZero(s.T(), flushed)
+
+	body, err := decompress("gzip", w.Body)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), compressBody, string(body))
+}
+
+func (s *CompressTestSuite) TestUpgrade() {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid/ws", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("Upgrade", "websocket")
+	w := httptest.NewRecorder()
+
+	var unwrapped bool
+	middleware.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, unwrapped = w.(*httptest.ResponseRecorder)
+	})).ServeHTTP(w, r)
+
+	assert.True(s.T(), unwrapped)
+}
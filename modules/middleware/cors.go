@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CORSPolicy configures cross-origin requests allowed from browsers
+type CORSPolicy struct {
+	// AllowedOrigins are origins allowed to make requests, "*" allows every origin. Empty disables CORS.
+	AllowedOrigins []string
+	// AllowedMethods are methods allowed in preflight requests, in addition to the route having the method
+	AllowedMethods []string
+	// AllowedHeaders are request headers allowed in preflight requests, case insensitive
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by scripts besides CORS-safelisted ones
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or HTTP authentication.
+	// Browsers refuse credentials of responses allowing "*", so the allowed origins must be listed.
+	AllowCredentials bool
+	// MaxAge is how long (in seconds) browsers cache preflight responses, 0 leaves it to the browser
+	MaxAge int
+}
+
+// allowOrigin returns value of Access-Control-Allow-Origin header for the origin, empty when the origin is not allowed
+func (p CORSPolicy) allowOrigin(origin string) string {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// CheckOrigin reports whether WebSocket handshake request r comes from an origin allowed by the policy.
+// Requests without Origin header are not made by browsers and are allowed,
+// as are requests from the same host, the same as default check of gorilla/websocket.
+func (p CORSPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.allowOrigin(origin) != ""
+}
+
+// allowHeaders returns whether every header of comma separated list is allowed
+func (p CORSPolicy) allowHeaders(headers string) bool {
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, header) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS returns handler serving mux with CORS headers of the policy for allowed origins.
+// Routes of mux are method-scoped, so preflight requests are answered here when mux has a route
+// for the requested method and path, and the method and headers are allowed by the policy.
+// Preflight requests not allowed are passed to mux, which rejects OPTIONS method.
+func CORS(mux *http.ServeMux, policy CORSPolicy) http.Handler {
+	if len(policy.AllowedOrigins) == 0 {
+		return mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		allowOrigin := policy.allowOrigin(origin)
+		if origin == "" || allowOrigin == "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && method != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !preflightAllowed(mux, policy, r, method) {
+				mux.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			w.Header().Set("Access-Control-Allow-Methods", method)
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if policy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		if policy.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if len(policy.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// preflightAllowed returns whether the policy allows preflight request r for method, and mux has a route for it
+func preflightAllowed(mux *http.ServeMux, policy CORSPolicy, r *http.Request, method string) bool {
+	if !slices.Contains(policy.AllowedMethods, method) {
+		return false
+	}
+	if !policy.allowHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+		return false
+	}
+
+	route := r.Clone(r.Context())
+	route.Method = method
+	_, pattern := mux.Handler(route)
+	return pattern != ""
+}
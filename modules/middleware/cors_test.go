@@ -0,0 +1,186 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CORSTestSuite struct {
+	suite.Suite
+	mux    *http.ServeMux
+	policy middleware.CORSPolicy
+	calls  int
+}
+
+func (s *CORSTestSuite) SetupTest() {
+	s.calls = 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		w.WriteHeader(http.StatusOK)
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /decks/{id}", handler)
+	s.mux.HandleFunc("DELETE /decks/{id}", handler)
+	s.mux.HandleFunc("POST /decks/{id}/cards", handler)
+
+	s.policy = middleware.CORSPolicy{
+		AllowedOrigins: []string{"https://game.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"ETag", "Retry-After"},
+		MaxAge:         600,
+	}
+}
+
+func TestCORS(t *testing.T) {
+	suite.Run(t, new(CORSTestSuite))
+}
+
+func (s *CORSTestSuite) preflight(policy middleware.CORSPolicy, origin, path, method, headers string) *http.Response {
+	r := httptest.NewRequest(http.MethodOptions, "http://localhost"+path, nil)
+	r.Header.Set("Origin", origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	w := httptest.NewRecorder()
+
+	middleware.CORS(s.mux, policy).ServeHTTP(w, r)
+	return w.Result()
+}
+
+func (s *CORSTestSuite) TestPreflight() {
+	s.Run("success - route has the method", func() {
+		response := s.preflight(s.policy, "https://game.example.com", "/decks/some-uuid", http.MethodDelete, "x-api-key, content-type")
+
+		assert.Equal(s.T(), http.StatusNoContent, response.StatusCode)
+		assert.Equal(s.T(), "https://game.example.com", response.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(s.T(), http.MethodDelete, response.Header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(s.T(), "x-api-key, content-type", response.Header.Get("Access-Control-Allow-Headers"))
+		assert.Equal(s.T(), "600", response.Header.Get("Access-Control-Max-Age"))
+		assert.Empty(s.T(), response.Header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(s.T(), []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, response.Header.Values("Vary"))
+		assert.Equal(s.T(), 0, s.calls)
+	})
+
+	s.Run("success - every origin with credentials does not echo origin", func() {
+		policy := s.policy
+		policy.AllowedOrigins = []string{"*"}
+		policy.AllowCredentials = true
+		response := s.preflight(policy, "https://other.example.com", "/decks/some-uuid/cards", http.MethodPost, "")
+
+		assert.Equal(s.T(), http.StatusNoContent, response.StatusCode)
+		assert.Equal(s.T(), "*", response.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	tests := []struct {
+		name    string
+		origin  string
+		path    string
+		method  string
+		headers string
+	}{
+		{"origin not allowed", "https://evil.example.com", "/decks/some-uuid", http.MethodGet, ""},
+		{"route does not have the method", "https://game.example.com", "/decks/some-uuid/cards", http.MethodDelete, ""},
+		{"method not allowed", "https://game.example.com", "/decks/some-uuid", http.MethodPut, ""},
+		{"header not allowed", "https://game.example.com", "/decks/some-uuid", http.MethodGet, "X-Custom"},
+		{"route not found", "https://game.example.com", "/unknown", http.MethodGet, ""},
+	}
+
+	for _, tt := range tests {
+		s.Run("failed - "+tt.name, func() {
+			response := s.preflight(s.policy, tt.origin, tt.path, tt.method, tt.headers)
+
+			assert.NotEqual(s.T(), http.StatusNoContent, response.StatusCode)
+			assert.Empty(s.T(), response.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(s.T(), 0, s.calls)
+		})
+	}
+}
+
+func (s *CORSTestSuite) TestRequest() {
+	serve := func(policy middleware.CORSPolicy, origin string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+
+		middleware.CORS(s.mux, policy).ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	s.Run("success - allowed origin", func() {
+		response := serve(s.policy, "https://game.example.com")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Equal(s.T(), "https://game.example.com", response.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(s.T(), "ETag, Retry-After", response.Header.Get("Access-Control-Expose-Headers"))
+		assert.Equal(s.T(), "Origin", response.Header.Get("Vary"))
+	})
+
+	s.Run("success - every origin", func() {
+		policy := s.policy
+		policy.AllowedOrigins = []string{"*"}
+		response := serve(policy, "https://other.example.com")
+
+		assert.Equal(s.T(), "*", response.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	s.Run("success - origin not allowed is served without CORS headers", func() {
+		s.calls = 0
+		response := serve(s.policy, "https://evil.example.com")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Empty(s.T(), response.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(s.T(), 1, s.calls)
+	})
+
+	s.Run("success - same origin request", func() {
+		response := serve(s.policy, "")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Empty(s.T(), response.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	s.Run("success - CORS disabled", func() {
+		response := serve(middleware.CORSPolicy{}, "https://game.example.com")
+
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Empty(s.T(), response.Header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(s.T(), response.Header.Get("Vary"))
+	})
+}
+
+func (s *CORSTestSuite) TestCheckOrigin() {
+	tests := []struct {
+		name     string
+		policy   middleware.CORSPolicy
+		host     string
+		origin   string
+		expected bool
+	}{
+		{"request without origin", s.policy, "api.example.com", "", true},
+		{"same host", s.policy, "api.example.com", "https://api.example.com", true},
+		{"allowed origin", s.policy, "api.example.com", "https://game.example.com", true},
+		{"origin not allowed", s.policy, "api.example.com", "https://evil.example.com", false},
+		{"CORS disabled", middleware.CORSPolicy{}, "api.example.com", "https://game.example.com", false},
+		{"every origin", middleware.CORSPolicy{AllowedOrigins: []string{"*"}}, "api.example.com", "https://evil.example.com", true},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			r := httptest.NewRequest(http.MethodGet, "http://"+tt.host+"/decks/some-uuid/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+
+			assert.Equal(s.T(), tt.expected, tt.policy.CheckOrigin(r))
+		})
+	}
+}
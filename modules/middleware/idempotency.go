@@ -0,0 +1,203 @@
+// Package middleware contains various middleware needed by HTTP REST API Server
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyBodyLimit      = 1 << 20
+	// idempotencyLockTimeout is how long a key stays reserved without response,
+	// after that the request is assumed to have died with the server and the key can be reserved again
+	idempotencyLockTimeout = time.Minute
+	// idempotencyCompleteAttempts is number of attempts storing the response before the key is released
+	idempotencyCompleteAttempts = 2
+)
+
+// ErrorWriter writes err as response of the request, mapping its entity.Error code into HTTP status.
+// Middleware use the writer of the API they are in front of, e.g. carddeck.WriteError, so errors look the same.
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, err error)
+
+// IdempotencyStore defines storage for responses of requests made with Idempotency-Key header
+type IdempotencyStore interface {
+	// Reserve reserves the key for a new request.
+	// Returns the existing record when the key is already used and has not expired yet,
+	// unless it has been reserved without response for longer than lockTimeout.
+	Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time, lockTimeout time.Duration) (*entity.IdempotencyRecord, error)
+	// Complete stores the response of reserved key.
+	Complete(ctx context.Context, record *entity.IdempotencyRecord) error
+	// Release removes reserved key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency returns middleware that replays the first stored response
+// for retried requests having the same Idempotency-Key header.
+// Request with the same key but different method, path, query or body is rejected.
+// Responses with 5xx status code are not stored and keys of handlers that panicked are released, so the request can be retried.
+// Requests without Idempotency-Key header are passed as is.
+// Keys are scoped to the tenant of the request, so it must run after Bearer and APIKey middleware.
+func Idempotency(store IdempotencyStore, ttl time.Duration, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > idempotencyKeyMaxLength {
+				err := entity.NewError(entity.ErrParamInvalid, entity.ErrMsgParamInvalid)
+				err.AddDetail(entity.NewErrorDetail(idempotencyKeyHeader, "Idempotency-Key header is too long"))
+				writeError(w, r, err)
+				return
+			}
+
+			key = scopedKey(r.Context(), key)
+
+			// read one byte past the limit, so larger body is rejected instead of being fingerprinted and passed truncated
+			body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyBodyLimit+1))
+			if err != nil {
+				log.Error().Err(err).Msg("[idempotency] error reading request body")
+				writeError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+				return
+			}
+			if len(body) > idempotencyBodyLimit {
+				writeError(w, r, entity.NewError(entity.ErrRequestTooLarge, entity.ErrMsgRequestTooLarge))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := requestFingerprint(r, body)
+			existing, err := store.Reserve(r.Context(), key, fingerprint, time.Now().Add(ttl), idempotencyLockTimeout)
+			if err != nil {
+				log.Error().Err(err).Msg("[idempotency] error reserving idempotency key")
+				writeError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+				return
+			}
+
+			if existing != nil {
+				replay(w, r, existing, fingerprint, writeError)
+				return
+			}
+
+			// use background context, client might have gone while the response is stored
+			ctx := context.Background()
+			completed := false
+			// deferred, so the key is also released when the handler panics
+			defer func() {
+				if completed {
+					return
+				}
+				if err := store.Release(ctx, key); err != nil {
+					log.Error().Err(err).Msg("[idempotency] error releasing idempotency key")
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w}
+			h.ServeHTTP(rec, r)
+
+			if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+				return
+			}
+
+			header := w.Header().Clone()
+			header.Del("Date")
+			record := &entity.IdempotencyRecord{
+				Key:         key,
+				Fingerprint: fingerprint,
+				StatusCode:  rec.status,
+				Header:      header,
+				Body:        rec.body.Bytes(),
+			}
+			for attempt := 1; attempt <= idempotencyCompleteAttempts && !completed; attempt++ {
+				if err := store.Complete(ctx, record); err != nil {
+					log.Error().Err(err).Int("attempt", attempt).Msg("[idempotency] error storing idempotent response")
+					continue
+				}
+				completed = true
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, record *entity.IdempotencyRecord, fingerprint string, writeError ErrorWriter) {
+	if record.Fingerprint != fingerprint {
+		writeError(w, r, entity.NewError(entity.ErrIdempotencyKeyConflict, entity.ErrMsgIdempotencyKeyConflict))
+		return
+	}
+
+	if record.StatusCode == 0 {
+		writeError(w, r, entity.NewError(entity.ErrIdempotencyKeyInProgress, entity.ErrMsgIdempotencyKeyInProgress))
+		return
+	}
+
+	for k, v := range record.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	if _, err := w.Write(record.Body); err != nil {
+		log.Error().Err(err).Msg("[idempotency] error replaying response")
+	}
+}
+
+// scopedKey prefixes key with tenant of the request (see entity.WithTenant), so tenants can not replay responses of each other.
+// Key is hashed to fit the storage together with the prefix.
+func scopedKey(ctx context.Context, key string) string {
+	tenantID := entity.TenantFromContext(ctx)
+	if tenantID == "" {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return tenantID + ":" + hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies request payload, so the same key can not be reused for different request
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes response to the client while keeping copy of status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original writer, used by http.ResponseController
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
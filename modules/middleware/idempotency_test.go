@@ -0,0 +1,302 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	mock_middleware "github.com/raymondwongso/carddeck/test/mock/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyTestSuite struct {
+	suite.Suite
+	store *mock_middleware.MockIdempotencyStore
+	calls int
+	next  http.Handler
+}
+
+func (s *IdempotencyTestSuite) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.store = mock_middleware.NewMockIdempotencyStore(ctrl)
+	s.calls = 0
+	s.next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+}
+
+func TestIdempotency(t *testing.T) {
+	suite.Run(t, new(IdempotencyTestSuite))
+}
+
+func (s *IdempotencyTestSuite) TestWithoutKey() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	w := httptest.NewRecorder()
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	assert.Equal(s.T(), http.StatusCreated, w.Result().StatusCode)
+	assert.Equal(s.T(), 1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestFirstRequest() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks?shuffled=true", strings.NewReader("payload"))
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	var fingerprint string
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ any, _ string, fp string, expiresAt time.Time, _ time.Duration) (*entity.IdempotencyRecord, error) {
+			fingerprint = fp
+			assert.WithinDuration(s.T(), time.Now().Add(time.Hour), expiresAt, time.Minute)
+			return nil, nil
+		})
+	s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, record *entity.IdempotencyRecord) error {
+			assert.Equal(s.T(), "key-1", record.Key)
+			assert.Equal(s.T(), fingerprint, record.Fingerprint)
+			assert.Equal(s.T(), http.StatusCreated, record.StatusCode)
+			assert.Equal(s.T(), []string{`"1"`}, record.Header["Etag"])
+			assert.Equal(s.T(), []byte("payload"), record.Body)
+			return nil
+		})
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	response := w.Result()
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+	assert.Equal(s.T(), "payload", string(body))
+	assert.Equal(s.T(), 1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestKeyScopedToTenant() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", strings.NewReader("payload"))
+	r = r.WithContext(entity.WithTenant(r.Context(), "tenant-1"))
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	// tenant followed by SHA-256 of the key
+	scoped := "tenant-1:be2974546978e3739e6d6da85c4be9f334ce32df2b9fd4b6ff1b55c0d57e9d44"
+	s.store.EXPECT().Reserve(gomock.Any(), scoped, gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+	s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ any, record *entity.IdempotencyRecord) error {
+			assert.Equal(s.T(), scoped, record.Key)
+			return nil
+		})
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	assert.Equal(s.T(), http.StatusCreated, w.Result().StatusCode)
+}
+
+func (s *IdempotencyTestSuite) TestReplay() {
+	var fingerprint string
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ any, _ string, fp string, _ time.Time, _ time.Duration) (*entity.IdempotencyRecord, error) {
+			fingerprint = fp
+			return nil, nil
+		})
+	s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(nil)
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ any, _ string, fp string, _ time.Time, _ time.Duration) (*entity.IdempotencyRecord, error) {
+			return &entity.IdempotencyRecord{
+				Key:         "key-1",
+				Fingerprint: fingerprint,
+				StatusCode:  http.StatusCreated,
+				Header:      map[string][]string{"Etag": {`"1"`}},
+				Body:        []byte("payload"),
+			}, nil
+		})
+
+	h := middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next)
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", strings.NewReader("payload"))
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		response := w.Result()
+		body, _ := io.ReadAll(response.Body)
+		assert.Equal(s.T(), http.StatusCreated, response.StatusCode)
+		assert.Equal(s.T(), "payload", string(body))
+		assert.Equal(s.T(), `"1"`, response.Header.Get("ETag"))
+		if i == 1 {
+			assert.Equal(s.T(), "true", response.Header.Get("Idempotent-Replayed"))
+		}
+	}
+
+	assert.Equal(s.T(), 1, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestConflict() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", strings.NewReader("other payload"))
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(&entity.IdempotencyRecord{
+		Key:         "key-1",
+		Fingerprint: "fingerprint-of-other-request",
+		StatusCode:  http.StatusCreated,
+	}, nil)
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	s.assertError(w, http.StatusUnprocessableEntity, entity.ErrIdempotencyKeyConflict)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestInProgress() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(
+		func(_ any, key string, fp string, _ time.Time, _ time.Duration) (*entity.IdempotencyRecord, error) {
+			return &entity.IdempotencyRecord{Key: key, Fingerprint: fp}, nil
+		})
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	s.assertError(w, http.StatusConflict, entity.ErrIdempotencyKeyInProgress)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestReleaseOnServerError() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+	s.store.EXPECT().Release(gomock.Any(), "key-1").Return(nil)
+
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(failing).ServeHTTP(w, r)
+
+	assert.Equal(s.T(), http.StatusInternalServerError, w.Result().StatusCode)
+}
+
+func (s *IdempotencyTestSuite) TestReleaseOnPanic() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+	s.store.EXPECT().Release(gomock.Any(), "key-1").Return(nil)
+
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("some panic")
+	})
+	// net/http recovers the panic of the handler
+	assert.Panics(s.T(), func() {
+		middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(panicking).ServeHTTP(w, r)
+	})
+}
+
+func (s *IdempotencyTestSuite) TestCompleteError() {
+	s.Run("success - storing the response is retried", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+
+		s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+		gomock.InOrder(
+			s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(errors.New("some error")),
+			s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(nil),
+		)
+
+		middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+		assert.Equal(s.T(), http.StatusCreated, w.Result().StatusCode)
+	})
+
+	s.Run("success - key is released when the response can not be stored", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+
+		s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+		s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(errors.New("some error")).Times(2)
+		s.store.EXPECT().Release(gomock.Any(), "key-1").Return(nil)
+
+		middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+		assert.Equal(s.T(), http.StatusCreated, w.Result().StatusCode)
+	})
+}
+
+func (s *IdempotencyTestSuite) TestStoreError() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, errors.New("some error"))
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	s.assertError(w, http.StatusInternalServerError, entity.ErrInternal)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) TestKeyTooLong() {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks", nil)
+	r.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+	w := httptest.NewRecorder()
+
+	middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+	s.assertError(w, http.StatusBadRequest, entity.ErrParamInvalid)
+	assert.Equal(s.T(), 0, s.calls)
+}
+
+func (s *IdempotencyTestSuite) assertError(w *httptest.ResponseRecorder, status int, code string) {
+	response := w.Result()
+	assert.Equal(s.T(), status, response.StatusCode)
+
+	var perr entity.Error
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&perr))
+	assert.Equal(s.T(), code, perr.Code)
+}
+
+func (s *IdempotencyTestSuite) TestBodyTooLarge() {
+	s.Run("success - body at the limit", func() {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(strings.Repeat("a", 1<<20)))
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+
+		s.store.EXPECT().Reserve(gomock.Any(), "key-1", gomock.Any(), gomock.Any(), time.Minute).Return(nil, nil)
+		s.store.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(nil)
+
+		middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+		assert.Equal(s.T(), http.StatusCreated, w.Result().StatusCode)
+		assert.Equal(s.T(), 1<<20, w.Body.Len())
+	})
+
+	s.Run("failed - body over the limit", func() {
+		s.calls = 0
+		r := httptest.NewRequest(http.MethodPost, "http://localhost/batch", strings.NewReader(strings.Repeat("a", 1<<20+1)))
+		r.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+
+		middleware.Idempotency(s.store, time.Hour, carddeck.WriteError)(s.next).ServeHTTP(w, r)
+
+		s.assertError(w, http.StatusRequestEntityTooLarge, entity.ErrRequestTooLarge)
+		assert.Equal(s.T(), 0, s.calls)
+	})
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// jwksMinRefreshInterval limits how often unknown key IDs and failed loads reload the key set,
+	// so tokens with made up key IDs or an unavailable identity provider do not flood it with requests
+	jwksMinRefreshInterval = time.Minute
+	jwksBodyLimit          = 1 << 20
+)
+
+// errUnknownKey is returned when the key set does not have key used to sign the token
+var errUnknownKey = errors.New("unknown signing key")
+
+// JWKS is JSON Web Key Set verifying signature of bearer tokens.
+// Keys are cached and reloaded every refresh interval, or sooner when a token is signed by unknown key,
+// so keys rotated by the identity provider are picked up without restarting the server.
+// Failed loads are not retried for a minute, cached keys are served in the meantime.
+type JWKS struct {
+	load    func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+
+	// loadMu makes concurrent requests wait for a single reload
+	loadMu   sync.Mutex
+	mu       sync.RWMutex
+	keys     map[string]any
+	loadedAt time.Time
+	// attemptedAt and loadErr are time and error of the last load, successful or not
+	attemptedAt time.Time
+	loadErr     error
+}
+
+// NewFileJWKS creates key set loaded from JSON file at path
+func NewFileJWKS(path string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		load: func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		refresh: refresh,
+	}
+}
+
+// NewURLJWKS creates key set fetched from url, typically jwks_uri of the identity provider
+func NewURLJWKS(url string, client *http.Client, refresh time.Duration) *JWKS {
+	return &JWKS{
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", "application/json")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected status %d fetching key set", resp.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, jwksBodyLimit))
+		},
+		refresh: refresh,
+	}
+}
+
+// Key returns public key having key ID kid.
+// Stale keys are returned right away while the key set is reloaded in the background.
+func (j *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	loadedAt, attemptedAt, loadErr := j.loadedAt, j.attemptedAt, j.loadErr
+	j.mu.RUnlock()
+
+	// requests do not wait on the identity provider when it was asked recently, even if that failed
+	recent := !attemptedAt.IsZero() && time.Since(attemptedAt) < min(j.refresh, jwksMinRefreshInterval)
+	switch {
+	case ok && time.Since(loadedAt) < j.refresh:
+		return key, nil
+	case ok:
+		if !recent {
+			j.reloadInBackground(attemptedAt)
+		}
+		return key, nil
+	case recent:
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return nil, errUnknownKey
+	}
+
+	if err := j.reload(ctx, attemptedAt); err != nil {
+		return nil, err
+	}
+
+	j.mu.RLock()
+	key, ok = j.keys[kid]
+	j.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownKey
+	}
+	return key, nil
+}
+
+// Refresh loads the key set, used to load keys before the first request
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.mu.RLock()
+	attemptedAt := j.attemptedAt
+	j.mu.RUnlock()
+
+	return j.reload(ctx, attemptedAt)
+}
+
+// reloadInBackground reloads the key set unless a reload is already running
+func (j *JWKS) reloadInBackground(attemptedAt time.Time) {
+	if !j.loadMu.TryLock() {
+		return
+	}
+
+	go func() {
+		defer j.loadMu.Unlock()
+		if err := j.reloadLocked(context.Background(), attemptedAt); err != nil {
+			log.Error().Err(err).Msg("[jwks] error reloading key set, using cached keys")
+		}
+	}()
+}
+
+// reload loads the key set unless another request has attempted to load it after attemptedAt
+func (j *JWKS) reload(ctx context.Context, attemptedAt time.Time) error {
+	j.loadMu.Lock()
+	defer j.loadMu.Unlock()
+
+	return j.reloadLocked(ctx, attemptedAt)
+}
+
+// reloadLocked is reload called with loadMu held
+func (j *JWKS) reloadLocked(ctx context.Context, attemptedAt time.Time) error {
+	j.mu.RLock()
+	attempted, loadErr := j.attemptedAt.After(attemptedAt), j.loadErr
+	j.mu.RUnlock()
+	if attempted {
+		return loadErr
+	}
+
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.attemptedAt = time.Now()
+	j.loadErr = err
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	j.loadedAt = j.attemptedAt
+	return nil
+}
+
+// fetch loads and parses the key set
+func (j *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	data, err := j.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// jwk is JSON Web Key as defined in RFC 7517, only fields of RSA and EC public keys are decoded
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns public keys of the key set by key ID.
+// Keys that are not used for signatures or have unsupported type are skipped.
+func parseJWKS(data []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid key set: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var (
+			key any
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaPublicKey()
+		case "EC":
+			key, err = k.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k *jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA modulus or exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k *jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var (
+		curve     elliptic.Curve
+		ecdhCurve ecdh.Curve
+	)
+	switch k.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, errors.New("invalid EC coordinates length")
+	}
+
+	// ecdh rejects points that are not on the curve
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
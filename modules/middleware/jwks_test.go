@@ -0,0 +1,187 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type JWKSTestSuite struct {
+	suite.Suite
+	rsaKey *signingKey
+	ecKey  *signingKey
+
+	mu      sync.Mutex
+	keySet  []byte
+	status  int
+	fetches int
+	server  *httptest.Server
+}
+
+func (s *JWKSTestSuite) SetupSuite() {
+	s.rsaKey = newRSAKey(s.T(), "rsa-1")
+	s.ecKey = newECKey(s.T(), "ec-1")
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.fetches++
+		w.WriteHeader(s.status)
+		w.Write(s.keySet)
+	}))
+}
+
+func (s *JWKSTestSuite) TearDownSuite() {
+	s.server.Close()
+}
+
+func (s *JWKSTestSuite) SetupTest() {
+	s.serveKeySet(http.StatusOK, jwks(s.T(), s.rsaKey))
+	s.fetches = 0
+}
+
+func TestJWKS(t *testing.T) {
+	suite.Run(t, new(JWKSTestSuite))
+}
+
+func (s *JWKSTestSuite) serveKeySet(status int, keySet []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.keySet = keySet
+}
+
+// fetchCount returns number of key set fetches, reloads in background increment it concurrently
+func (s *JWKSTestSuite) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func (s *JWKSTestSuite) TestKeysCached() {
+	keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		key, err := keys.Key(context.Background(), "rsa-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &s.rsaKey.key.(*rsa.PrivateKey).PublicKey, key)
+	}
+	assert.Equal(s.T(), 1, s.fetches)
+}
+
+func (s *JWKSTestSuite) TestUnknownKey() {
+	s.Run("failed - unknown key ID does not reload recently loaded key set", func() {
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, time.Hour)
+		require.NoError(s.T(), keys.Refresh(context.Background()))
+
+		_, err := keys.Key(context.Background(), "ec-1")
+		assert.Error(s.T(), err)
+		assert.Equal(s.T(), 1, s.fetches)
+	})
+
+	s.Run("success - rotated key is loaded", func() {
+		s.fetches = 0
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, 10*time.Millisecond)
+		require.NoError(s.T(), keys.Refresh(context.Background()))
+
+		s.serveKeySet(http.StatusOK, jwks(s.T(), s.ecKey))
+		time.Sleep(20 * time.Millisecond)
+
+		key, err := keys.Key(context.Background(), "ec-1")
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), &s.ecKey.key.(*ecdsa.PrivateKey).PublicKey, key)
+		assert.Equal(s.T(), 2, s.fetches)
+	})
+}
+
+func (s *JWKSTestSuite) TestReloadFailed() {
+	s.Run("success - cached key is used while key set is unavailable", func() {
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, 10*time.Millisecond)
+		require.NoError(s.T(), keys.Refresh(context.Background()))
+
+		s.serveKeySet(http.StatusServiceUnavailable, nil)
+		time.Sleep(20 * time.Millisecond)
+
+		key, err := keys.Key(context.Background(), "rsa-1")
+		assert.NoError(s.T(), err)
+		assert.NotNil(s.T(), key)
+		assert.Eventually(s.T(), func() bool { return s.fetchCount() == 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	s.Run("success - stale key is returned while key set reloads in background", func() {
+		s.serveKeySet(http.StatusOK, jwks(s.T(), s.rsaKey))
+		s.fetches = 0
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, 10*time.Millisecond)
+		require.NoError(s.T(), keys.Refresh(context.Background()))
+		time.Sleep(20 * time.Millisecond)
+
+		key, err := keys.Key(context.Background(), "rsa-1")
+		assert.NoError(s.T(), err)
+		assert.NotNil(s.T(), key)
+		assert.Eventually(s.T(), func() bool { return s.fetchCount() == 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	s.Run("failed - key set never loaded", func() {
+		s.serveKeySet(http.StatusServiceUnavailable, nil)
+		s.fetches = 0
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, time.Hour)
+
+		_, err := keys.Key(context.Background(), "rsa-1")
+		assert.Error(s.T(), err)
+	})
+
+	s.Run("failed - failed load is not retried right away", func() {
+		s.serveKeySet(http.StatusServiceUnavailable, nil)
+		s.fetches = 0
+		keys := middleware.NewURLJWKS(s.server.URL, http.DefaultClient, time.Hour)
+		require.Error(s.T(), keys.Refresh(context.Background()))
+
+		for i := 0; i < 3; i++ {
+			_, err := keys.Key(context.Background(), "rsa-1")
+			assert.Error(s.T(), err)
+		}
+		assert.Equal(s.T(), 1, s.fetches)
+	})
+}
+
+func (s *JWKSTestSuite) TestFile() {
+	path := filepath.Join(s.T().TempDir(), "jwks.json")
+
+	s.Run("success", func() {
+		require.NoError(s.T(), os.WriteFile(path, jwks(s.T(), s.rsaKey, s.ecKey), 0o600))
+		keys := middleware.NewFileJWKS(path, time.Hour)
+
+		_, err := keys.Key(context.Background(), "rsa-1")
+		assert.NoError(s.T(), err)
+		_, err = keys.Key(context.Background(), "ec-1")
+		assert.NoError(s.T(), err)
+	})
+
+	s.Run("success - keys not used for signatures are skipped", func() {
+		require.NoError(s.T(), os.WriteFile(path, []byte(`{"keys":[{"kty":"RSA","kid":"enc-1","use":"enc","n":"","e":""},{"kty":"oct","kid":"hmac-1","k":"c2VjcmV0"}]}`), 0o600))
+		keys := middleware.NewFileJWKS(path, time.Hour)
+
+		assert.NoError(s.T(), keys.Refresh(context.Background()))
+		_, err := keys.Key(context.Background(), "hmac-1")
+		assert.Error(s.T(), err)
+	})
+
+	s.Run("failed - invalid key set", func() {
+		require.NoError(s.T(), os.WriteFile(path, []byte(`{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-256","x":"AQ","y":"AQ"}]}`), 0o600))
+		keys := middleware.NewFileJWKS(path, time.Hour)
+
+		assert.Error(s.T(), keys.Refresh(context.Background()))
+	})
+}
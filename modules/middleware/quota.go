@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/rs/zerolog/log"
+)
+
+// quotaWindow is the period requests per minute of a tenant are counted in
+const quotaWindow = time.Minute
+
+// TenantQuotaProvider provides quota of tenants
+type TenantQuotaProvider interface {
+	// TenantQuota returns quota of the tenant, 0 MaxRequestsPerMinute means unlimited
+	TenantQuota(ctx context.Context, tenantID string) (entity.TenantQuota, error)
+}
+
+// RequestQuota returns middleware that rejects requests of a tenant exceeding MaxRequestsPerMinute of its quota
+// counted by counter, with Retry-After header telling when the next window starts.
+// Requests without tenant are passed as is, so it must run after Bearer and APIKey middleware.
+func RequestQuota(counter *RequestCounter, writeError ErrorWriter) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := entity.TenantFromContext(r.Context())
+			if tenantID == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter, err := counter.Take(r.Context(), tenantID)
+			if err != nil {
+				log.Error().Err(err).Msg("[quota] error loading tenant quota")
+				writeError(w, r, entity.NewError(entity.ErrInternal, entity.ErrMsgInternal))
+				return
+			}
+
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				writeError(w, r, entity.NewError(entity.ErrRequestQuotaExceeded, entity.ErrMsgRequestQuotaExceeded))
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestWindow counts requests of a tenant in the window starting at start
+type requestWindow struct {
+	start time.Time
+	limit int
+	count int
+}
+
+// RequestCounter counts requests of tenants in their current window.
+// Each tenant has a window of a minute starting at its first request, quota of the tenant is loaded once per window.
+// Requests are counted by every instance of the server separately,
+// the REST and gRPC API of an instance share the counter so they share the quota too.
+type RequestCounter struct {
+	provider  TenantQuotaProvider
+	mu        sync.Mutex
+	windows   map[string]*requestWindow
+	lastSweep time.Time
+}
+
+// NewRequestCounter creates counter of requests limited by quota of tenants loaded from provider
+func NewRequestCounter(provider TenantQuotaProvider) *RequestCounter {
+	return &RequestCounter{
+		provider: provider,
+		windows:  make(map[string]*requestWindow),
+	}
+}
+
+// Take counts request of the tenant,
+// returns how long until the tenant can make requests again when the request exceeds its quota.
+func (c *RequestCounter) Take(ctx context.Context, tenantID string) (time.Duration, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.sweep(now)
+	window, ok := c.windows[tenantID]
+	c.mu.Unlock()
+
+	if !ok || now.Sub(window.start) >= quotaWindow {
+		// quota is loaded without holding the lock, so a slow provider does not block other tenants
+		quota, err := c.provider.TenantQuota(ctx, tenantID)
+		if err != nil {
+			return 0, err
+		}
+
+		c.mu.Lock()
+		// concurrent request of the tenant might have started the window already
+		if current, ok := c.windows[tenantID]; ok && now.Sub(current.start) < quotaWindow {
+			window = current
+		} else {
+			window = &requestWindow{start: now, limit: quota.MaxRequestsPerMinute}
+			c.windows[tenantID] = window
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if window.limit > 0 && window.count >= window.limit {
+		return window.start.Add(quotaWindow).Sub(now), nil
+	}
+	window.count++
+	return 0, nil
+}
+
+// sweep removes expired windows of tenants that stopped making requests, must be called holding the lock
+func (c *RequestCounter) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < quotaWindow {
+		return
+	}
+
+	for tenantID, window := range c.windows {
+		if now.Sub(window.start) >= quotaWindow {
+			delete(c.windows, tenantID)
+		}
+	}
+	c.lastSweep = now
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	mock_middleware "github.com/raymondwongso/carddeck/test/mock/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RequestQuotaTestSuite struct {
+	suite.Suite
+	provider *mock_middleware.MockTenantQuotaProvider
+	calls    int
+	handler  http.Handler
+}
+
+func (s *RequestQuotaTestSuite) SetupTest() {
+	ctrl := gomock.NewController(s.T())
+	s.provider = mock_middleware.NewMockTenantQuotaProvider(ctrl)
+	s.calls = 0
+	s.handler = middleware.RequestQuota(middleware.NewRequestCounter(s.provider), carddeck.WriteError)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestRequestQuota(t *testing.T) {
+	suite.Run(t, new(RequestQuotaTestSuite))
+}
+
+func (s *RequestQuotaTestSuite) serve(tenantID string) *http.Response {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil)
+	if tenantID != "" {
+		r = r.WithContext(entity.WithTenant(r.Context(), tenantID))
+	}
+	w := httptest.NewRecorder()
+
+	s.handler.ServeHTTP(w, r)
+	return w.Result()
+}
+
+func (s *RequestQuotaTestSuite) TestQuotaExceeded() {
+	// quota is loaded once per window
+	s.provider.EXPECT().TenantQuota(gomock.Any(), "tenant-1").Return(entity.TenantQuota{MaxRequestsPerMinute: 2}, nil).Times(1)
+	s.provider.EXPECT().TenantQuota(gomock.Any(), "tenant-2").Return(entity.TenantQuota{MaxRequestsPerMinute: 2}, nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(s.T(), http.StatusOK, s.serve("tenant-1").StatusCode)
+	}
+
+	response := s.serve("tenant-1")
+	assert.Equal(s.T(), http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(s.T(), 2, s.calls)
+
+	retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), retryAfter > 0 && retryAfter <= 60)
+
+	var resp entity.Error
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+	assert.Equal(s.T(), entity.ErrRequestQuotaExceeded, resp.Code)
+
+	// other tenants are counted separately
+	assert.Equal(s.T(), http.StatusOK, s.serve("tenant-2").StatusCode)
+}
+
+func (s *RequestQuotaTestSuite) TestUnlimited() {
+	s.provider.EXPECT().TenantQuota(gomock.Any(), "tenant-1").Return(entity.TenantQuota{}, nil).Times(1)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(s.T(), http.StatusOK, s.serve("tenant-1").StatusCode)
+	}
+	assert.Equal(s.T(), 5, s.calls)
+}
+
+func (s *RequestQuotaTestSuite) TestWithoutTenant() {
+	response := s.serve("")
+
+	assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	assert.Equal(s.T(), 1, s.calls)
+}
+
+func (s *RequestQuotaTestSuite) TestProviderError() {
+	s.provider.EXPECT().TenantQuota(gomock.Any(), "tenant-1").Return(entity.TenantQuota{}, errors.New("some error"))
+
+	response := s.serve("tenant-1")
+
+	assert.Equal(s.T(), http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(s.T(), 0, s.calls)
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitPolicy is token bucket of each client, refilled by Rate tokens per second up to Burst tokens.
+// Every request takes a token.
+type RateLimitPolicy struct {
+	Rate  float64
+	Burst int
+	// ClientIPHeader is header set by the reverse proxy holding IP of clients, e.g. X-Forwarded-For.
+	// Remote address of the connection is used when empty.
+	ClientIPHeader string
+	// TrustedProxies is number of reverse proxies appending to ClientIPHeader, 1 when zero.
+	// The address appended by the outermost proxy is used, addresses before it are sent by the client and can be forged.
+	TrustedProxies int
+	// ByClientIP limits every request by client IP, even authenticated ones,
+	// used in front of authentication so requests with invalid credentials are limited too.
+	ByClientIP bool
+}
+
+// window returns how long an empty bucket takes to refill
+func (p RateLimitPolicy) window() time.Duration {
+	return time.Duration(float64(p.Burst) / p.Rate * float64(time.Second))
+}
+
+// RateLimit returns middleware that limits rate of requests of each client by token bucket of the policy.
+// Clients are tenants of authenticated requests (see entity.WithTenant), and client IP of the rest,
+// so it must run after Bearer and APIKey middleware unless the policy limits ByClientIP.
+// Every response has RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// and requests without token left are rejected with 429 and Retry-After header.
+// Zero rate disables limiting.
+func RateLimit(policy RateLimitPolicy, writeError ErrorWriter) func(http.Handler) http.Handler {
+	if policy.Rate <= 0 {
+		return func(h http.Handler) http.Handler {
+			return h
+		}
+	}
+
+	buckets := &rateBuckets{policy: policy, clients: make(map[string]*rateBucket)}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			limiter := buckets.get(rateLimitClient(r, policy), now)
+			allowed := limiter.AllowN(now, 1)
+			tokens := limiter.TokensAt(now)
+
+			// reset is when the bucket is full again
+			reset := math.Ceil((float64(policy.Burst) - tokens) / policy.Rate)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(reset)))
+			w.Header().Set("RateLimit-Policy", strconv.Itoa(policy.Burst)+";w="+strconv.Itoa(int(math.Ceil(policy.window().Seconds()))))
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil((1-tokens)/policy.Rate))))
+				writeError(w, r, entity.NewError(entity.ErrRateLimited, entity.ErrMsgRateLimited))
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitClient returns key of the client making the request
+func rateLimitClient(r *http.Request, policy RateLimitPolicy) string {
+	if tenantID := entity.TenantFromContext(r.Context()); tenantID != "" && !policy.ByClientIP {
+		return "tenant:" + tenantID
+	}
+
+	return "ip:" + clientIP(r, policy.ClientIPHeader, policy.TrustedProxies)
+}
+
+// clientIP returns IP of the client, the address appended to header by the outermost of trustedProxies reverse proxies
+func clientIP(r *http.Request, header string, trustedProxies int) string {
+	if header != "" {
+		var addresses []string
+		for _, value := range r.Header.Values(header) {
+			for _, address := range strings.Split(value, ",") {
+				if address = strings.TrimSpace(address); address != "" {
+					addresses = append(addresses, address)
+				}
+			}
+		}
+
+		if len(addresses) > 0 {
+			return addresses[max(len(addresses)-max(trustedProxies, 1), 0)]
+		}
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return ip
+}
+
+// rateBucket is token bucket of a client
+type rateBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateBuckets holds token buckets of clients
+type rateBuckets struct {
+	policy    RateLimitPolicy
+	mu        sync.Mutex
+	clients   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+// get returns token bucket of the client, creating a full one for new client
+func (b *rateBuckets) get(client string, now time.Time) *rate.Limiter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sweep(now)
+	bucket, ok := b.clients[client]
+	if !ok {
+		bucket = &rateBucket{limiter: rate.NewLimiter(rate.Limit(b.policy.Rate), b.policy.Burst)}
+		b.clients[client] = bucket
+	}
+	bucket.lastSeen = now
+
+	return bucket.limiter
+}
+
+// sweep removes buckets of clients idle long enough for their bucket to be full again,
+// they are the same as new buckets. Must be called holding the lock.
+func (b *rateBuckets) sweep(now time.Time) {
+	window := b.policy.window()
+	if now.Sub(b.lastSweep) < window {
+		return
+	}
+
+	for client, bucket := range b.clients {
+		if now.Sub(bucket.lastSeen) >= window {
+			delete(b.clients, client)
+		}
+	}
+	b.lastSweep = now
+}
@@ -0,0 +1,133 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	calls int
+	next  http.Handler
+}
+
+func (s *RateLimitTestSuite) SetupTest() {
+	s.calls = 0
+	s.next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
+
+// request returns request of the tenant from the remote address, tenant is not set when empty
+func (s *RateLimitTestSuite) request(tenantID, remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "http://localhost/decks/some-uuid/cards", nil)
+	r.RemoteAddr = remoteAddr
+	if tenantID != "" {
+		r = r.WithContext(entity.WithTenant(r.Context(), tenantID))
+	}
+	return r
+}
+
+func (s *RateLimitTestSuite) serve(h http.Handler, r *http.Request) *http.Response {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Result()
+}
+
+func (s *RateLimitTestSuite) TestLimited() {
+	h := middleware.RateLimit(middleware.RateLimitPolicy{Rate: 0.5, Burst: 2}, carddeck.WriteError)(s.next)
+
+	response := s.serve(h, s.request("tenant-1", "192.0.2.1:1234"))
+	assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	assert.Equal(s.T(), "2", response.Header.Get("RateLimit-Limit"))
+	assert.Equal(s.T(), "1", response.Header.Get("RateLimit-Remaining"))
+	assert.Equal(s.T(), "2", response.Header.Get("RateLimit-Reset"))
+	assert.Equal(s.T(), "2;w=4", response.Header.Get("RateLimit-Policy"))
+
+	response = s.serve(h, s.request("tenant-1", "192.0.2.2:1234"))
+	assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+	assert.Equal(s.T(), "0", response.Header.Get("RateLimit-Remaining"))
+
+	response = s.serve(h, s.request("tenant-1", "192.0.2.3:1234"))
+	assert.Equal(s.T(), http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(s.T(), "2", response.Header.Get("Retry-After"))
+	assert.Equal(s.T(), "0", response.Header.Get("RateLimit-Remaining"))
+	assert.Equal(s.T(), 2, s.calls)
+
+	var resp entity.Error
+	assert.NoError(s.T(), json.NewDecoder(response.Body).Decode(&resp))
+	assert.Equal(s.T(), entity.ErrRateLimited, resp.Code)
+
+	// other tenants have their own bucket
+	assert.Equal(s.T(), http.StatusOK, s.serve(h, s.request("tenant-2", "192.0.2.1:1234")).StatusCode)
+}
+
+func (s *RateLimitTestSuite) TestClientIP() {
+	s.Run("success - remote address of anonymous requests", func() {
+		h := middleware.RateLimit(middleware.RateLimitPolicy{Rate: 1, Burst: 1}, carddeck.WriteError)(s.next)
+
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, s.request("", "192.0.2.1:1234")).StatusCode)
+		assert.Equal(s.T(), http.StatusTooManyRequests, s.serve(h, s.request("", "192.0.2.1:5678")).StatusCode)
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, s.request("", "192.0.2.2:1234")).StatusCode)
+	})
+
+	s.Run("success - address appended by the proxy to client IP header", func() {
+		h := middleware.RateLimit(middleware.RateLimitPolicy{Rate: 1, Burst: 1, ClientIPHeader: "X-Forwarded-For"}, carddeck.WriteError)(s.next)
+		forwarded := func(header string) *http.Request {
+			r := s.request("", "10.0.0.1:1234")
+			r.Header.Set("X-Forwarded-For", header)
+			return r
+		}
+
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, forwarded("203.0.113.1, 198.51.100.1")).StatusCode)
+		// addresses sent by the client do not make a new client
+		assert.Equal(s.T(), http.StatusTooManyRequests, s.serve(h, forwarded("203.0.113.2, 198.51.100.1")).StatusCode)
+		assert.Equal(s.T(), http.StatusTooManyRequests, s.serve(h, forwarded("198.51.100.1")).StatusCode)
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, forwarded("198.51.100.1, 198.51.100.2")).StatusCode)
+	})
+
+	s.Run("success - address appended by the outermost of trusted proxies", func() {
+		h := middleware.RateLimit(middleware.RateLimitPolicy{Rate: 1, Burst: 1, ClientIPHeader: "X-Forwarded-For", TrustedProxies: 2}, carddeck.WriteError)(s.next)
+		forwarded := func(header string) *http.Request {
+			r := s.request("", "10.0.0.1:1234")
+			r.Header.Set("X-Forwarded-For", header)
+			return r
+		}
+
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, forwarded("203.0.113.1, 198.51.100.1, 10.0.0.2")).StatusCode)
+		assert.Equal(s.T(), http.StatusTooManyRequests, s.serve(h, forwarded("203.0.113.2, 198.51.100.1, 10.0.0.3")).StatusCode)
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, forwarded("198.51.100.2, 10.0.0.2")).StatusCode)
+	})
+
+	s.Run("success - authenticated requests by client IP", func() {
+		h := middleware.RateLimit(middleware.RateLimitPolicy{Rate: 1, Burst: 1, ByClientIP: true}, carddeck.WriteError)(s.next)
+
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, s.request("tenant-1", "192.0.2.1:1234")).StatusCode)
+		assert.Equal(s.T(), http.StatusTooManyRequests, s.serve(h, s.request("tenant-2", "192.0.2.1:5678")).StatusCode)
+		assert.Equal(s.T(), http.StatusOK, s.serve(h, s.request("tenant-1", "192.0.2.2:1234")).StatusCode)
+	})
+}
+
+func (s *RateLimitTestSuite) TestDisabled() {
+	h := middleware.RateLimit(middleware.RateLimitPolicy{}, carddeck.WriteError)(s.next)
+
+	for i := 0; i < 5; i++ {
+		response := s.serve(h, s.request("tenant-1", "192.0.2.1:1234"))
+		assert.Equal(s.T(), http.StatusOK, response.StatusCode)
+		assert.Empty(s.T(), response.Header.Get("RateLimit-Limit"))
+	}
+	assert.Equal(s.T(), 5, s.calls)
+}
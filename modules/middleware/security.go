@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// SecurityHeadersPolicy configures security headers of responses, empty values are not sent
+type SecurityHeadersPolicy struct {
+	ContentSecurityPolicy string
+	// FrameOptions is value of X-Frame-Options header, e.g. DENY
+	FrameOptions string
+	// ReferrerPolicy is value of Referrer-Policy header, e.g. no-referrer
+	ReferrerPolicy string
+	// HSTSMaxAge is max-age (in seconds) of Strict-Transport-Security header, 0 does not send the header.
+	// Only enable it when the server is reached through HTTPS.
+	HSTSMaxAge int
+}
+
+// SecurityHeaders returns middleware that sets security headers of the policy and X-Content-Type-Options: nosniff
+// on every response. Handlers may override them, e.g. pages needing scripts wrapped by SecurityHeaders with other policy.
+func SecurityHeaders(policy SecurityHeadersPolicy) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			if policy.ContentSecurityPolicy != "" {
+				header.Set("Content-Security-Policy", policy.ContentSecurityPolicy)
+			}
+			if policy.FrameOptions != "" {
+				header.Set("X-Frame-Options", policy.FrameOptions)
+			}
+			if policy.ReferrerPolicy != "" {
+				header.Set("Referrer-Policy", policy.ReferrerPolicy)
+			}
+			if policy.HSTSMaxAge > 0 {
+				header.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(policy.HSTSMaxAge)+"; includeSubDomains")
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
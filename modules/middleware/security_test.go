@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raymondwongso/carddeck/modules/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(h http.Handler) http.Header {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost/decks/some-uuid", nil))
+		return w.Result().Header
+	}
+
+	t.Run("success - every header", func(t *testing.T) {
+		header := serve(middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{
+			ContentSecurityPolicy: "default-src 'none'",
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			HSTSMaxAge:            31536000,
+		})(ok))
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "default-src 'none'", header.Get("Content-Security-Policy"))
+		assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+		assert.Equal(t, "no-referrer", header.Get("Referrer-Policy"))
+		assert.Equal(t, "max-age=31536000; includeSubDomains", header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("success - empty values are not sent", func(t *testing.T) {
+		header := serve(middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{})(ok))
+
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Empty(t, header.Get("Content-Security-Policy"))
+		assert.Empty(t, header.Get("X-Frame-Options"))
+		assert.Empty(t, header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("success - inner policy overrides outer policy", func(t *testing.T) {
+		outer := middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{ContentSecurityPolicy: "default-src 'none'", FrameOptions: "DENY"})
+		inner := middleware.SecurityHeaders(middleware.SecurityHeadersPolicy{ContentSecurityPolicy: "default-src 'self'"})
+		header := serve(outer(inner(ok)))
+
+		assert.Equal(t, "default-src 'self'", header.Get("Content-Security-Policy"))
+		assert.Equal(t, "DENY", header.Get("X-Frame-Options"))
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/raymondwongso/carddeck/modules/carddeck/client"
+	"github.com/raymondwongso/carddeck/modules/carddeck/tui"
+	"github.com/spf13/cobra"
+)
+
+// playCommand returns play command opening interactive table of a deck of a running server
+func playCommand() *cobra.Command {
+	var baseURL, apiKey string
+
+	playCmd := &cobra.Command{
+		Use:   "play [deck id]",
+		Short: "Play a deck of a running server in the terminal, creating shuffled deck unless deck id is specified",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			c := client.New(baseURL, client.WithAPIKey(apiKey))
+
+			var deckID string
+			if len(args) > 0 {
+				deckID = args[0]
+			} else {
+				deck, err := c.CreateDeck(ctx, true, nil)
+				if err != nil {
+					return err
+				}
+				deckID = deck.ID
+			}
+
+			if _, err := tea.NewProgram(tui.NewTable(ctx, c, deckID), tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+				return fmt.Errorf("running table: %w", err)
+			}
+			return nil
+		},
+	}
+	playCmd.Flags().StringVar(&baseURL, "url", defaultBaseURL(), fmt.Sprintf("base URL of the server, defaults to %s env", urlEnv))
+	playCmd.Flags().StringVar(&apiKey, "api-key", os.Getenv(apiKeyEnv), fmt.Sprintf("API key of the server, defaults to %s env", apiKeyEnv))
+
+	return playCmd
+}
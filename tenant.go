@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/raymondwongso/carddeck/config"
+	"github.com/raymondwongso/carddeck/modules/carddeck"
+	"github.com/raymondwongso/carddeck/modules/carddeck/entity"
+	"github.com/spf13/cobra"
+)
+
+// tenantCommand returns tenant command managing tenants directly in the database
+func tenantCommand() *cobra.Command {
+	tenantCmd := &cobra.Command{
+		Use:   "tenant",
+		Short: "Create tenants and set their quota",
+	}
+
+	tenantCmd.AddCommand(func() *cobra.Command {
+		var name string
+		var maxActiveDecks, maxRequestsPerMinute int
+
+		createCmd := &cobra.Command{
+			Use:   "create",
+			Short: "Create new tenant, quota not given uses default quota of the server",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cmd.SilenceUsage = true
+				tenant := &entity.Tenant{Name: name}
+				quotaFlags(cmd, tenant, maxActiveDecks, maxRequestsPerMinute)
+				return createTenant(cmd.Context(), cmd.OutOrStdout(), tenant)
+			},
+		}
+		createCmd.Flags().StringVar(&name, "name", "", "name of the tenant, e.g. studio name")
+		createCmd.Flags().IntVar(&maxActiveDecks, "max-active-decks", 0, "maximum number of decks the tenant has not deleted, 0 means unlimited")
+		createCmd.Flags().IntVar(&maxRequestsPerMinute, "max-requests-per-minute", 0, "maximum number of REST requests of the tenant per minute, 0 means unlimited")
+
+		return createCmd
+	}())
+
+	tenantCmd.AddCommand(func() *cobra.Command {
+		var maxActiveDecks, maxRequestsPerMinute int
+
+		setQuotaCmd := &cobra.Command{
+			Use:   "set-quota [tenant id]",
+			Short: "Set quota of the tenant, quota not given is restored to default quota of the server",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cmd.SilenceUsage = true
+				tenant := &entity.Tenant{ID: args[0]}
+				quotaFlags(cmd, tenant, maxActiveDecks, maxRequestsPerMinute)
+				return setTenantQuota(cmd.Context(), tenant)
+			},
+		}
+		setQuotaCmd.Flags().IntVar(&maxActiveDecks, "max-active-decks", 0, "maximum number of decks the tenant has not deleted, 0 means unlimited")
+		setQuotaCmd.Flags().IntVar(&maxRequestsPerMinute, "max-requests-per-minute", 0, "maximum number of REST requests of the tenant per minute, 0 means unlimited")
+
+		return setQuotaCmd
+	}())
+
+	return tenantCmd
+}
+
+// quotaFlags overrides quota of the tenant by quota flags given to cmd
+func quotaFlags(cmd *cobra.Command, tenant *entity.Tenant, maxActiveDecks, maxRequestsPerMinute int) {
+	if cmd.Flags().Changed("max-active-decks") {
+		tenant.MaxActiveDecks = &maxActiveDecks
+	}
+	if cmd.Flags().Changed("max-requests-per-minute") {
+		tenant.MaxRequestsPerMinute = &maxRequestsPerMinute
+	}
+}
+
+func createTenant(ctx context.Context, w io.Writer, tenant *entity.Tenant) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tenant, err = carddeck.BuildService(config, db).CreateTenant(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME")
+	fmt.Fprintf(tw, "%s\t%s\n", tenant.ID, tenant.Name)
+	return tw.Flush()
+}
+
+func setTenantQuota(ctx context.Context, tenant *entity.Tenant) error {
+	config, err := config.Load(".env")
+	if err != nil {
+		return err
+	}
+
+	db, err := carddeck.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return carddeck.BuildService(config, db).SetTenantQuota(ctx, tenant)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./modules/carddeck/internal/grpc/quota.go
+
+// Package mock_grpc is a generated GoMock package.
+package mock_grpc
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockQuotaCounter is a mock of QuotaCounter interface.
+type MockQuotaCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaCounterMockRecorder
+}
+
+// MockQuotaCounterMockRecorder is the mock recorder for MockQuotaCounter.
+type MockQuotaCounterMockRecorder struct {
+	mock *MockQuotaCounter
+}
+
+// NewMockQuotaCounter creates a new mock instance.
+func NewMockQuotaCounter(ctrl *gomock.Controller) *MockQuotaCounter {
+	mock := &MockQuotaCounter{ctrl: ctrl}
+	mock.recorder = &MockQuotaCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaCounter) EXPECT() *MockQuotaCounterMockRecorder {
+	return m.recorder
+}
+
+// Take mocks base method.
+func (m *MockQuotaCounter) Take(ctx context.Context, tenantID string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, tenantID)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockQuotaCounterMockRecorder) Take(ctx, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockQuotaCounter)(nil).Take), ctx, tenantID)
+}
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./modules/carddeck/internal/repository/postgres/listener.go
+
+// Package mock_postgres is a generated GoMock package.
+package mock_postgres
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	pgconn "github.com/jackc/pgx/v5/pgconn"
+)
+
+// MockNotificationConn is a mock of NotificationConn interface.
+type MockNotificationConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationConnMockRecorder
+}
+
+// MockNotificationConnMockRecorder is the mock recorder for MockNotificationConn.
+type MockNotificationConnMockRecorder struct {
+	mock *MockNotificationConn
+}
+
+// NewMockNotificationConn creates a new mock instance.
+func NewMockNotificationConn(ctrl *gomock.Controller) *MockNotificationConn {
+	mock := &MockNotificationConn{ctrl: ctrl}
+	mock.recorder = &MockNotificationConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationConn) EXPECT() *MockNotificationConnMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockNotificationConn) Close(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockNotificationConnMockRecorder) Close(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockNotificationConn)(nil).Close), ctx)
+}
+
+// Exec mocks base method.
+func (m *MockNotificationConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, sql}
+	for _, a := range arguments {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(pgconn.CommandTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockNotificationConnMockRecorder) Exec(ctx, sql interface{}, arguments ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, sql}, arguments...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockNotificationConn)(nil).Exec), varargs...)
+}
+
+// WaitForNotification mocks base method.
+func (m *MockNotificationConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForNotification", ctx)
+	ret0, _ := ret[0].(*pgconn.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitForNotification indicates an expected call of WaitForNotification.
+func (mr *MockNotificationConnMockRecorder) WaitForNotification(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForNotification", reflect.TypeOf((*MockNotificationConn)(nil).WaitForNotification), ctx)
+}
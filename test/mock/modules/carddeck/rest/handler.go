@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: ./modules/carddeck/internal/rest/handler.go
+// Source: modules/carddeck/internal/rest/handler.go
 
 // Package mock_rest is a generated GoMock package.
 package mock_rest
@@ -35,6 +35,21 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// Batch mocks base method.
+func (m *MockService) Batch(ctx context.Context, operations []*entity.BatchOperation) ([]*entity.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", ctx, operations)
+	ret0, _ := ret[0].([]*entity.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockServiceMockRecorder) Batch(ctx, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockService)(nil).Batch), ctx, operations)
+}
+
 // CreateDeck mocks base method.
 func (m *MockService) CreateDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error) {
 	m.ctrl.T.Helper()
@@ -50,19 +65,78 @@ func (mr *MockServiceMockRecorder) CreateDeck(ctx, shuffled, cardCodes interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDeck", reflect.TypeOf((*MockService)(nil).CreateDeck), ctx, shuffled, cardCodes)
 }
 
+// CreateWebhook mocks base method.
+func (m *MockService) CreateWebhook(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(*entity.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockServiceMockRecorder) CreateWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockService)(nil).CreateWebhook), ctx, webhook)
+}
+
+// DeleteDeck mocks base method.
+func (m *MockService) DeleteDeck(ctx context.Context, id string, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeck", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDeck indicates an expected call of DeleteDeck.
+func (mr *MockServiceMockRecorder) DeleteDeck(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeck", reflect.TypeOf((*MockService)(nil).DeleteDeck), ctx, id, version)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockService) DeleteWebhook(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockServiceMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockService)(nil).DeleteWebhook), ctx, id)
+}
+
 // DrawCards mocks base method.
-func (m *MockService) DrawCards(ctx context.Context, id string, n int64) (*entity.Cards, error) {
+func (m *MockService) DrawCards(ctx context.Context, id string, n, version int64) (*entity.Cards, *entity.Deck, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DrawCards", ctx, id, n)
+	ret := m.ctrl.Call(m, "DrawCards", ctx, id, n, version)
 	ret0, _ := ret[0].(*entity.Cards)
+	ret1, _ := ret[1].(*entity.Deck)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DrawCards indicates an expected call of DrawCards.
+func (mr *MockServiceMockRecorder) DrawCards(ctx, id, n, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrawCards", reflect.TypeOf((*MockService)(nil).DrawCards), ctx, id, n, version)
+}
+
+// ExportDeck mocks base method.
+func (m *MockService) ExportDeck(ctx context.Context, id string) (*entity.DeckExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDeck", ctx, id)
+	ret0, _ := ret[0].(*entity.DeckExport)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DrawCards indicates an expected call of DrawCards.
-func (mr *MockServiceMockRecorder) DrawCards(ctx, id, n interface{}) *gomock.Call {
+// ExportDeck indicates an expected call of ExportDeck.
+func (mr *MockServiceMockRecorder) ExportDeck(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrawCards", reflect.TypeOf((*MockService)(nil).DrawCards), ctx, id, n)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDeck", reflect.TypeOf((*MockService)(nil).ExportDeck), ctx, id)
 }
 
 // GetDeck mocks base method.
@@ -79,3 +153,109 @@ func (mr *MockServiceMockRecorder) GetDeck(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeck", reflect.TypeOf((*MockService)(nil).GetDeck), ctx, id)
 }
+
+// ImportDeck mocks base method.
+func (m *MockService) ImportDeck(ctx context.Context, doc *entity.DeckExport) (*entity.Deck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportDeck", ctx, doc)
+	ret0, _ := ret[0].(*entity.Deck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportDeck indicates an expected call of ImportDeck.
+func (mr *MockServiceMockRecorder) ImportDeck(ctx, doc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportDeck", reflect.TypeOf((*MockService)(nil).ImportDeck), ctx, doc)
+}
+
+// ListDeckEvents mocks base method.
+func (m *MockService) ListDeckEvents(ctx context.Context, id string, afterID int64) ([]*entity.DeckEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeckEvents", ctx, id, afterID)
+	ret0, _ := ret[0].([]*entity.DeckEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeckEvents indicates an expected call of ListDeckEvents.
+func (mr *MockServiceMockRecorder) ListDeckEvents(ctx, id, afterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeckEvents", reflect.TypeOf((*MockService)(nil).ListDeckEvents), ctx, id, afterID)
+}
+
+// ListWebhookDeadLetters mocks base method.
+func (m *MockService) ListWebhookDeadLetters(ctx context.Context, id string) ([]*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeadLetters", ctx, id)
+	ret0, _ := ret[0].([]*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeadLetters indicates an expected call of ListWebhookDeadLetters.
+func (mr *MockServiceMockRecorder) ListWebhookDeadLetters(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeadLetters", reflect.TypeOf((*MockService)(nil).ListWebhookDeadLetters), ctx, id)
+}
+
+// RestoreDeck mocks base method.
+func (m *MockService) RestoreDeck(ctx context.Context, shuffled bool, cardCodes []string) (*entity.Deck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreDeck", ctx, shuffled, cardCodes)
+	ret0, _ := ret[0].(*entity.Deck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreDeck indicates an expected call of RestoreDeck.
+func (mr *MockServiceMockRecorder) RestoreDeck(ctx, shuffled, cardCodes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreDeck", reflect.TypeOf((*MockService)(nil).RestoreDeck), ctx, shuffled, cardCodes)
+}
+
+// ReturnCards mocks base method.
+func (m *MockService) ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*entity.Deck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnCards", ctx, id, cardCodes, version)
+	ret0, _ := ret[0].(*entity.Deck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReturnCards indicates an expected call of ReturnCards.
+func (mr *MockServiceMockRecorder) ReturnCards(ctx, id, cardCodes, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnCards", reflect.TypeOf((*MockService)(nil).ReturnCards), ctx, id, cardCodes, version)
+}
+
+// ShuffleDeck mocks base method.
+func (m *MockService) ShuffleDeck(ctx context.Context, id string, version int64) (*entity.Deck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShuffleDeck", ctx, id, version)
+	ret0, _ := ret[0].(*entity.Deck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShuffleDeck indicates an expected call of ShuffleDeck.
+func (mr *MockServiceMockRecorder) ShuffleDeck(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShuffleDeck", reflect.TypeOf((*MockService)(nil).ShuffleDeck), ctx, id, version)
+}
+
+// SubscribeDeck mocks base method.
+func (m *MockService) SubscribeDeck(ctx context.Context, id string) (<-chan *entity.DeckEvent, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeDeck", ctx, id)
+	ret0, _ := ret[0].(<-chan *entity.DeckEvent)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SubscribeDeck indicates an expected call of SubscribeDeck.
+func (mr *MockServiceMockRecorder) SubscribeDeck(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeDeck", reflect.TypeOf((*MockService)(nil).SubscribeDeck), ctx, id)
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: modules/carddeck/tui/table.go
+
+// Package mock_tui is a generated GoMock package.
+package mock_tui
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	client "github.com/raymondwongso/carddeck/modules/carddeck/client"
+	entity "github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// DrawCards mocks base method.
+func (m *MockClient) DrawCards(ctx context.Context, id string, count, version int64) (*client.DrawnCards, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DrawCards", ctx, id, count, version)
+	ret0, _ := ret[0].(*client.DrawnCards)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DrawCards indicates an expected call of DrawCards.
+func (mr *MockClientMockRecorder) DrawCards(ctx, id, count, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrawCards", reflect.TypeOf((*MockClient)(nil).DrawCards), ctx, id, count, version)
+}
+
+// GetDeck mocks base method.
+func (m *MockClient) GetDeck(ctx context.Context, id string) (*entity.Deck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeck", ctx, id)
+	ret0, _ := ret[0].(*entity.Deck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeck indicates an expected call of GetDeck.
+func (mr *MockClientMockRecorder) GetDeck(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeck", reflect.TypeOf((*MockClient)(nil).GetDeck), ctx, id)
+}
+
+// ReturnCards mocks base method.
+func (m *MockClient) ReturnCards(ctx context.Context, id string, cardCodes []string, version int64) (*client.DeckSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnCards", ctx, id, cardCodes, version)
+	ret0, _ := ret[0].(*client.DeckSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReturnCards indicates an expected call of ReturnCards.
+func (mr *MockClientMockRecorder) ReturnCards(ctx, id, cardCodes, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnCards", reflect.TypeOf((*MockClient)(nil).ReturnCards), ctx, id, cardCodes, version)
+}
+
+// ShuffleDeck mocks base method.
+func (m *MockClient) ShuffleDeck(ctx context.Context, id string, version int64) (*client.DeckSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShuffleDeck", ctx, id, version)
+	ret0, _ := ret[0].(*client.DeckSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShuffleDeck indicates an expected call of ShuffleDeck.
+func (mr *MockClientMockRecorder) ShuffleDeck(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShuffleDeck", reflect.TypeOf((*MockClient)(nil).ShuffleDeck), ctx, id, version)
+}
+
+// WatchDeck mocks base method.
+func (m *MockClient) WatchDeck(ctx context.Context, id string, handle func(*entity.DeckEvent)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchDeck", ctx, id, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchDeck indicates an expected call of WatchDeck.
+func (mr *MockClientMockRecorder) WatchDeck(ctx, id, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeck", reflect.TypeOf((*MockClient)(nil).WatchDeck), ctx, id, handle)
+}
@@ -0,0 +1,90 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./modules/middleware/compress.go
+
+// Package mock_middleware is a generated GoMock package.
+package mock_middleware
+
+import (
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockencoder is a mock of encoder interface.
+type Mockencoder struct {
+	ctrl     *gomock.Controller
+	recorder *MockencoderMockRecorder
+}
+
+// MockencoderMockRecorder is the mock recorder for Mockencoder.
+type MockencoderMockRecorder struct {
+	mock *Mockencoder
+}
+
+// NewMockencoder creates a new mock instance.
+func NewMockencoder(ctrl *gomock.Controller) *Mockencoder {
+	mock := &Mockencoder{ctrl: ctrl}
+	mock.recorder = &MockencoderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockencoder) EXPECT() *MockencoderMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *Mockencoder) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockencoderMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*Mockencoder)(nil).Close))
+}
+
+// Flush mocks base method.
+func (m *Mockencoder) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockencoderMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*Mockencoder)(nil).Flush))
+}
+
+// Reset mocks base method.
+func (m *Mockencoder) Reset(w io.Writer) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Reset", w)
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockencoderMockRecorder) Reset(w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*Mockencoder)(nil).Reset), w)
+}
+
+// Write mocks base method.
+func (m *Mockencoder) Write(p []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", p)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockencoderMockRecorder) Write(p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*Mockencoder)(nil).Write), p)
+}
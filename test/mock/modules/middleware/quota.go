@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./modules/middleware/quota.go
+
+// Package mock_middleware is a generated GoMock package.
+package mock_middleware
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	entity "github.com/raymondwongso/carddeck/modules/carddeck/entity"
+)
+
+// MockTenantQuotaProvider is a mock of TenantQuotaProvider interface.
+type MockTenantQuotaProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantQuotaProviderMockRecorder
+}
+
+// MockTenantQuotaProviderMockRecorder is the mock recorder for MockTenantQuotaProvider.
+type MockTenantQuotaProviderMockRecorder struct {
+	mock *MockTenantQuotaProvider
+}
+
+// NewMockTenantQuotaProvider creates a new mock instance.
+func NewMockTenantQuotaProvider(ctrl *gomock.Controller) *MockTenantQuotaProvider {
+	mock := &MockTenantQuotaProvider{ctrl: ctrl}
+	mock.recorder = &MockTenantQuotaProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTenantQuotaProvider) EXPECT() *MockTenantQuotaProviderMockRecorder {
+	return m.recorder
+}
+
+// TenantQuota mocks base method.
+func (m *MockTenantQuotaProvider) TenantQuota(ctx context.Context, tenantID string) (entity.TenantQuota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantQuota", ctx, tenantID)
+	ret0, _ := ret[0].(entity.TenantQuota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantQuota indicates an expected call of TenantQuota.
+func (mr *MockTenantQuotaProviderMockRecorder) TenantQuota(ctx, tenantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantQuota", reflect.TypeOf((*MockTenantQuotaProvider)(nil).TenantQuota), ctx, tenantID)
+}